@@ -0,0 +1,252 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+package vars
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ArchiveFormat identifies the archives written by varsapi.Export.
+	ArchiveFormat = "vars-archive"
+	// ArchiveVersion is the version of the archive format. Archives of a newer version can't be imported.
+	ArchiveVersion = 1
+)
+
+// ArchiveTable describes a table of the archive. The statements of the archive are built from the descriptions,
+// since they cover every table of the database.
+type ArchiveTable struct {
+	Name        string
+	Columns     []string
+	Serial      string       // Serial ID column, remapped on import
+	Match       string       // Column identifying an existing row of the same entity when merging
+	Owner       string       // Column referencing the row that owns the row, see ArchiveOptions
+	ReplaceOnly bool         // The rows are only restored by a replace
+	Refs        []ArchiveRef // References to the rows of other tables
+}
+
+// ArchiveRef is a reference of an archive table to the rows of another one.
+type ArchiveRef struct {
+	Columns []string // Referencing columns
+	Table   string   // Referenced table
+	Keys    []string // Referenced columns
+}
+
+// ArchiveManifest is the first member of an archive.
+type ArchiveManifest struct {
+	Format      string
+	Version     int
+	Created     time.Time
+	Tables      map[string]int // Number of rows of each table
+	Attachments int            // Number of attachment contents
+}
+
+// ArchiveOptions holds the options of an archive import.
+type ArchiveOptions struct {
+	// Replace deletes all the data of VARS before restoring the archive, keeping the IDs of the archive. Otherwise
+	// the archive is merged: the rows get new IDs, employees, systems, vulnerabilities and templates that exist
+	// with the same name are kept as they are instead of being added, along with the rows they own, and rows that
+	// conflict with existing ones are skipped.
+	Replace bool
+	DryRun  bool // Roll back the import and only report the changes
+}
+
+// ArchiveReport holds the results of an archive import.
+type ArchiveReport struct {
+	DryRun   bool
+	Replace  bool
+	Version  int            // Version of the archive
+	Inserted map[string]int // Rows inserted by table
+	Matched  map[string]int // Rows merged into an existing row of the same name by table
+	Skipped  map[string]int // Rows skipped by table
+}
+
+// ArchiveTables lists the tables of the archive, referenced tables first.
+var ArchiveTables = []*ArchiveTable{
+	{Name: "emp", Columns: []string{"empid", "firstname", "lastname", "email", "username", "role", "active", "deactivated"}, Serial: "empid", Match: "username"},
+	{Name: "rolepermissions", Columns: []string{"role", "permission"}},
+	{Name: "systems", Columns: []string{"sysid", "sysname", "systype", "opsys", "description", "location", "state"}, Serial: "sysid", Match: "sysname"},
+	{Name: "sysaddrs", Columns: []string{"sysid", "addr"}, Owner: "sysid", Refs: []ArchiveRef{sysRef("sysid")}},
+	{Name: "sysports", Columns: []string{"sysid", "port", "protocol", "service"}, Owner: "sysid", Refs: []ArchiveRef{sysRef("sysid")}},
+	{Name: "syscomponents", Columns: []string{"sysid", "name", "version", "purl"}, Owner: "sysid", Refs: []ArchiveRef{sysRef("sysid")}},
+	{Name: "templates", Columns: []string{"templateid", "name", "summary", "test", "mitigation", "cvss", "cvsslink", "corpscore"}, Serial: "templateid", Match: "name"},
+	{Name: "templaterefs", Columns: []string{"templateid", "url"}, Owner: "templateid", Refs: []ArchiveRef{{[]string{"templateid"}, "templates", []string{"templateid"}}}},
+	{Name: "vuln", Columns: []string{"vulnid", "vulnname", "finder", "initiator", "summary", "test", "mitigation"}, Serial: "vulnid", Match: "vulnname", Refs: []ArchiveRef{empRef("finder"), empRef("initiator")}},
+	{Name: "impact", Columns: []string{"vulnid", "cvss", "cvsslink", "corpscore"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid")}},
+	{Name: "dates", Columns: []string{"vulnid", "published", "initiated", "mitigated"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid")}},
+	{Name: "exploits", Columns: []string{"vulnid", "exploitable", "exploit"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid")}},
+	{Name: "cves", Columns: []string{"vulnid", "cve"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid")}},
+	{Name: "cwes", Columns: []string{"vulnid", "cwe"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid")}},
+	{Name: "tickets", Columns: []string{"vulnid", "ticket"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid")}},
+	{Name: "ref", Columns: []string{"vulnid", "url"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid")}},
+	{Name: "related", Columns: []string{"vulnid", "relvulnid", "reltype"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid"), vulnRef("relvulnid")}},
+	{Name: "merges", Columns: []string{"dropid", "dropname", "keepid", "merged"}, Owner: "keepid", ReplaceOnly: true, Refs: []ArchiveRef{vulnRef("keepid")}},
+	{Name: "affected", Columns: []string{"vulnid", "sysid", "mitigated"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid"), sysRef("sysid")}},
+	{Name: "affectedpkgs", Columns: []string{"vulnid", "sysid", "package", "installed", "fixed"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid"), sysRef("sysid"), affRef()}},
+	{Name: "scanmitigations", Columns: []string{"vulnid", "sysid", "scanner", "scan", "mitigated"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid"), sysRef("sysid"), affRef()}},
+	{Name: "scanrefs", Columns: []string{"scanner", "pluginid", "vulnid"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid")}},
+	{Name: "notes", Columns: []string{"noteid", "vulnid", "empid", "added", "note", "parent", "deleted", "public"}, Serial: "noteid", Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid"), empRef("empid"), {[]string{"parent"}, "notes", []string{"noteid"}}}},
+	{Name: "notementions", Columns: []string{"noteid", "empid"}, Owner: "noteid", Refs: []ArchiveRef{noteRef(), empRef("empid")}},
+	{Name: "noterevisions", Columns: []string{"noteid", "revised", "note"}, Owner: "noteid", Refs: []ArchiveRef{noteRef()}},
+	{Name: "attachments", Columns: []string{"attachid", "vulnid", "sysid", "empid", "filename", "mimetype", "size", "sha256", "storekey", "added"}, Serial: "attachid", Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid"), sysRef("sysid"), empRef("empid")}},
+	{Name: "tasks", Columns: []string{"taskid", "vulnid", "title", "assignee", "due", "done", "sysid"}, Serial: "taskid", Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid"), empRef("assignee"), sysRef("sysid")}},
+	{Name: "cve_catalog", Columns: []string{"cve", "summary", "published", "lastmodified", "cvssvector", "cvssscore"}},
+	{Name: "cve_catalog_cwes", Columns: []string{"cve", "cwe"}, Refs: []ArchiveRef{{[]string{"cve"}, "cve_catalog", []string{"cve"}}}},
+	{Name: "cve_catalog_refs", Columns: []string{"cve", "url"}, Refs: []ArchiveRef{{[]string{"cve"}, "cve_catalog", []string{"cve"}}}},
+	{Name: "epss", Columns: []string{"cve", "score", "percentile", "modeldate", "modelversion"}},
+	{Name: "kev", Columns: []string{"cve", "vendor", "product", "name", "action", "dateadded", "duedate", "ransomware"}},
+	{Name: "kevmatches", Columns: []string{"vulnid", "cve", "matched"}, Owner: "vulnid", Refs: []ArchiveRef{vulnRef("vulnid"), {[]string{"cve"}, "kev", []string{"cve"}}}},
+}
+
+// GetArchiveRows returns the rows of the table using the given transaction. Numeric values are returned as
+// strings.
+func GetArchiveRows(tx *sql.Tx, t *ArchiveTable) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	cols := strings.Join(t.Columns, ", ")
+	res, err := tx.Query("SELECT " + cols + " FROM " + t.Name + " ORDER BY " + cols + ";")
+	if err != nil {
+		return rows, newErrFromErr(err, "GetArchiveRows", t.Name)
+	}
+	defer res.Close()
+	for res.Next() {
+		vals := make([]interface{}, len(t.Columns))
+		ptrs := make([]interface{}, len(t.Columns))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := res.Scan(ptrs...); err != nil {
+			return rows, newErrFromErr(err, "GetArchiveRows", t.Name, "rows.Scan")
+		}
+		row := make(map[string]interface{})
+		for i, col := range t.Columns {
+			if b, ok := vals[i].([]byte); ok {
+				vals[i] = string(b)
+			}
+			row[col] = vals[i]
+		}
+		rows = append(rows, row)
+	}
+	if err := res.Err(); err != nil {
+		return rows, newErrFromErr(err, "GetArchiveRows", t.Name)
+	}
+	return rows, nil
+}
+
+// GetArchiveMatch returns the serial ID of the existing row of the table with the value in the Match column using
+// the given transaction.
+func GetArchiveMatch(tx *sql.Tx, t *ArchiveTable, value interface{}) (int64, error) {
+	var id int64
+	err := tx.QueryRow("SELECT "+t.Serial+" FROM "+t.Name+" WHERE "+t.Match+"=$1 ORDER BY "+t.Serial+" LIMIT 1;", value).Scan(&id)
+	if err != nil {
+		return id, newErrFromErr(err, "GetArchiveMatch", t.Name)
+	}
+	return id, nil
+}
+
+// InsertArchiveRow inserts the row into the table using the given transaction. Columns missing from the row are
+// inserted as null. Unless replace is true, a row that conflicts with an existing one is skipped and false is
+// returned.
+func InsertArchiveRow(tx *sql.Tx, t *ArchiveTable, row map[string]interface{}, replace bool) (bool, error) {
+	vals := make([]interface{}, len(t.Columns))
+	params := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		vals[i] = row[col]
+		params[i] = "$" + strconv.Itoa(i+1)
+	}
+	stmt := "INSERT INTO " + t.Name + " (" + strings.Join(t.Columns, ", ") + ") VALUES (" + strings.Join(params, ", ") + ")"
+	if !replace {
+		stmt += " ON CONFLICT DO NOTHING"
+	}
+	res, err := tx.Exec(stmt+";", vals...)
+	if err != nil {
+		return false, newErrFromErr(err, "InsertArchiveRow", t.Name)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, newErrFromErr(err, "InsertArchiveRow", t.Name)
+	}
+	return n > 0, nil
+}
+
+// NextArchiveID returns a new serial ID of the table using the given transaction.
+func NextArchiveID(tx *sql.Tx, t *ArchiveTable) (int64, error) {
+	var id int64
+	err := tx.QueryRow("SELECT nextval('" + t.Name + "_" + t.Serial + "_seq');").Scan(&id)
+	if err != nil {
+		return id, newErrFromErr(err, "NextArchiveID", t.Name)
+	}
+	return id, nil
+}
+
+// ResetArchiveSequences moves the serial sequences of the archive tables past the highest ID of the tables using
+// the given transaction, so the IDs restored by a replace are not handed out again.
+func ResetArchiveSequences(tx *sql.Tx) error {
+	for _, t := range ArchiveTables {
+		if t.Serial == "" {
+			continue
+		}
+		_, err := tx.Exec("SELECT setval('" + t.Name + "_" + t.Serial + "_seq', COALESCE((SELECT MAX(" + t.Serial + ") FROM " + t.Name + "), 0) + 1, false);")
+		if err != nil {
+			return newErrFromErr(err, "ResetArchiveSequences", t.Name)
+		}
+	}
+	return nil
+}
+
+// TruncateArchiveTables deletes the rows of all the archive tables using the given transaction.
+func TruncateArchiveTables(tx *sql.Tx) error {
+	var names []string
+	for _, t := range ArchiveTables {
+		names = append(names, t.Name)
+	}
+	if _, err := tx.Exec("TRUNCATE " + strings.Join(names, ", ") + ";"); err != nil {
+		return newErrFromErr(err, "TruncateArchiveTables")
+	}
+	return nil
+}
+
+// affRef returns the reference of the (vulnid, sysid) columns to affected.
+func affRef() ArchiveRef {
+	return ArchiveRef{[]string{"vulnid", "sysid"}, "affected", []string{"vulnid", "sysid"}}
+}
+
+// empRef returns the reference of the column to emp.
+func empRef(col string) ArchiveRef {
+	return ArchiveRef{[]string{col}, "emp", []string{"empid"}}
+}
+
+// noteRef returns the reference of the noteid column to notes.
+func noteRef() ArchiveRef {
+	return ArchiveRef{[]string{"noteid"}, "notes", []string{"noteid"}}
+}
+
+// sysRef returns the reference of the column to systems.
+func sysRef(col string) ArchiveRef {
+	return ArchiveRef{[]string{col}, "systems", []string{"sysid"}}
+}
+
+// vulnRef returns the reference of the column to vuln.
+func vulnRef(col string) ArchiveRef {
+	return ArchiveRef{[]string{col}, "vuln", []string{"vulnid"}}
+}
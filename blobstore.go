@@ -0,0 +1,149 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+package vars
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// DefaultAttachDir is the directory used by the file blob store when AttachDir is not configured.
+	DefaultAttachDir = "/var/lib/vars/attachments"
+	// DefaultAttachMaxSize is the maximum attachment size (in bytes) used when AttachMaxSize is not configured.
+	DefaultAttachMaxSize = 10 << 20
+)
+
+// DefaultAttachTypes are the MIME types that can be attached when AttachTypes is not configured.
+var DefaultAttachTypes = []string{"application/pdf", "application/zip", "image/gif", "image/jpeg", "image/png", "text/plain"}
+
+// BlobStore is implemented by the backends that hold the contents of attachments.
+// Put returns the key the contents were stored under. Stores that are transactional
+// perform their work inside of tx, stores that are not ignore it.
+type BlobStore interface {
+	Put(tx *sql.Tx, data []byte) (string, error)
+	Get(key string) ([]byte, error)
+	Delete(tx *sql.Tx, key string) error
+	Transactional() bool
+}
+
+// FileStore keeps attachment contents as files in Dir.
+type FileStore struct {
+	Dir string
+}
+
+// Put writes data to a new file in the store directory.
+func (fs *FileStore) Put(tx *sql.Tx, data []byte) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", newErrFromErr(err, "FileStore", "Put")
+	}
+	key := hex.EncodeToString(b)
+	if err := os.MkdirAll(fs.Dir, 0750); err != nil {
+		return "", newErrFromErr(err, "FileStore", "Put")
+	}
+	if err := ioutil.WriteFile(filepath.Join(fs.Dir, key), data, 0640); err != nil {
+		return "", newErrFromErr(err, "FileStore", "Put")
+	}
+	return key, nil
+}
+
+// Get reads the file stored under key.
+func (fs *FileStore) Get(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(fs.Dir, filepath.Base(key)))
+	if err != nil {
+		return data, newErrFromErr(err, "FileStore", "Get")
+	}
+	return data, nil
+}
+
+// Delete removes the file stored under key. A missing file is not an error.
+func (fs *FileStore) Delete(tx *sql.Tx, key string) error {
+	err := os.Remove(filepath.Join(fs.Dir, filepath.Base(key)))
+	if err != nil && !os.IsNotExist(err) {
+		return newErrFromErr(err, "FileStore", "Delete")
+	}
+	return nil
+}
+
+// Transactional returns false since files are written outside of the database.
+func (fs *FileStore) Transactional() bool {
+	return false
+}
+
+// LargeObjectStore keeps attachment contents as Postgresql large objects. The key is the oid.
+type LargeObjectStore struct{}
+
+// Put creates a new large object holding data.
+func (ls *LargeObjectStore) Put(tx *sql.Tx, data []byte) (string, error) {
+	var oid int64
+	err := tx.Stmt(queries[ssInsertLargeObject]).QueryRow(data).Scan(&oid)
+	if err != nil {
+		return "", newErrFromErr(err, execNames[ssInsertLargeObject])
+	}
+	return strconv.FormatInt(oid, 10), nil
+}
+
+// Get returns the contents of the large object with the oid key.
+func (ls *LargeObjectStore) Get(key string) ([]byte, error) {
+	var data []byte
+	err := queries[ssGetLargeObject].QueryRow(key).Scan(&data)
+	if err != nil {
+		return data, newErrFromErr(err, execNames[ssGetLargeObject])
+	}
+	return data, nil
+}
+
+// Delete unlinks the large object with the oid key.
+func (ls *LargeObjectStore) Delete(tx *sql.Tx, key string) error {
+	var res int
+	err := tx.Stmt(queries[ssDeleteLargeObject]).QueryRow(key).Scan(&res)
+	if err != nil {
+		return newErrFromErr(err, execNames[ssDeleteLargeObject])
+	}
+	return nil
+}
+
+// Transactional returns true since large objects are part of the database transaction.
+func (ls *LargeObjectStore) Transactional() bool {
+	return true
+}
+
+// GetBlobStore returns the BlobStore selected by Conf.AttachStore.
+func GetBlobStore() (BlobStore, error) {
+	switch Conf.AttachStore {
+	case "", "file":
+		dir := Conf.AttachDir
+		if dir == "" {
+			dir = DefaultAttachDir
+		}
+		return &FileStore{Dir: dir}, nil
+	case "largeobject":
+		return &LargeObjectStore{}, nil
+	default:
+		return nil, newErr(unknownType, "GetBlobStore")
+	}
+}
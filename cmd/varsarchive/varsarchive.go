@@ -0,0 +1,147 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Varsarchive exports VARS to an archive and imports it back, for moving an instance or taking a snapshot before
+// an upgrade.
+//
+// Usage:
+//
+//	varsarchive export FILE   Write an archive of all the data of VARS to FILE (- for stdout)
+//	varsarchive [-replace] [-dry-run] import FILE
+//	                          Merge the archive FILE (- for stdin) into VARS, or replace all the data of VARS
+//	                          with it when -replace is given
+//
+// The VARS configuration file is read from VARS_CONFIG or /etc/vars/vars.conf.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/cbelk/vars"
+	"github.com/cbelk/vars/pkg/varsapi"
+)
+
+var (
+	logError = log.New(os.Stderr, "Vars-Error: ", log.Ldate|log.Ltime)
+	logInfo  = log.New(os.Stderr, "Vars-Info: ", log.Ldate|log.Ltime)
+
+	dryRun  = flag.Bool("dry-run", false, "Only report the changes an import would make")
+	replace = flag.Bool("replace", false, "Delete all the data of VARS before importing the archive")
+)
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) != 2 || (args[0] != "export" && args[0] != "import") {
+		usage()
+	}
+
+	readVarsConfig()
+	db, err := varsapi.ConnectDB()
+	if err != nil {
+		logError.Fatal(err)
+	}
+	defer varsapi.CloseDB(db)
+
+	if args[0] == "export" {
+		var w io.WriteCloser = os.Stdout
+		if args[1] != "-" {
+			if w, err = os.Create(args[1]); err != nil {
+				logError.Fatal(err)
+			}
+		}
+		man, err := varsapi.Export(db, w)
+		if e := w.Close(); err == nil {
+			err = e
+		}
+		if err != nil {
+			// Don't leave a truncated archive behind
+			if args[1] != "-" {
+				os.Remove(args[1])
+			}
+			logError.Fatal(err)
+		}
+		printCounts(os.Stderr, "exported", man.Tables)
+		logInfo.Printf("%s: archive version %d, %d attachments", args[1], man.Version, man.Attachments)
+		return
+	}
+
+	var r io.ReadCloser = os.Stdin
+	if args[1] != "-" {
+		if r, err = os.Open(args[1]); err != nil {
+			logError.Fatal(err)
+		}
+	}
+	defer r.Close()
+	rep, err := varsapi.Import(db, r, &vars.ArchiveOptions{Replace: *replace, DryRun: *dryRun})
+	if err != nil {
+		logError.Fatal(err)
+	}
+	verb := "inserted"
+	if rep.DryRun {
+		verb = "to insert"
+	}
+	printCounts(os.Stdout, verb, rep.Inserted)
+	printCounts(os.Stdout, "matched", rep.Matched)
+	printCounts(os.Stdout, "skipped", rep.Skipped)
+	mode := "merged"
+	if rep.Replace {
+		mode = "restored"
+	}
+	logInfo.Printf("%s: archive version %d %s", args[1], rep.Version, mode)
+}
+
+// printCounts prints the row counts of the tables that have rows to w.
+func printCounts(w io.Writer, label string, counts map[string]int) {
+	var names []string
+	for name, n := range counts {
+		if n > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", label, name, counts[name])
+	}
+}
+
+// readVarsConfig reads the vars.conf file from VARS_CONFIG or the default location.
+func readVarsConfig() {
+	config := os.Getenv("VARS_CONFIG")
+	if config == "" {
+		config = "/etc/vars/vars.conf"
+	}
+	if err := varsapi.ReadConfig(config); err != nil {
+		logError.Fatal(err)
+	}
+}
+
+// usage prints the usage and exits.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s export FILE | [-replace] [-dry-run] import FILE\n", os.Args[0])
+	flag.PrintDefaults()
+	os.Exit(2)
+}
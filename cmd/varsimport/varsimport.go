@@ -0,0 +1,401 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Varsimport loads data downloaded out of band into VARS.
+//
+// Usage:
+//
+//	varsimport epss FILE...   Import FIRST EPSS daily score files (optionally gzipped)
+//	varsimport -user USERNAME [-dry-run] [-reconcile] grype FILE...
+//	                          Import Grype JSON output, recording each scanned image as a container-image system
+//	varsimport kev FILE...    Import the CISA KEV catalog (JSON or CSV) and flag the vulnerabilities in it
+//	varsimport nvd FILE...    Import NVD CVE JSON 2.0 feeds (optionally gzipped) into the CVE catalog
+//	varsimport -user USERNAME [-dry-run] [-reconcile] nessus FILE...
+//	                          Import Nessus v2 scan reports, recording USERNAME as the finder of new vulnerabilities
+//	varsimport [-dry-run] nmap FILE...
+//	                          Import Nmap XML output into the systems inventory and list the systems that were not seen
+//	varsimport -user USERNAME [-dry-run] [-reconcile] openvas FILE...
+//	                          Import OpenVAS / GVM XML reports, recording USERNAME as the finder of new vulnerabilities
+//	varsimport [-dry-run] osv DIR...
+//	                          Import the OSV JSON records of directories (e.g. an unzipped osv.dev ecosystem dump) into
+//	                          the CVE catalog
+//	varsimport -user USERNAME [-dry-run] -vulns osv DIR...
+//	                          Start a vulnerability for each OSV record that isn't tracked yet
+//	varsimport -system NAME [-dry-run] sbom FILE
+//	                          Replace the components of system NAME with those of a CycloneDX or SPDX SBOM and list
+//	                          the vulnerabilities that may affect it
+//	varsimport [-dry-run] [-best-effort] systems FILE...
+//	                          Add the systems of CSV files (see varsapi.SystemsCsvColumns); a file with a rejected row
+//	                          adds nothing unless -best-effort is given
+//	varsimport -user USERNAME [-dry-run] [-reconcile] trivy FILE...
+//	                          Import Trivy JSON output, recording each scanned image as a container-image system
+//
+// With -reconcile the affected systems of the scanned hosts are mitigated and reopened to match the scan, and the
+// systems changed by hand are listed as conflicts.
+//
+// The VARS configuration file is read from VARS_CONFIG or /etc/vars/vars.conf.
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/cbelk/vars"
+	"github.com/cbelk/vars/pkg/epss"
+	"github.com/cbelk/vars/pkg/kev"
+	"github.com/cbelk/vars/pkg/nvd"
+	"github.com/cbelk/vars/pkg/varsapi"
+)
+
+var (
+	logError = log.New(os.Stderr, "Vars-Error: ", log.Ldate|log.Ltime)
+	logInfo  = log.New(os.Stdout, "Vars-Info: ", log.Ldate|log.Ltime)
+
+	bestEffort = flag.Bool("best-effort", false, "Add the valid rows of a systems CSV when other rows are rejected")
+	dryRun     = flag.Bool("dry-run", false, "Only report the changes an import would make")
+	reconcile  = flag.Bool("reconcile", false, "Mitigate and reopen the affected systems of the scanned hosts to match the scan")
+	system     = flag.String("system", "", "Name of the system an SBOM import lists the components of")
+	user       = flag.String("user", "", "Username recorded as the finder of the vulnerabilities started by a scan or OSV import")
+	vulns      = flag.Bool("vulns", false, "Start vulnerabilities for the OSV records instead of adding them to the CVE catalog")
+)
+
+// importers maps the import commands to the functions that import the given files.
+var importers = map[string]func(db *sql.DB, files []string) error{
+	"epss":    importEpss,
+	"grype":   scanImporter(varsapi.ImportGrype),
+	"kev":     importKev,
+	"nessus":  scanImporter(varsapi.ImportNessus),
+	"nmap":    importNmap,
+	"nvd":     importNvd,
+	"openvas": scanImporter(varsapi.ImportOpenVas),
+	"osv":     importOsv,
+	"sbom":    importSbom,
+	"systems": importSystems,
+	"trivy":   scanImporter(varsapi.ImportTrivy),
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 2 {
+		usage()
+	}
+	imp, ok := importers[args[0]]
+	if !ok {
+		usage()
+	}
+
+	readVarsConfig()
+	db, err := varsapi.ConnectDB()
+	if err != nil {
+		logError.Fatal(err)
+	}
+	defer varsapi.CloseDB(db)
+
+	if err := imp(db, args[1:]); err != nil {
+		logError.Fatal(err)
+	}
+}
+
+// importEpss imports the EPSS scores.
+func importEpss(db *sql.DB, files []string) error {
+	for _, file := range files {
+		scores, err := epss.ParseFile(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		imported, unchanged, err := varsapi.ImportEpss(db, scores)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		logInfo.Printf("%s: %d EPSS scores imported, %d already up to date", file, imported, unchanged)
+	}
+	return nil
+}
+
+// formatPorts formats the ports as a comma separated list of port/protocol.
+func formatPorts(ports []*vars.SysPort) string {
+	var s []string
+	for _, p := range ports {
+		s = append(s, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+	}
+	return strings.Join(s, ",")
+}
+
+// importKev imports the KEV catalog and lists the vulnerabilities that were newly matched to it.
+func importKev(db *sql.DB, files []string) error {
+	for _, file := range files {
+		entries, err := kev.ParseFile(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		matches, err := varsapi.ImportKev(db, entries)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		logInfo.Printf("%s: %d KEV entries imported, %d new matches", file, len(entries), len(matches))
+		for _, m := range matches {
+			fmt.Printf("%d\t%s\t%s\n", m.VulnID, m.VulnName, m.Cve)
+		}
+	}
+	return nil
+}
+
+// importNmap imports the Nmap output into the systems inventory and prints the changes that were made, or would be
+// made for a dry run.
+func importNmap(db *sql.DB, files []string) error {
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		diff, err := varsapi.ImportNmap(db, f, &vars.ScanOptions{DryRun: *dryRun})
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		printInventoryDiff(file, diff)
+	}
+	return nil
+}
+
+// importNvd imports the NVD feeds into the CVE catalog. Each file is imported in its own transaction.
+func importNvd(db *sql.DB, files []string) error {
+	for _, file := range files {
+		entries, err := nvd.ParseFile(file)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		imported, unchanged, err := varsapi.ImportCatalog(db, entries)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		logInfo.Printf("%s: %d CVEs imported, %d already up to date", file, imported, unchanged)
+	}
+	return nil
+}
+
+// importOsv imports the OSV records of the directories into the CVE catalog, or as new vulnerabilities with -vulns.
+// Each directory is imported in its own transaction.
+func importOsv(db *sql.DB, dirs []string) error {
+	opts := vars.OsvOptions{DryRun: *dryRun, Vulns: *vulns}
+	if *vulns {
+		if *user == "" {
+			return errors.New("-user is required to start vulnerabilities")
+		}
+		emp, err := varsapi.GetEmployeeByUsername(*user)
+		if !varsapi.IsNilErr(err) {
+			return fmt.Errorf("%s: %v", *user, err)
+		}
+		opts.Employee = emp.ID
+	}
+	for _, dir := range dirs {
+		rep, err := varsapi.ImportOSV(db, dir, &opts)
+		if err != nil {
+			return fmt.Errorf("%s: %v", dir, err)
+		}
+		if *vulns {
+			verb := "started"
+			if rep.DryRun {
+				verb = "to start"
+			}
+			logInfo.Printf("%s: %d records, %d vulnerabilities %s, %d already tracked, %d skipped", dir, rep.Records,
+				len(rep.NewVulns), verb, len(rep.Tracked), len(rep.Skipped))
+			for _, vuln := range rep.NewVulns {
+				fmt.Printf("vulnerability\t%s\t%v\n", vuln.Name, vuln.Cves)
+			}
+			continue
+		}
+		logInfo.Printf("%s: %d records, %d CVEs imported, %d already up to date, %d skipped", dir, rep.Records,
+			rep.Imported, rep.Unchanged, len(rep.Skipped))
+	}
+	return nil
+}
+
+// importSbom replaces the components of the system with those of the SBOM and prints the changes and the candidate
+// affected rows.
+func importSbom(db *sql.DB, files []string) error {
+	if *system == "" {
+		return errors.New("-system is required for SBOM imports")
+	}
+	if len(files) != 1 {
+		return errors.New("an SBOM import takes a single file")
+	}
+	sys, err := varsapi.GetSystemByName(*system)
+	if !varsapi.IsNilErr(err) {
+		return fmt.Errorf("%s: %v", *system, err)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	diff, err := varsapi.ImportSbom(db, sys.ID, f, &vars.ScanOptions{DryRun: *dryRun})
+	if err != nil {
+		return fmt.Errorf("%s: %v", files[0], err)
+	}
+	verb := "added"
+	if diff.DryRun {
+		verb = "to add"
+	}
+	logInfo.Printf("%s: %s SBOM with %d components, %d %s, %d removed, %d candidate affected systems", files[0],
+		diff.Format, diff.Components, len(diff.Added), verb, len(diff.Removed), len(diff.Candidates))
+	for _, c := range diff.Added {
+		fmt.Printf("added\t%s\t%s\t%s\n", c.Name, c.Version, c.Purl)
+	}
+	for _, c := range diff.Removed {
+		fmt.Printf("removed\t%s\t%s\t%s\n", c.Name, c.Version, c.Purl)
+	}
+	for _, c := range diff.Candidates {
+		fmt.Printf("candidate\t%s\t%s\t%s %s\t%s\n", c.Vuln, c.System, c.Component, c.Version, c.Reason)
+	}
+	return nil
+}
+
+// importSystems adds the systems of the CSV files and prints the rows that were rejected.
+func importSystems(db *sql.DB, files []string) error {
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		rep, err := varsapi.ImportSystemsCsv(db, f, &vars.CsvOptions{BestEffort: *bestEffort, DryRun: *dryRun})
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		added := len(rep.Added)
+		if !rep.Committed {
+			added = 0
+		}
+		logInfo.Printf("%s: %d rows, %d systems added, %d rejected", file, rep.Rows, added, len(rep.Rejected))
+		for _, col := range rep.Ignored {
+			fmt.Printf("ignored-column\t%s\n", col)
+		}
+		for _, e := range rep.Rejected {
+			fmt.Printf("rejected\t%d\t%s\t%s\n", e.Row, e.Name, strings.Join(e.Errors, "; "))
+		}
+	}
+	return nil
+}
+
+// printInventoryDiff prints the changes of an inventory import.
+func printInventoryDiff(file string, diff *vars.InventoryDiff) {
+	verb := "added"
+	if diff.DryRun {
+		verb = "to add"
+	}
+	logInfo.Printf("%s: %d systems %s, %d changed, %d unchanged, %d not seen", file,
+		len(diff.NewSystems), verb, len(diff.Changed), diff.Unchanged, len(diff.InactiveCandidates))
+	for _, sys := range diff.NewSystems {
+		fmt.Printf("new\t%s\t%s\t%s\n", sys.Name, sys.OpSys, formatPorts(sys.Ports))
+	}
+	for _, c := range diff.Changed {
+		fmt.Printf("changed\t%s\tos=%s\taddresses=%v\topened=%s\tclosed=%s\n", c.System.Name, c.OpSys,
+			c.NewAddresses, formatPorts(c.OpenedPorts), formatPorts(c.ClosedPorts))
+	}
+	for _, sys := range diff.InactiveCandidates {
+		fmt.Printf("not-seen\t%s\t%v\n", sys.Name, sys.Addresses)
+	}
+}
+
+// printScanDiff prints the changes of a scan import.
+func printScanDiff(file string, diff *vars.ScanDiff) {
+	verb := "added"
+	if diff.DryRun {
+		verb = "to add"
+	}
+	logInfo.Printf("%s: %d systems, %d vulnerabilities and %d affected systems %s, %d mitigated, %d reopened, %d conflicts, %d unchanged, %d unknown hosts",
+		file, len(diff.NewSystems), len(diff.NewVulns), len(diff.NewAffected), verb, len(diff.Mitigated), len(diff.Reopened),
+		len(diff.Conflicts), diff.Unchanged, len(diff.UnknownHosts))
+	for _, sys := range diff.NewSystems {
+		fmt.Printf("system\t%s\t%s\n", sys.Name, sys.OpSys)
+	}
+	for _, vuln := range diff.NewVulns {
+		fmt.Printf("vulnerability\t%s\t%v\n", vuln.Name, vuln.Cves)
+	}
+	for _, aff := range diff.NewAffected {
+		fmt.Printf("affected\t%s\t%s\n", aff.Vuln, aff.System)
+	}
+	for _, aff := range diff.Mitigated {
+		fmt.Printf("mitigated\t%s\t%s\n", aff.Vuln, aff.System)
+	}
+	for _, aff := range diff.Reopened {
+		fmt.Printf("reopened\t%s\t%s\n", aff.Vuln, aff.System)
+	}
+	for _, c := range diff.Conflicts {
+		fmt.Printf("conflict\t%s\t%s\t%s\n", c.Vuln, c.System, c.Reason)
+	}
+	for _, host := range diff.UnknownHosts {
+		fmt.Printf("unknown\t%s\n", host)
+	}
+}
+
+// readVarsConfig reads the vars.conf file from VARS_CONFIG or the default location.
+func readVarsConfig() {
+	config := os.Getenv("VARS_CONFIG")
+	if config == "" {
+		config = "/etc/vars/vars.conf"
+	}
+	if err := varsapi.ReadConfig(config); err != nil {
+		logError.Fatal(err)
+	}
+}
+
+// scanImporter returns the function importing scan reports with imp. The changes that were made, or would be made
+// for a dry run, are printed.
+func scanImporter(imp func(*sql.DB, io.Reader, *vars.ScanOptions) (*vars.ScanDiff, error)) func(*sql.DB, []string) error {
+	return func(db *sql.DB, files []string) error {
+		if *user == "" {
+			return errors.New("-user is required for scan imports")
+		}
+		emp, err := varsapi.GetEmployeeByUsername(*user)
+		if !varsapi.IsNilErr(err) {
+			return fmt.Errorf("%s: %v", *user, err)
+		}
+		for _, file := range files {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			diff, err := imp(db, f, &vars.ScanOptions{DryRun: *dryRun, Employee: emp.ID, Reconcile: *reconcile})
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+			printScanDiff(file, diff)
+		}
+		return nil
+	}
+}
+
+// usage prints the usage and exits.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [-user USERNAME] [-system NAME] [-dry-run] [-reconcile] [-best-effort] [-vulns] epss|grype|kev|nessus|nmap|nvd|openvas|osv|sbom|systems|trivy FILE...\n", os.Args[0])
+	flag.PrintDefaults()
+	os.Exit(2)
+}
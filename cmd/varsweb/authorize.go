@@ -0,0 +1,119 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/cbelk/vars"
+	"github.com/julienschmidt/httprouter"
+)
+
+// userKey is the context key used to pass the User from authorize to the handlers.
+type userKey struct{}
+
+// permRule returns the permission required for a request to a route.
+type permRule func(ps httprouter.Params) string
+
+var (
+	empGetPerms = permByParam("emp", vars.PermEmployeeManage, map[string]string{
+		"name": vars.PermEmployeeView,
+		"list": vars.PermEmployeeList,
+	})
+	importPerms = permByParam("scanner", vars.PermScanImport, map[string]string{
+		"systems": vars.PermSystemCreate,
+	})
+	vulnPutPerms = permByParam("field", vars.PermVulnUpdate, map[string]string{
+		"affected":   vars.PermAffectedUpdate,
+		"attachment": vars.PermAttachmentWrite,
+		"mitigated":  vars.PermVulnClose,
+		"note":       vars.PermNoteWrite,
+	})
+	vulnDeletePerms = permByParam("field", vars.PermVulnUpdate, map[string]string{
+		"affected":   vars.PermAffectedUpdate,
+		"attachment": vars.PermAttachmentWrite,
+		"mitigated":  vars.PermVulnClose,
+		"note":       vars.PermNoteWrite,
+		"vuln":       vars.PermVulnDelete,
+	})
+	vulnPostPerms = permByParam("field", vars.PermVulnUpdate, map[string]string{
+		"affected": vars.PermAffectedUpdate,
+		"autofill": vars.PermVulnUpdate,
+		"clone":    vars.PermVulnCreate,
+		"finder":   vars.PermVulnAssign,
+		"merge":    vars.PermVulnDelete,
+		"name":     vars.PermVulnRename,
+	})
+)
+
+// perm returns a permRule that always requires the permission p.
+func perm(p string) permRule {
+	return func(_ httprouter.Params) string {
+		return p
+	}
+}
+
+// permByParam returns a permRule that looks up the permission by the value of the route parameter
+// param. Values that are not in perms require the permission def.
+func permByParam(param, def string, perms map[string]string) permRule {
+	return func(ps httprouter.Params) string {
+		if p, ok := perms[ps.ByName(param)]; ok {
+			return p
+		}
+		return def
+	}
+}
+
+// authorize wraps the handler so that it is only called for users that are logged in and whose role
+// has the permission required by the rule. The User is passed to the handler in the request context.
+func authorize(rule permRule, h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		user, err := getSession(r)
+		if err != nil {
+			logRequest(r)
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !user.Authed {
+			logRequest(r)
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		p := rule(ps)
+		if !user.Can(p) {
+			logRequest(r)
+			logInfo.Printf("Unauthorized request. Username: %s | Permission: %s | Client IP/Port: %s\n", user.Emp.UserName, p, r.RemoteAddr)
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			err := templates.Lookup("notauthorized-get").Execute(w, user)
+			if err != nil {
+				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
+				http.Error(w, "Error with templating", http.StatusInternalServerError)
+			}
+			return
+		}
+		h(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)), ps)
+	}
+}
@@ -0,0 +1,233 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/cbelk/vars/pkg/varsapi"
+)
+
+var (
+	mdHeading  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdULItem   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdOLItem   = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdQuote    = regexp.MustCompile(`^\s*&gt;\s?(.*)$`)
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdStrong   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdEm       = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+	mdToken    = regexp.MustCompile("\x00(\\d+)\x00")
+	mdSchemes  = []string{"http", "https", "mailto"}
+	mdFenceTag = "```"
+)
+
+// mentionResolver returns the display name of the employee with the given username and whether
+// the username belongs to an employee.
+type mentionResolver func(username string) (string, bool)
+
+// renderMarkdown renders the Markdown subset used in notes (headings, paragraphs, lists, block quotes,
+// fenced and inline code, emphasis, links and @mentions) to HTML. All of the text is HTML escaped
+// before any markup is added so raw HTML in the note is never passed through, and links are only
+// created for http, https, mailto and relative URLs.
+func renderMarkdown(src string, mention mentionResolver) template.HTML {
+	var b strings.Builder
+	var para, list []string
+	var listTag string
+	inFence := false
+
+	flushPara := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+			para = nil
+		}
+	}
+	flushList := func() {
+		if len(list) > 0 {
+			b.WriteString("<" + listTag + ">\n")
+			for _, item := range list {
+				b.WriteString("<li>" + item + "</li>\n")
+			}
+			b.WriteString("</" + listTag + ">\n")
+			list = nil
+		}
+	}
+	var quote []string
+	flushQuote := func() {
+		if len(quote) > 0 {
+			b.WriteString("<blockquote>" + strings.Join(quote, "<br>\n") + "</blockquote>\n")
+			quote = nil
+		}
+	}
+	flush := func() {
+		flushPara()
+		flushList()
+		flushQuote()
+	}
+
+	src = strings.Replace(src, "\r\n", "\n", -1)
+	for _, line := range strings.Split(src, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), mdFenceTag) {
+			if inFence {
+				b.WriteString("</code></pre>\n")
+			} else {
+				flush()
+				b.WriteString("<pre><code>")
+			}
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			b.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		esc := html.EscapeString(line)
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			flush()
+			n := len(m[1])
+			b.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", n, renderInline(m[2], mention), n))
+		} else if m := mdQuote.FindStringSubmatch(esc); m != nil {
+			flushPara()
+			flushList()
+			quote = append(quote, renderInline(html.UnescapeString(m[1]), mention))
+		} else if m := mdULItem.FindStringSubmatch(line); m != nil {
+			if listTag != "ul" {
+				flushList()
+			}
+			flushPara()
+			flushQuote()
+			listTag = "ul"
+			list = append(list, renderInline(m[1], mention))
+		} else if m := mdOLItem.FindStringSubmatch(line); m != nil {
+			if listTag != "ol" {
+				flushList()
+			}
+			flushPara()
+			flushQuote()
+			listTag = "ol"
+			list = append(list, renderInline(m[1], mention))
+		} else {
+			flushList()
+			flushQuote()
+			para = append(para, renderInline(line, mention))
+		}
+	}
+	if inFence {
+		b.WriteString("</code></pre>\n")
+	}
+	flush()
+	return template.HTML(b.String())
+}
+
+// renderInline renders the inline Markdown of a single line to HTML. Code spans are escaped and left
+// alone, the rest of the text goes through renderSpan.
+func renderInline(s string, mention mentionResolver) string {
+	parts := strings.Split(s, "`")
+	if len(parts)%2 == 0 {
+		// Unmatched backtick, treat it as text
+		last := len(parts) - 1
+		parts[last-1] = parts[last-1] + "`" + parts[last]
+		parts = parts[:last]
+	}
+	var b strings.Builder
+	for i, p := range parts {
+		if i%2 == 1 {
+			b.WriteString("<code>" + html.EscapeString(p) + "</code>")
+		} else {
+			b.WriteString(renderSpan(p, mention))
+		}
+	}
+	return b.String()
+}
+
+// renderSpan escapes s and adds the links, emphasis and mentions. Links are swapped out for tokens
+// while the rest of the markup is added so that nothing is added inside of an href. NUL characters
+// are dropped from s first so that the text itself can never contain a token.
+func renderSpan(s string, mention mentionResolver) string {
+	var links []string
+	esc := html.EscapeString(strings.Replace(s, "\x00", "", -1))
+	esc = mdLink.ReplaceAllStringFunc(esc, func(m string) string {
+		sm := mdLink.FindStringSubmatch(m)
+		href := html.UnescapeString(sm[2])
+		if !safeURL(href) {
+			return m
+		}
+		links = append(links, fmt.Sprintf(`<a href="%s" rel="noopener noreferrer">%s</a>`, html.EscapeString(href), renderEmphasis(sm[1])))
+		return fmt.Sprintf("\x00%d\x00", len(links)-1)
+	})
+	esc = renderEmphasis(esc)
+	esc = varsapi.MentionRegexp.ReplaceAllStringFunc(esc, func(m string) string {
+		i := strings.Index(m, "@")
+		username := m[i+1:]
+		if mention == nil {
+			return m
+		}
+		name, ok := mention(username)
+		if !ok {
+			return m
+		}
+		return fmt.Sprintf(`%s<span class="vars-mention" title="%s">@%s</span>`, m[:i], html.EscapeString(name), username)
+	})
+	return mdToken.ReplaceAllStringFunc(esc, func(m string) string {
+		var i int
+		if _, err := fmt.Sscanf(strings.Trim(m, "\x00"), "%d", &i); err != nil || i < 0 || i >= len(links) {
+			return ""
+		}
+		return links[i]
+	})
+}
+
+// renderEmphasis adds the strong and em tags to already escaped text.
+func renderEmphasis(esc string) string {
+	esc = mdStrong.ReplaceAllStringFunc(esc, func(m string) string {
+		sm := mdStrong.FindStringSubmatch(m)
+		return "<strong>" + sm[1] + sm[2] + "</strong>"
+	})
+	return mdEm.ReplaceAllStringFunc(esc, func(m string) string {
+		sm := mdEm.FindStringSubmatch(m)
+		return "<em>" + sm[1] + sm[2] + "</em>"
+	})
+}
+
+// safeURL returns true if the URL is relative or uses one of the allowed schemes.
+func safeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		return u.Host == "" && !strings.HasPrefix(href, "//")
+	}
+	for _, s := range mdSchemes {
+		if strings.EqualFold(u.Scheme, s) {
+			return true
+		}
+	}
+	return false
+}
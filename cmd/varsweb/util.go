@@ -30,6 +30,8 @@ import (
 	"plugin"
 	"regexp"
 	"strings"
+
+	"github.com/cbelk/vars"
 )
 
 type Config struct {
@@ -138,6 +140,9 @@ func ReadVarsConfig() {
 	if err != nil {
 		logError.Fatal(err)
 	}
+
+	// The varsapi reads the attachment, role and import settings from vars.Conf
+	vars.Conf = Conf
 }
 
 // ReadWebConfig sets the path to the varsweb.conf file and builds the varsweb Config object with its contents.
@@ -21,28 +21,43 @@
 package main
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"html/template"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/http/httputil"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/alexedwards/scs"
 	"github.com/cbelk/vars"
+	"github.com/cbelk/vars/pkg/stix"
 	"github.com/cbelk/vars/pkg/varsapi"
 	"github.com/julienschmidt/httprouter"
 )
 
-const (
-	AdminUser      = 0
-	PrivilegedUser = 1
-	StandardUser   = 2
-	Reporter       = 3
-)
+// maxScanSize is the maximum size of an uploaded scan report.
+const maxScanSize = 256 << 20
+
+// maxLoggedBody is the maximum size of a request body that is written to the info log.
+const maxLoggedBody = 64 << 10
+
+// mediaTypes maps the download formats to their media types.
+var mediaTypes = map[string]string{
+	"json":  "application/json",
+	"csv":   "text/csv",
+	"pdf":   "application/pdf",
+	"sarif": "application/sarif+json",
+	"stix":  "application/stix+json",
+	"xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
 
 var (
 	Conf           vars.Config
@@ -54,6 +69,12 @@ var (
 type User struct {
 	Authed bool
 	Emp    *vars.Employee
+	roles  vars.Roles // Roles as of the request, loaded by getSession
+}
+
+// Can returns true if the user is authed and their role has the permission.
+func (u User) Can(perm string) bool {
+	return u.Authed && u.Emp != nil && u.roles.Can(u.Emp.Role, perm)
 }
 
 func main() {
@@ -94,30 +115,41 @@ func main() {
 	router.GET("/login", handleLoginGet)
 	router.POST("/login", handleLoginPost)
 	router.GET("/logout", handleLogout)
-	router.PUT("/employee", handleEmployeeAdd)
-	router.GET("/employee", handleEmployeePage)
-	router.DELETE("/employee/:emp", handleEmployeeDelete)
-	router.GET("/employee/:emp", handleEmployees)
-	router.GET("/employee/:emp/:id", handleEmployees)
-	router.POST("/employee/:emp/:field", handleEmployeePost)
-	router.GET("/notes/:vuln", handleNotes)
-	router.POST("/notes/:noteid", handleNotesPost)
-	router.GET("/report", handleReportPage)
-	router.GET("/report/:report", handleReport)
-	router.PUT("/system", handleSystemAdd)
-	router.GET("/system", handleSystemPage)
-	router.GET("/system/:sys", handleSystems)
-	router.DELETE("/system/:sys", handleSystemDelete)
-	router.POST("/system/:sys/:field", handleSystemPost)
-	router.PUT("/vulnerability", handleVulnerabilityAdd)
-	router.GET("/vulnerability", handleVulnerabilityPage)
-	router.GET("/vulnerability/:vuln", handleVulnerabilities)
-	router.GET("/vulnerability/:vuln/:field", handleVulnerabilityField)
-	router.PUT("/vulnerability/:vuln/:field", handleVulnerabilityPut)
-	router.POST("/vulnerability/:vuln/:field", handleVulnerabilityPost)
-	router.DELETE("/vulnerability/:vuln/:field", handleVulnerabilityDelete)
-	router.POST("/vulnerability/:vuln/:field/:item", handleVulnerabilityPost)
-	router.DELETE("/vulnerability/:vuln/:field/:item", handleVulnerabilityDelete)
+	router.PUT("/employee", authorize(perm(vars.PermEmployeeManage), handleEmployeeAdd))
+	router.GET("/employee", authorize(perm(vars.PermEmployeeManage), handleEmployeePage))
+	router.DELETE("/employee/:emp", authorize(perm(vars.PermEmployeeManage), handleEmployeeDelete))
+	router.GET("/employee/:emp", authorize(empGetPerms, handleEmployees))
+	router.GET("/employee/:emp/:id", authorize(empGetPerms, handleEmployees))
+	router.POST("/employee/:emp/:field", authorize(perm(vars.PermEmployeeManage), handleEmployeePost))
+	router.POST("/import/:scanner", authorize(importPerms, handleImport))
+	router.GET("/kev", authorize(perm(vars.PermVulnView), handleKevMatches))
+	router.GET("/notes/:vuln", authorize(perm(vars.PermVulnView), handleNotes))
+	router.POST("/notes/:noteid", authorize(perm(vars.PermNoteWrite), handleNotesPost))
+	router.GET("/report", authorize(perm(vars.PermReportView), handleReportPage))
+	router.GET("/report/:report", authorize(perm(vars.PermReportView), handleReport))
+	router.GET("/role", authorize(perm(vars.PermRoleManage), handleRoles))
+	router.POST("/role/:role", authorize(perm(vars.PermRoleManage), handleRolePost))
+	router.DELETE("/role/:role", authorize(perm(vars.PermRoleManage), handleRoleDelete))
+	router.PUT("/system", authorize(perm(vars.PermSystemCreate), handleSystemAdd))
+	router.GET("/system", authorize(perm(vars.PermSystemView), handleSystemPage))
+	router.GET("/system/:sys", authorize(perm(vars.PermSystemView), handleSystems))
+	router.DELETE("/system/:sys", authorize(perm(vars.PermSystemDelete), handleSystemDelete))
+	router.GET("/system/:sys/:field", authorize(perm(vars.PermSystemView), handleSystemField))
+	router.POST("/system/:sys/:field", authorize(perm(vars.PermSystemUpdate), handleSystemPost))
+	router.PUT("/template", authorize(perm(vars.PermTemplateManage), handleTemplateAdd))
+	router.GET("/template/:tmpl", authorize(perm(vars.PermVulnCreate), handleTemplates))
+	router.POST("/template/:tmpl", authorize(perm(vars.PermTemplateManage), handleTemplatePost))
+	router.DELETE("/template/:tmpl", authorize(perm(vars.PermTemplateManage), handleTemplateDelete))
+	router.PUT("/vulnerability", authorize(perm(vars.PermVulnCreate), handleVulnerabilityAdd))
+	router.GET("/vulnerability", authorize(perm(vars.PermVulnView), handleVulnerabilityPage))
+	router.GET("/vulnerability/:vuln", authorize(perm(vars.PermVulnView), handleVulnerabilities))
+	router.GET("/vulnerability/:vuln/:field", authorize(perm(vars.PermVulnView), handleVulnerabilityField))
+	router.PUT("/vulnerability/:vuln/:field", authorize(vulnPutPerms, handleVulnerabilityPut))
+	router.POST("/vulnerability/:vuln/:field", authorize(vulnPostPerms, handleVulnerabilityPost))
+	router.DELETE("/vulnerability/:vuln/:field", authorize(vulnDeletePerms, handleVulnerabilityDelete))
+	router.GET("/vulnerability/:vuln/:field/:item", authorize(perm(vars.PermVulnView), handleVulnerabilityField))
+	router.POST("/vulnerability/:vuln/:field/:item", authorize(vulnPostPerms, handleVulnerabilityPost))
+	router.DELETE("/vulnerability/:vuln/:field/:item", authorize(vulnDeletePerms, handleVulnerabilityDelete))
 
 	// Serve css, javascript and images
 	router.ServeFiles("/styles/*filepath", http.Dir(fmt.Sprintf("%s/styles", webConf.WebRoot)))
@@ -188,10 +220,16 @@ func handleLoginPost(w http.ResponseWriter, r *http.Request, _ httprouter.Params
 	user.Authed = authed
 	session := sessionManager.Load(r)
 	if user.Authed {
-		if u == "VARSremoved" {
+		emp, err := varsapi.GetEmployeeByUsername(u)
+		if err != nil && !varsapi.IsNoRowsError(err) {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if err != nil || !emp.Active {
 			logInfo.Printf("Unauthorized attempt to log in. Username: %s | Client IP/Port: %s\n", u, r.RemoteAddr)
 			w.Header().Add("Content-Type", "text/html")
-			err := templates.Lookup("notauthorized-removed").Execute(w, nil)
+			err := templates.Lookup("notauthorized-removed").Execute(w, "")
 			if err != nil {
 				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-removed")
 				http.Error(w, "Error with templating", http.StatusInternalServerError)
@@ -199,23 +237,6 @@ func handleLoginPost(w http.ResponseWriter, r *http.Request, _ httprouter.Params
 			}
 			return
 		}
-		emp, err := varsapi.GetEmployeeByUsername(u)
-		if err != nil {
-			if varsapi.IsNoRowsError(err) {
-				logInfo.Printf("Unauthorized attempt to log in. Username: %s | Client IP/Port: %s\n", u, r.RemoteAddr)
-				w.Header().Add("Content-Type", "text/html")
-				err := templates.Lookup("notauthorized-removed").Execute(w, "")
-				if err != nil {
-					logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-removed")
-					http.Error(w, "Error with templating", http.StatusInternalServerError)
-					return
-				}
-				return
-			}
-			logError.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
 		user.Emp = emp
 		err = session.PutObject(w, "user", user)
 		if err != nil {
@@ -262,344 +283,224 @@ func handleEmployeePage(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level == AdminUser {
-			s := struct {
-				Page string
-				User interface{}
-			}{"emp", user}
-			w.Header().Add("Content-Type", "text/html")
-			err := templates.Lookup("emps").Execute(w, s)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "emps")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+	s := struct {
+		Page string
+		User interface{}
+	}{"emp", user}
+	w.Header().Add("Content-Type", "text/html")
+	err = templates.Lookup("emps").Execute(w, s)
+	if err != nil {
+		logError.Printf("Error with templating while performing lookup on %s\n", "emps")
+		http.Error(w, "Error with templating", http.StatusInternalServerError)
+		return
 	}
 }
 
 // handleEmployees serves the employee objects
 func handleEmployees(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
-	if err != nil {
-		logError.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
 	e := ps.ByName("emp")
-	if user.Authed {
-		if user.Emp.Level == AdminUser {
-			switch e {
-			case "all":
-				emps, err := varsapi.GetEmployees()
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				var data []interface{}
-				for _, emp := range emps {
-					s := struct {
-						ID        int64
-						FirstName string
-						LastName  string
-						Email     string
-						UserName  string
-						Level     int
-					}{emp.ID, emp.FirstName, emp.LastName, emp.Email, emp.UserName, emp.Level}
-					data = append(data, s)
-				}
-				err = json.NewEncoder(w).Encode(data)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			case "active":
-				emps, err := varsapi.GetEmployees()
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				var data []interface{}
-				for _, emp := range emps {
-					if emp.UserName != "VARSremoved" {
-						s := struct {
-							ID        int64
-							FirstName string
-							LastName  string
-							Email     string
-							UserName  string
-							Level     int
-						}{emp.ID, emp.FirstName, emp.LastName, emp.Email, emp.UserName, emp.Level}
-						data = append(data, s)
-					}
-				}
-				err = json.NewEncoder(w).Encode(data)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			case "removed":
-				emps, err := varsapi.GetEmployees()
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				var data []interface{}
-				for _, emp := range emps {
-					if emp.UserName == "VARSremoved" {
-						s := struct {
-							ID        int64
-							FirstName string
-							LastName  string
-							Email     string
-							UserName  string
-							Level     int
-						}{emp.ID, emp.FirstName, emp.LastName, emp.Email, emp.UserName, emp.Level}
-						data = append(data, s)
-					}
-				}
-				err = json.NewEncoder(w).Encode(data)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			case "list":
-				emps, err := varsapi.GetEmployees()
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				var data []interface{}
-				for _, emp := range emps {
-					name := fmt.Sprintf("%s %s", emp.FirstName, emp.LastName)
-					s := struct {
-						ID   int64
-						Name string
-					}{emp.ID, name}
-					data = append(data, s)
-				}
-				err = json.NewEncoder(w).Encode(data)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			case "name":
-				i := ps.ByName("id")
-				eid, err := strconv.Atoi(i)
-				if err != nil {
-					w.WriteHeader(http.StatusNotFound)
-					return
-				}
-				emp, err := varsapi.GetEmployeeByID(int64(eid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				name := fmt.Sprintf("%s %s", emp.FirstName, emp.LastName)
+	switch e {
+	case "all":
+		emps, err := varsapi.GetEmployees()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var data []interface{}
+		for _, emp := range emps {
+			s := struct {
+				ID        int64
+				FirstName string
+				LastName  string
+				Email     string
+				UserName  string
+				Role      string
+				Active    bool
+			}{emp.ID, emp.FirstName, emp.LastName, emp.Email, emp.UserName, emp.Role, emp.Active}
+			data = append(data, s)
+		}
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "active":
+		emps, err := varsapi.GetEmployees()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var data []interface{}
+		for _, emp := range emps {
+			if emp.Active {
 				s := struct {
-					Name string
-				}{name}
-				err = json.NewEncoder(w).Encode(s)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			default:
-				eid, err := strconv.Atoi(e)
-				if err != nil {
-					w.WriteHeader(http.StatusNotFound)
-					return
-				}
-				emp, err := varsapi.GetEmployeeByID(int64(eid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = json.NewEncoder(w).Encode(emp)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
+					ID        int64
+					FirstName string
+					LastName  string
+					Email     string
+					UserName  string
+					Role      string
+				}{emp.ID, emp.FirstName, emp.LastName, emp.Email, emp.UserName, emp.Role}
+				data = append(data, s)
 			}
-		} else if user.Emp.Level == PrivilegedUser || user.Emp.Level == StandardUser {
-			if e == "name" {
-				i := ps.ByName("id")
-				eid, err := strconv.Atoi(i)
-				if err != nil {
-					w.WriteHeader(http.StatusNotFound)
-					return
-				}
-				emp, err := varsapi.GetEmployeeByID(int64(eid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				name := fmt.Sprintf("%s %s", emp.FirstName, emp.LastName)
+		}
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "removed":
+		emps, err := varsapi.GetEmployees()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var data []interface{}
+		for _, emp := range emps {
+			if !emp.Active {
 				s := struct {
-					Name string
-				}{name}
-				err = json.NewEncoder(w).Encode(s)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			} else if e == "list" {
-				if user.Emp.Level == PrivilegedUser {
-					emps, err := varsapi.GetEmployees()
-					if err != nil {
-						logError.Println(err)
-						w.WriteHeader(http.StatusInternalServerError)
-						return
-					}
-					var data []interface{}
-					for _, emp := range emps {
-						name := fmt.Sprintf("%s %s", emp.FirstName, emp.LastName)
-						s := struct {
-							ID   int64
-							Name string
-						}{emp.ID, name}
-						data = append(data, s)
-					}
-					err = json.NewEncoder(w).Encode(data)
-					if err != nil {
-						logError.Println(err)
-						w.WriteHeader(http.StatusInternalServerError)
-						return
-					}
-				} else {
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-				}
-			} else {
-				w.WriteHeader(http.StatusTeapot)
-				return
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
+					ID        int64
+					FirstName string
+					LastName  string
+					Email     string
+					UserName  string
+					Role      string
+				}{emp.ID, emp.FirstName, emp.LastName, emp.Email, emp.UserName, emp.Role}
+				data = append(data, s)
 			}
 		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "list":
+		emps, err := varsapi.GetEmployees()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var data []interface{}
+		for _, emp := range emps {
+			name := fmt.Sprintf("%s %s", emp.FirstName, emp.LastName)
+			s := struct {
+				ID   int64
+				Name string
+			}{emp.ID, name}
+			data = append(data, s)
+		}
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "name":
+		i := ps.ByName("id")
+		eid, err := strconv.Atoi(i)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		emp, err := varsapi.GetEmployeeByID(int64(eid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		name := fmt.Sprintf("%s %s", emp.FirstName, emp.LastName)
+		s := struct {
+			Name string
+		}{name}
+		err = json.NewEncoder(w).Encode(s)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	default:
+		eid, err := strconv.Atoi(e)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		emp, err := varsapi.GetEmployeeByID(int64(eid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(emp)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 }
 
 // handleEmployeeAdd adds the new employee to VARS
 func handleEmployeeAdd(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
+	fname := r.FormValue("firstname")
+	lname := r.FormValue("lastname")
+	email := r.FormValue("email")
+	uname := r.FormValue("username")
+	role := r.FormValue("role")
+	emp := varsapi.CreateEmployee(fname, lname, email, uname, role)
+	err := varsapi.AddEmployee(db, emp)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level == AdminUser {
-			fname := r.FormValue("firstname")
-			lname := r.FormValue("lastname")
-			email := r.FormValue("email")
-			uname := r.FormValue("username")
-			l := r.FormValue("level")
-			level, err := strconv.Atoi(l)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			emp := varsapi.CreateEmployee(fname, lname, email, uname, level)
-			err = varsapi.AddEmployee(db, emp)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			ist := struct {
-				ID int64
-			}{emp.ID}
-			err = json.NewEncoder(w).Encode(ist)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+	ist := struct {
+		ID int64
+	}{emp.ID}
+	err = json.NewEncoder(w).Encode(ist)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 }
 
 func handleEmployeeDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
+	e := ps.ByName("emp")
+	eid, err := strconv.Atoi(e)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	e := ps.ByName("emp")
-	eid, err := strconv.Atoi(e)
+	var reassign vars.VarsNullInt64
+	if re := r.FormValue("reassign"); re != "" {
+		rid, err := strconv.Atoi(re)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reassign = varsapi.GetVarsNullInt64(int64(rid))
+	}
+	err = varsapi.DeactivateEmployee(db, int64(eid), reassign)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level == AdminUser {
-			err = varsapi.DeleteEmployee(db, int64(eid))
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
-	}
 }
 
 func handleEmployeePost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
-	if err != nil {
-		logError.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
 	e := ps.ByName("emp")
 	eid, err := strconv.Atoi(e)
 	if err != nil {
@@ -608,79 +509,68 @@ func handleEmployeePost(w http.ResponseWriter, r *http.Request, ps httprouter.Pa
 		return
 	}
 	field := ps.ByName("field")
-	if user.Authed {
-		switch field {
-		case "name":
-			if user.Emp.Level == AdminUser {
-				fname := r.FormValue("firstname")
-				lname := r.FormValue("lastname")
-				err := varsapi.UpdateEmployeeName(db, int64(eid), fname, lname)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "email":
-			if user.Emp.Level == AdminUser {
-				email := r.FormValue("email")
-				err := varsapi.UpdateEmployeeEmail(db, int64(eid), email)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "username":
-			if user.Emp.Level == AdminUser {
-				username := r.FormValue("username")
-				err := varsapi.UpdateEmployeeUsername(db, int64(eid), username)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "level":
-			if user.Emp.Level == AdminUser {
-				l := r.FormValue("level")
-				level, err := strconv.Atoi(l)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = varsapi.UpdateEmployeeLevel(db, int64(eid), level)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		default:
-			w.WriteHeader(http.StatusTeapot)
+	switch field {
+	case "name":
+		fname := r.FormValue("firstname")
+		lname := r.FormValue("lastname")
+		err := varsapi.UpdateEmployeeName(db, int64(eid), fname, lname)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "email":
+		email := r.FormValue("email")
+		err := varsapi.UpdateEmployeeEmail(db, int64(eid), email)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "username":
+		username := r.FormValue("username")
+		err := varsapi.UpdateEmployeeUsername(db, int64(eid), username)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "active":
+		err := varsapi.ReactivateEmployee(db, int64(eid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "role":
+		role := r.FormValue("role")
+		err := varsapi.UpdateEmployeeRole(db, int64(eid), role)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		w.WriteHeader(http.StatusTeapot)
+		return
 	}
 }
 
-func handleNotes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+// handleImport imports the scan report uploaded as the file form value. If dryrun is true the changes that would be
+// made are returned without applying them. If reconcile is true the affected systems are reconciled with the scan.
+// An SBOM replaces the components of the system given by the system form value. A systems CSV is imported all or
+// nothing unless besteffort is true.
+func handleImport(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
 	user, err := getSession(r)
 	if err != nil {
@@ -688,301 +578,192 @@ func handleNotes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level <= StandardUser {
-			v := ps.ByName("vuln")
-			vid, err := strconv.Atoi(v)
-			if err != nil {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			ns, err := varsapi.GetNotes(int64(vid))
-			if err != nil {
-				if varsapi.IsNoRowsError(err) {
-					w.WriteHeader(http.StatusOK)
-					return
-				}
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			var notes []interface{}
-			for _, n := range ns {
-				canEdit := user.Emp.ID == n.EmpID
-				employee, err := varsapi.GetEmployeeByID(n.EmpID)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				note := struct {
-					Nid      int64
-					Emp      string
-					Added    string
-					Note     string
-					Editable bool
-				}{n.ID, fmt.Sprintf("%v %v", employee.FirstName, employee.LastName), n.Added.Format("Mon, 02 Jan 2006 15:04:05"), n.Note, canEdit}
-				notes = append(notes, note)
-			}
-			err = json.NewEncoder(w).Encode(notes)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
-	}
-}
-
-func handleNotesPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	logRequest(r)
-	user, err := getSession(r)
+	r.Body = http.MaxBytesReader(w, r.Body, maxScanSize)
+	file, _, err := r.FormFile("file")
 	if err != nil {
 		logError.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
-	if user.Authed {
-		n := ps.ByName("noteid")
-		nid, err := strconv.Atoi(n)
+	defer file.Close()
+	opts := vars.ScanOptions{Employee: user.Emp.ID}
+	if dr := r.FormValue("dryrun"); dr != "" {
+		opts.DryRun, err = strconv.ParseBool(dr)
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		author, err := varsapi.GetNoteAuthor(int64(nid))
+	}
+	if rc := r.FormValue("reconcile"); rc != "" {
+		opts.Reconcile, err = strconv.ParseBool(rc)
 		if err != nil {
-			logError.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		if user.Emp.Level <= StandardUser && user.Emp.ID == author {
-			note := r.FormValue("note")
-			err = varsapi.UpdateNote(db, int64(nid), note)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			} else {
-				w.WriteHeader(http.StatusOK)
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
+	}
+	var diff interface{}
+	switch ps.ByName("scanner") {
+	case "systems":
+		copts := vars.CsvOptions{DryRun: opts.DryRun}
+		if be := r.FormValue("besteffort"); be != "" {
+			copts.BestEffort, err = strconv.ParseBool(be)
 			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
+				w.WriteHeader(http.StatusBadRequest)
 				return
 			}
 		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+		diff, err = varsapi.ImportSystemsCsv(db, file, &copts)
+	case "grype":
+		diff, err = varsapi.ImportGrype(db, file, &opts)
+	case "nessus":
+		diff, err = varsapi.ImportNessus(db, file, &opts)
+	case "nmap":
+		diff, err = varsapi.ImportNmap(db, file, &opts)
+	case "openvas":
+		diff, err = varsapi.ImportOpenVas(db, file, &opts)
+	case "sbom":
+		sid, e := strconv.Atoi(r.FormValue("system"))
+		if e != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		diff, err = varsapi.ImportSbom(db, int64(sid), file, &opts)
+		if varsapi.IsNoRowsError(err) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	case "trivy":
+		diff, err = varsapi.ImportTrivy(db, file, &opts)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
 	}
-}
-
-// handleReportPage serves the system page outline
-func handleReportPage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	logRequest(r)
-	user, err := getSession(r)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		s := struct {
-			Page string
-			User interface{}
-		}{"report", user}
-		w.Header().Add("Content-Type", "text/html")
-		err := templates.Lookup("report").Execute(w, s)
-		if err != nil {
-			logError.Printf("Error with templating while performing lookup on %s\n", "report")
-			http.Error(w, "Error with templating", http.StatusInternalServerError)
-			return
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
-	}
-}
-
-func handleReport(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	logRequest(r)
-	user, err := getSession(r)
+	err = json.NewEncoder(w).Encode(diff)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		name := ps.ByName("report")
-		switch name {
-		case "list":
-			var data []string
-			for name, _ := range reports {
-				data = append(data, name)
-			}
-			err = json.NewEncoder(w).Encode(data)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		default:
-			_, ok := reports[name]
-			if !ok {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			gen, err := reports[name].Lookup("GenerateReport")
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			g, ok := gen.(func() (string, error))
-			if !ok {
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			rep, err := g()
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			w.Header().Add("Content-Type", "text/html")
-			w.Write([]byte(rep))
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
-	}
 }
 
-// handleSystemAdd adds the new system to VARS
-func handleSystemAdd(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+// handleKevMatches writes the vulnerabilities that were matched to the KEV catalog since the date in the since
+// parameter (YYYY-MM-DD), or in the last 30 days, so that they can be rescored.
+func handleKevMatches(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
+	since := time.Now().AddDate(0, 0, -30)
+	if s := r.FormValue("since"); s != "" {
+		t, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+	matches, err := varsapi.GetKevMatches(since)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level <= StandardUser {
-			name := r.FormValue("name")
-			tp := r.FormValue("type")
-			opsys := r.FormValue("os")
-			loc := r.FormValue("location")
-			desc := r.FormValue("description")
-			sys := varsapi.CreateSystem(name, tp, opsys, loc, desc, "active")
-			err = varsapi.AddSystem(db, sys)
-			if err != nil {
-				if varsapi.IsNameNotAvailableError(err) {
-					w.WriteHeader(http.StatusNotAcceptable)
-					return
-				}
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			ist := struct {
-				ID int64
-			}{sys.ID}
-			err = json.NewEncoder(w).Encode(ist)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
-	}
-}
-
-// handleSystemPage serves the system page outline
-func handleSystemPage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	logRequest(r)
-	user, err := getSession(r)
+	err = json.NewEncoder(w).Encode(matches)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level <= StandardUser {
-			s := struct {
-				Page string
-				User interface{}
-			}{"sys", user}
-			w.Header().Add("Content-Type", "text/html")
-			err := templates.Lookup("sys").Execute(w, s)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "sys")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
-	}
 }
 
-func handleSystemDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+func handleNotes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
 	user, err := getSession(r)
 	if err != nil {
+		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	s := ps.ByName("sys")
-	sid, err := strconv.Atoi(s)
+	v := ps.ByName("vuln")
+	vid, err := strconv.Atoi(v)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	ns, err := varsapi.GetNotes(int64(vid))
 	if err != nil {
+		if varsapi.IsNoRowsError(err) {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level <= PrivilegedUser {
-			err = varsapi.DeleteSystem(db, int64(sid))
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			w.WriteHeader(http.StatusOK)
+	emps, err := varsapi.GetEmployees()
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	names := make(map[string]string)
+	ids := make(map[int64]string)
+	for _, e := range emps {
+		names[e.UserName] = fmt.Sprintf("%v %v", e.FirstName, e.LastName)
+		ids[e.ID] = names[e.UserName]
+	}
+	resolve := func(username string) (string, bool) {
+		name, ok := names[username]
+		return name, ok
+	}
+	var notes []interface{}
+	for _, n := range ns {
+		canEdit := user.Emp.ID == n.EmpID && !n.Deleted.Valid
+		canReply := user.Can(vars.PermNoteWrite) && !n.Deleted.Valid
+		revs, err := varsapi.GetNoteRevisions(n.ID)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		w.WriteHeader(http.StatusUnauthorized)
+		text := n.Note
+		html := renderMarkdown(n.Note, resolve)
+		if n.Deleted.Valid {
+			text = ""
+			html = template.HTML("<p><em>This note was deleted.</em></p>")
+		}
+		var mentions []string
+		for _, m := range n.Mentions {
+			mentions = append(mentions, ids[m])
+		}
+		note := struct {
+			Nid      int64
+			Parent   vars.VarsNullInt64
+			Emp      string
+			Added    string
+			Note     string
+			Html     template.HTML
+			Mentions []string
+			Edited   bool
+			Deleted  bool
+			Editable bool
+			Reply    bool
+			Public   bool
+		}{n.ID, n.Parent, ids[n.EmpID], n.Added.Format("Mon, 02 Jan 2006 15:04:05"), text, html, mentions, len(revs) > 0, n.Deleted.Valid, canEdit, canReply, n.Public}
+		notes = append(notes, note)
+	}
+	err = json.NewEncoder(w).Encode(notes)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
 		return
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
 	}
 }
 
-func handleSystems(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+// handleNotesPost updates the text of the note, or whether it is public if the public form value is set.
+func handleNotesPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
 	user, err := getSession(r)
 	if err != nil {
@@ -990,69 +771,53 @@ func handleSystems(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	s := ps.ByName("sys")
-	if user.Authed {
-		if user.Emp.Level <= StandardUser {
-			switch s {
-			case "all":
-				syss, err := varsapi.GetSystems()
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = json.NewEncoder(w).Encode(syss)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			case "active", "inactive":
-				syss, err := varsapi.GetSystemsByState(s)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = json.NewEncoder(w).Encode(syss)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			default:
-				sid, err := strconv.Atoi(s)
-				if err != nil {
-					w.WriteHeader(http.StatusNotFound)
-					return
-				}
-				sys, err := varsapi.GetSystem(int64(sid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = json.NewEncoder(w).Encode(sys)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			}
+	n := ps.ByName("noteid")
+	nid, err := strconv.Atoi(n)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	note, err := varsapi.GetNote(int64(nid))
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if pub := r.FormValue("public"); pub != "" && !note.Deleted.Valid && (user.Emp.ID == note.EmpID || user.Can(vars.PermVulnUpdate)) {
+		public, err := strconv.ParseBool(pub)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = varsapi.UpdateNotePublic(db, int64(nid), public)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	} else if user.Emp.ID == note.EmpID && !note.Deleted.Valid {
+		note := r.FormValue("note")
+		err = varsapi.UpdateNote(db, int64(nid), note)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
+			w.WriteHeader(http.StatusOK)
 		}
 	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+		err := templates.Lookup("notauthorized-get").Execute(w, user)
+		if err != nil {
+			logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
+			http.Error(w, "Error with templating", http.StatusInternalServerError)
+			return
+		}
 	}
 }
 
-func handleSystemPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+// handleReportPage serves the system page outline
+func handleReportPage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	logRequest(r)
 	user, err := getSession(r)
 	if err != nil {
@@ -1060,280 +825,156 @@ func handleSystemPost(w http.ResponseWriter, r *http.Request, ps httprouter.Para
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	s := ps.ByName("sys")
-	sid, err := strconv.Atoi(s)
+	s := struct {
+		Page string
+		User interface{}
+	}{"report", user}
+	w.Header().Add("Content-Type", "text/html")
+	err = templates.Lookup("report").Execute(w, s)
 	if err != nil {
-		logError.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		logError.Printf("Error with templating while performing lookup on %s\n", "report")
+		http.Error(w, "Error with templating", http.StatusInternalServerError)
 		return
 	}
-	field := ps.ByName("field")
-	if user.Authed {
-		switch field {
-		case "name":
-			if user.Emp.Level <= StandardUser {
-				name := r.FormValue("name")
-				err := varsapi.UpdateSystemName(db, int64(sid), name)
-				if err != nil {
-					if varsapi.IsNameNotAvailableError(err) {
-						w.WriteHeader(http.StatusNotAcceptable)
-						return
-					}
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "type":
-			if user.Emp.Level <= StandardUser {
-				tp := r.FormValue("type")
-				err := varsapi.UpdateSystemType(db, int64(sid), tp)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "os":
-			if user.Emp.Level <= StandardUser {
-				opsys := r.FormValue("os")
-				err := varsapi.UpdateSystemOS(db, int64(sid), opsys)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "location":
-			if user.Emp.Level <= StandardUser {
-				loc := r.FormValue("location")
-				err := varsapi.UpdateSystemLocation(db, int64(sid), loc)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "description":
-			if user.Emp.Level <= StandardUser {
-				desc := r.FormValue("description")
-				err := varsapi.UpdateSystemDescription(db, int64(sid), desc)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "state":
-			if user.Emp.Level <= StandardUser {
-				state := r.FormValue("state")
-				if state != "active" && state != "inactive" {
-					w.WriteHeader(http.StatusNotAcceptable)
-					return
-				}
-				err := varsapi.UpdateSystemState(db, int64(sid), state)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		default:
-			w.WriteHeader(http.StatusTeapot)
+}
+
+func handleReport(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	name := ps.ByName("report")
+	switch name {
+	case "list":
+		var data []string
+		for name, _ := range reports {
+			data = append(data, name)
+		}
+		err := json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	default:
+		_, ok := reports[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		gen, err := reports[name].Lookup("GenerateReport")
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		g, ok := gen.(func() (string, error))
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rep, err := g()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		w.Header().Add("Content-Type", "text/html")
+		w.Write([]byte(rep))
 	}
 }
 
-// handleVulnerabilityAdd adds the new vuln to VARS
-func handleVulnerabilityAdd(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+// handleRoles writes the roles with their permissions and the list of all permissions.
+func handleRoles(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
+	roles, err := varsapi.GetRoles()
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level <= PrivilegedUser {
-			name := r.FormValue("name")
-			summ := r.FormValue("summary")
-			cvss := r.FormValue("cvssScore")
-			cvsl := r.FormValue("cvssLink")
-			corp := r.FormValue("corpscore")
-			test := r.FormValue("test")
-			find := r.FormValue("finder")
-			miti := r.FormValue("mitigation")
-			expb := r.FormValue("exploitable")
-			expl := r.FormValue("exploit")
-			exploitable, err := strconv.ParseBool(expb)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			cScore, err := strconv.ParseFloat(cvss, 32)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			corpscore, err := strconv.ParseFloat(corp, 32)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			vuln := varsapi.CreateVulnerability(name, summ, cvsl, test, miti, expl, exploitable, float32(cScore), float32(corpscore))
-			finder, err := strconv.Atoi(find)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			vuln.Finder = int64(finder)
-			vuln.Initiator = user.Emp.ID
-			err = varsapi.AddVulnerability(db, vuln)
-			if err != nil {
-				if varsapi.IsNameNotAvailableError(err) {
-					w.WriteHeader(http.StatusNotAcceptable)
-					return
-				}
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			ist := struct {
-				ID int64
-			}{vuln.ID}
-			err = json.NewEncoder(w).Encode(ist)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
+	s := struct {
+		Roles       map[string][]string
+		Permissions []string
+	}{make(map[string][]string), vars.Permissions}
+	for role, perms := range roles {
+		s.Roles[role] = []string{}
+		for p := range perms {
+			s.Roles[role] = append(s.Roles[role], p)
 		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+		sort.Strings(s.Roles[role])
+	}
+	err = json.NewEncoder(w).Encode(s)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 }
 
-func handleVulnerabilityField(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+// handleRolePost replaces the permissions of the role with the permission form values, defining the role in the
+// database if it is new.
+func handleRolePost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
+	r.ParseForm()
+	err := varsapi.SetRolePermissions(db, ps.ByName("role"), r.Form["permission"])
 	if err != nil {
+		if varsapi.IsInvalidValueError(err) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	v := ps.ByName("vuln")
-	vid, err := strconv.Atoi(v)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRoleDelete removes the role from the database.
+func handleRoleDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	err := varsapi.DeleteRole(db, ps.ByName("role"))
 	if err != nil {
+		if varsapi.IsNoRowsError(err) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	field := ps.ByName("field")
-	if user.Authed {
-		switch field {
-		case "cve":
-			cve := ""
-			cves, err := varsapi.GetCves(int64(vid))
-			if err != nil {
-				if !varsapi.IsNoRowsError(err) {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			}
-			sort.Strings(*cves)
-			cve = strings.Join(*cves, ", ")
-			s := struct {
-				CVE string
-			}{cve}
-			err = json.NewEncoder(w).Encode(s)
-			if err != nil {
-				logError.Println(err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-		default:
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
-	}
+	w.WriteHeader(http.StatusOK)
 }
 
-// handleVulnerabilityPage serves the vulnerability page outline
-func handleVulnerabilityPage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+// handleSystemAdd adds the new system to VARS
+func handleSystemAdd(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
+	name := r.FormValue("name")
+	tp := r.FormValue("type")
+	opsys := r.FormValue("os")
+	loc := r.FormValue("location")
+	desc := r.FormValue("description")
+	sys := varsapi.CreateSystem(name, tp, opsys, loc, desc, "active")
+	err := varsapi.AddSystem(db, sys)
 	if err != nil {
+		if varsapi.IsNameNotAvailableError(err) {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	if user.Authed {
-		if user.Emp.Level <= StandardUser {
-			s := struct {
-				Page string
-				User interface{}
-			}{"vuln", user}
-			w.Header().Add("Content-Type", "text/html")
-			err := templates.Lookup("vulns").Execute(w, s)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "vulns")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+	ist := struct {
+		ID int64
+	}{sys.ID}
+	err = json.NewEncoder(w).Encode(ist)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 }
 
-// handleVulnerabilities serves the vulnerability objects
-func handleVulnerabilities(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+// handleSystemPage serves the system page outline
+func handleSystemPage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	logRequest(r)
 	user, err := getSession(r)
 	if err != nil {
@@ -1341,687 +982,1743 @@ func handleVulnerabilities(w http.ResponseWriter, r *http.Request, ps httprouter
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	v := ps.ByName("vuln")
-	if user.Authed {
-		if user.Emp.Level <= StandardUser {
-			switch v {
-			case "all":
-				vulns, err := varsapi.GetVulnerabilities()
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				var data []interface{}
-				for _, v := range vulns {
-					cve := ""
-					cves, err := varsapi.GetCves(v.ID)
-					if err != nil {
-						if !varsapi.IsNoRowsError(err) {
-							logError.Println(err)
-							w.WriteHeader(http.StatusInternalServerError)
-							return
-						}
-					}
-					sort.Strings(*cves)
-					cve = strings.Join(*cves, ", ")
-					mit := ""
-					if v.Dates.Mitigated.Valid {
-						mit = v.Dates.Mitigated.Time.Format("Mon, 02 Jan 2006 15:04:05")
-					}
-					s := struct {
-						ID        int64
-						Name      string
-						Summary   string
-						Cvss      float32
-						CorpScore float32
-						Cve       string
-						Initiated string
-						Mitigated string
-					}{v.ID, v.Name, v.Summary, v.Cvss, v.CorpScore, cve, v.Dates.Initiated.Format("Mon, 02 Jan 2006 15:04:05"), mit}
-					data = append(data, s)
-				}
-				err = json.NewEncoder(w).Encode(data)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			case "open":
-				vulns, err := varsapi.GetOpenVulnerabilities()
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				var data []interface{}
-				for _, v := range vulns {
-					cve := ""
-					cves, err := varsapi.GetCves(v.ID)
-					if err != nil {
-						if !varsapi.IsNoRowsError(err) {
-							logError.Println(err)
-							w.WriteHeader(http.StatusInternalServerError)
-							return
-						}
-					}
-					sort.Strings(*cves)
-					cve = strings.Join(*cves, ", ")
-					s := struct {
-						ID        int64
-						Name      string
-						Summary   string
-						Cvss      float32
-						CorpScore float32
-						Cve       string
-						Initiated string
-					}{v.ID, v.Name, v.Summary, v.Cvss, v.CorpScore, cve, v.Dates.Initiated.Format("Mon, 02 Jan 2006 15:04:05")}
-					data = append(data, s)
-				}
-				err = json.NewEncoder(w).Encode(data)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			case "closed":
-				vulns, err := varsapi.GetClosedVulnerabilities()
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				var data []interface{}
-				for _, v := range vulns {
-					cve := ""
-					cves, err := varsapi.GetCves(v.ID)
-					if err != nil {
-						if !varsapi.IsNoRowsError(err) {
-							logError.Println(err)
-							w.WriteHeader(http.StatusInternalServerError)
-							return
-						}
-					}
-					sort.Strings(*cves)
-					cve = strings.Join(*cves, ", ")
-					var mit string
-					if v.Dates.Mitigated.Valid {
-						mit = v.Dates.Mitigated.Time.Format("Mon, 02 Jan 2006 15:04:05")
-					} else {
-						mit = ""
-					}
-					s := struct {
-						ID        int64
-						Name      string
-						Summary   string
-						Cvss      float32
-						CorpScore float32
-						Cve       string
-						Initiated string
-						Mitigated string
-					}{v.ID, v.Name, v.Summary, v.Cvss, v.CorpScore, cve, v.Dates.Initiated.Format("Mon, 02 Jan 2006 15:04:05"), mit}
-					data = append(data, s)
-				}
-				err = json.NewEncoder(w).Encode(data)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			default:
-				vid, err := strconv.Atoi(v)
-				if err != nil {
-					w.WriteHeader(http.StatusNotFound)
-					return
-				}
-				vuln, err := varsapi.GetVulnerability(int64(vid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = json.NewEncoder(w).Encode(vuln)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			}
-		} else {
-			err := templates.Lookup("notauthorized-get").Execute(w, user)
-			if err != nil {
-				logError.Printf("Error with templating while performing lookup on %s\n", "notauthorized-get")
-				http.Error(w, "Error with templating", http.StatusInternalServerError)
-				return
-			}
-		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+	s := struct {
+		Page string
+		User interface{}
+	}{"sys", user}
+	w.Header().Add("Content-Type", "text/html")
+	err = templates.Lookup("sys").Execute(w, s)
+	if err != nil {
+		logError.Printf("Error with templating while performing lookup on %s\n", "sys")
+		http.Error(w, "Error with templating", http.StatusInternalServerError)
+		return
 	}
 }
 
-func handleVulnerabilityPut(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+func handleSystemDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
+	s := ps.ByName("sys")
+	sid, err := strconv.Atoi(s)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	v := ps.ByName("vuln")
-	vid, err := strconv.Atoi(v)
+	err = varsapi.DeleteSystem(db, int64(sid))
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	field := ps.ByName("field")
-	if user.Authed {
-		switch field {
-		case "cve":
-			if user.Emp.Level <= StandardUser {
-				cve := r.FormValue("cve")
-				err := varsapi.AddCve(db, int64(vid), cve)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "ticket":
-			if user.Emp.Level <= StandardUser {
-				ticket := r.FormValue("ticket")
-				err := varsapi.AddTicket(db, int64(vid), ticket)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "ref":
-			if user.Emp.Level <= StandardUser {
-				ref := r.FormValue("ref")
-				err := varsapi.AddRef(db, int64(vid), ref)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "affected":
-			if user.Emp.Level <= StandardUser {
-				sys := r.FormValue("system")
-				sid, err := strconv.Atoi(sys)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = varsapi.AddAffected(db, int64(vid), int64(sid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "note":
-			if user.Emp.Level <= StandardUser {
-				note := r.FormValue("note")
-				err := varsapi.AddNote(db, int64(vid), user.Emp.ID, note)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "mitigated":
-			if user.Emp.Level <= PrivilegedUser {
-				err := varsapi.CloseVulnerability(db, int64(vid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
+	w.WriteHeader(http.StatusOK)
+	return
+}
+
+func handleSystems(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	s := ps.ByName("sys")
+	switch s {
+	case "all":
+		if responseFormat(r, "csv") == "csv" {
+			var buf bytes.Buffer
+			err := varsapi.ExportSystemsCsv(&buf)
+			if err != nil {
+				logError.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
 			}
-		default:
-			w.WriteHeader(http.StatusTeapot)
+			sendDownload(w, "csv", "systems.csv", buf.Bytes())
+			return
+		}
+		syss, err := varsapi.GetSystems()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(syss)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "active", "inactive":
+		syss, err := varsapi.GetSystemsByState(s)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(syss)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	default:
+		sid, err := strconv.Atoi(s)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		sys, err := varsapi.GetSystem(int64(sid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(sys)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
 	}
 }
 
-func handleVulnerabilityDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+// handleSystemField returns the components of the system imported from its SBOM, or the vulnerabilities that may
+// affect the system through them.
+func handleSystemField(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	logRequest(r)
-	user, err := getSession(r)
+	sid, err := strconv.Atoi(ps.ByName("sys"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var res interface{}
+	switch ps.ByName("field") {
+	case "components":
+		res, err = varsapi.GetSystemComponents(int64(sid))
+	case "candidates":
+		res, err = varsapi.GetSbomCandidates(int64(sid))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	v := ps.ByName("vuln")
-	vid, err := strconv.Atoi(v)
+	err = json.NewEncoder(w).Encode(res)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func handleSystemPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	s := ps.ByName("sys")
+	sid, err := strconv.Atoi(s)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	field := ps.ByName("field")
-	if user.Authed {
-		switch field {
-		case "cve":
-			if user.Emp.Level <= StandardUser {
-				cve := ps.ByName("item")
-				err := varsapi.DeleteCve(db, int64(vid), cve)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "ticket":
-			if user.Emp.Level <= StandardUser {
-				ticket := ps.ByName("item")
-				err := varsapi.DeleteTicket(db, int64(vid), ticket)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
+	switch field {
+	case "name":
+		name := r.FormValue("name")
+		err := varsapi.UpdateSystemName(db, int64(sid), name)
+		if err != nil {
+			if varsapi.IsNameNotAvailableError(err) {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
 			}
-		case "ref":
-			if user.Emp.Level <= StandardUser {
-				b, err := ioutil.ReadAll(r.Body)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				ref := string(b)
-				err = varsapi.DeleteRef(db, int64(vid), ref)
-				if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "type":
+		tp := r.FormValue("type")
+		err := varsapi.UpdateSystemType(db, int64(sid), tp)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "os":
+		opsys := r.FormValue("os")
+		err := varsapi.UpdateSystemOS(db, int64(sid), opsys)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "location":
+		loc := r.FormValue("location")
+		err := varsapi.UpdateSystemLocation(db, int64(sid), loc)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "description":
+		desc := r.FormValue("description")
+		err := varsapi.UpdateSystemDescription(db, int64(sid), desc)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "state":
+		state := r.FormValue("state")
+		if state != "active" && state != "inactive" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		err := varsapi.UpdateSystemState(db, int64(sid), state)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+}
+
+// handleTemplateAdd adds the new vulnerability template to VARS
+func handleTemplateAdd(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	logRequest(r)
+	tmpl, err := templateFromForm(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = varsapi.AddTemplate(db, tmpl)
+	if err != nil {
+		if varsapi.IsNameNotAvailableError(err) {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ist := struct {
+		ID int64
+	}{tmpl.ID}
+	err = json.NewEncoder(w).Encode(ist)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func handleTemplateDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	tid, err := strconv.Atoi(ps.ByName("tmpl"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	err = varsapi.DeleteTemplate(db, int64(tid))
+	if err != nil {
+		if varsapi.IsNoRowsError(err) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleTemplatePost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	tid, err := strconv.Atoi(ps.ByName("tmpl"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	tmpl, err := templateFromForm(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	tmpl.ID = int64(tid)
+	err = varsapi.UpdateTemplate(db, tmpl)
+	if err != nil {
+		if varsapi.IsNameNotAvailableError(err) {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		if varsapi.IsNoRowsError(err) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleTemplates serves the vulnerability templates
+func handleTemplates(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	t := ps.ByName("tmpl")
+	switch t {
+	case "all":
+		tmpls, err := varsapi.GetTemplates()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(tmpls)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	default:
+		tid, err := strconv.Atoi(t)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		tmpl, err := varsapi.GetTemplate(int64(tid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(tmpl)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// handleVulnerabilityAdd adds the new vuln to VARS
+func handleVulnerabilityAdd(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	logRequest(r)
+	user, err := getSession(r)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	name := r.FormValue("name")
+	summ := r.FormValue("summary")
+	cvss := r.FormValue("cvssScore")
+	cvsl := r.FormValue("cvssLink")
+	corp := r.FormValue("corpscore")
+	test := r.FormValue("test")
+	find := r.FormValue("finder")
+	miti := r.FormValue("mitigation")
+	expb := r.FormValue("exploitable")
+	expl := r.FormValue("exploit")
+	tmpl := r.FormValue("template")
+	// The scores may be left empty when a template is used
+	if tmpl != "" {
+		if cvss == "" {
+			cvss = "0"
+		}
+		if corp == "" {
+			corp = "0"
+		}
+	}
+	exploitable, err := strconv.ParseBool(expb)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	cScore, err := strconv.ParseFloat(cvss, 32)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	corpscore, err := strconv.ParseFloat(corp, 32)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	vuln := varsapi.CreateVulnerability(name, summ, cvsl, test, miti, expl, exploitable, float32(cScore), float32(corpscore))
+	finder, err := strconv.Atoi(find)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	vuln.Finder = int64(finder)
+	vuln.Initiator = user.Emp.ID
+	if tmpl != "" {
+		tid, err := strconv.Atoi(tmpl)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = varsapi.ApplyTemplate(vuln, int64(tid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	err = varsapi.AddVulnerability(db, vuln)
+	if err != nil {
+		if varsapi.IsNameNotAvailableError(err) {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ist := struct {
+		ID int64
+	}{vuln.ID}
+	err = json.NewEncoder(w).Encode(ist)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func handleVulnerabilityField(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	user, err := getSession(r)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	v := ps.ByName("vuln")
+	vid, err := strconv.Atoi(v)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	field := ps.ByName("field")
+	switch field {
+	case "corpscore":
+		vuln, err := varsapi.GetVulnerability(int64(vid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s := struct {
+			CorpScore float32
+		}{varsapi.CalculateCorpScore(vuln)}
+		err = json.NewEncoder(w).Encode(s)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "csaf", "vex":
+		var buf bytes.Buffer
+		if err := varsapi.ExportCsaf(&buf, int64(vid), field == "vex"); err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		sendDownload(w, "json", fmt.Sprintf("vars-%d-%s.json", vid, field), buf.Bytes())
+	case "dossier":
+		d, err := varsapi.GetDossier(int64(vid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if responseFormat(r, "pdf") == "pdf" {
+			var buf bytes.Buffer
+			if err := varsapi.ExportDossierPdf(&buf, d); err != nil {
+				logError.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			sendDownload(w, "pdf", fmt.Sprintf("vars-%d-dossier.pdf", vid), buf.Bytes())
+			return
+		}
+		emps, err := varsapi.GetEmployees()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		names := make(map[string]string)
+		for _, e := range emps {
+			names[e.UserName] = fmt.Sprintf("%v %v", e.FirstName, e.LastName)
+		}
+		resolve := func(username string) (string, bool) {
+			name, ok := names[username]
+			return name, ok
+		}
+		type note struct {
+			Author string
+			Added  time.Time
+			Note   template.HTML
+			Reply  bool
+		}
+		var notes []note
+		for _, n := range d.Notes {
+			notes = append(notes, note{n.Author, n.Added, renderMarkdown(n.Note, resolve), n.Reply})
+		}
+		s := struct {
+			Dossier *vars.Dossier
+			Notes   []note
+		}{d, notes}
+		w.Header().Add("Content-Type", "text/html")
+		err = templates.Lookup("dossier").Execute(w, s)
+		if err != nil {
+			logError.Printf("Error with templating while performing lookup on %s\n", "dossier")
+			http.Error(w, "Error with templating", http.StatusInternalServerError)
+			return
+		}
+	case "osv":
+		rec, err := varsapi.ExportOSV(int64(vid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		data, err := json.MarshalIndent(rec, "", "  ")
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		sendDownload(w, "json", rec.ID+".json", data)
+	case "task":
+		tasks, err := varsapi.GetTasks(int64(vid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s := struct {
+			Tasks    []*vars.Task
+			Progress int
+		}{tasks, varsapi.TaskProgress(tasks)}
+		err = json.NewEncoder(w).Encode(s)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "cve":
+		cve := ""
+		cves, err := varsapi.GetCves(int64(vid))
+		if err != nil {
+			if !varsapi.IsNoRowsError(err) {
+				logError.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		sort.Strings(*cves)
+		cve = strings.Join(*cves, ", ")
+		s := struct {
+			CVE string
+		}{cve}
+		err = json.NewEncoder(w).Encode(s)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "note":
+		nid, err := strconv.Atoi(ps.ByName("item"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n, err := varsapi.GetNote(int64(nid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if n.VulnID != int64(vid) || n.Deleted.Valid {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		revs, err := varsapi.GetNoteRevisions(n.ID)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var data []interface{}
+		for _, rev := range revs {
+			s := struct {
+				Revised string
+				Note    string
+			}{rev.Revised.Format("Mon, 02 Jan 2006 15:04:05"), rev.Note}
+			data = append(data, s)
+		}
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "attachment":
+		i := ps.ByName("item")
+		if i == "" {
+			atts, err := varsapi.GetAttachments(int64(vid))
+			if err != nil {
+				logError.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			var data []interface{}
+			for _, a := range atts {
+				employee, err := varsapi.GetEmployeeByID(a.EmpID)
+				if err != nil {
+					logError.Println(err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				s := struct {
+					Aid       int64
+					SysID     vars.VarsNullInt64
+					Emp       string
+					FileName  string
+					MimeType  string
+					Size      int64
+					Sha256    string
+					Added     string
+					Deletable bool
+				}{a.ID, a.SysID, fmt.Sprintf("%v %v", employee.FirstName, employee.LastName), a.FileName, a.MimeType, a.Size, a.Sha256, a.Added.Format("Mon, 02 Jan 2006 15:04:05"), user.Emp.ID == a.EmpID}
+				data = append(data, s)
+			}
+			err = json.NewEncoder(w).Encode(data)
+			if err != nil {
+				logError.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+		aid, err := strconv.Atoi(i)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		att, err := varsapi.GetAttachment(int64(aid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if att.VulnID != int64(vid) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		contents, err := varsapi.GetAttachmentContents(att)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Add("Content-Type", att.MimeType)
+		w.Header().Add("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": att.FileName}))
+		w.Write(contents)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+}
+
+// handleVulnerabilityPage serves the vulnerability page outline
+func handleVulnerabilityPage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	logRequest(r)
+	user, err := getSession(r)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	s := struct {
+		Page string
+		User interface{}
+	}{"vuln", user}
+	w.Header().Add("Content-Type", "text/html")
+	err = templates.Lookup("vulns").Execute(w, s)
+	if err != nil {
+		logError.Printf("Error with templating while performing lookup on %s\n", "vulns")
+		http.Error(w, "Error with templating", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleVulnerabilities serves the vulnerability objects
+func handleVulnerabilities(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	v := ps.ByName("vuln")
+	switch v {
+	case "all":
+		vulns, err := varsapi.GetVulnerabilities()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		vulns, err = filterSortVulns(r, vulns)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if f := responseFormat(r, "csv", "xlsx", "sarif", "stix"); f != "json" {
+			exportVulns(w, r, f, v, vulns)
+			return
+		}
+		var data []interface{}
+		for _, v := range vulns {
+			cve := ""
+			cves, err := varsapi.GetCves(v.ID)
+			if err != nil {
+				if !varsapi.IsNoRowsError(err) {
 					logError.Println(err)
 					w.WriteHeader(http.StatusInternalServerError)
 					return
 				}
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
 			}
-		case "affected":
-			if user.Emp.Level <= StandardUser {
-				sys := ps.ByName("item")
-				sid, err := strconv.Atoi(sys)
-				if err != nil {
+			sort.Strings(*cves)
+			cve = strings.Join(*cves, ", ")
+			mit := ""
+			if v.Dates.Mitigated.Valid {
+				mit = v.Dates.Mitigated.Time.Format("Mon, 02 Jan 2006 15:04:05")
+			}
+			s := struct {
+				ID        int64
+				Name      string
+				Summary   string
+				Cvss      float32
+				CorpScore float32
+				Epss      *float32
+				Cve       string
+				Initiated string
+				Mitigated string
+			}{v.ID, v.Name, v.Summary, v.Cvss, v.CorpScore, epssScore(v), cve, v.Dates.Initiated.Format("Mon, 02 Jan 2006 15:04:05"), mit}
+			data = append(data, s)
+		}
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "open":
+		vulns, err := varsapi.GetOpenVulnerabilities()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		vulns, err = filterSortVulns(r, vulns)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if f := responseFormat(r, "csv", "xlsx", "sarif", "stix"); f != "json" {
+			exportVulns(w, r, f, v, vulns)
+			return
+		}
+		var data []interface{}
+		for _, v := range vulns {
+			cve := ""
+			cves, err := varsapi.GetCves(v.ID)
+			if err != nil {
+				if !varsapi.IsNoRowsError(err) {
 					logError.Println(err)
 					w.WriteHeader(http.StatusInternalServerError)
 					return
 				}
-				err = varsapi.DeleteAffected(db, int64(vid), int64(sid))
-				if err != nil {
+			}
+			sort.Strings(*cves)
+			cve = strings.Join(*cves, ", ")
+			s := struct {
+				ID        int64
+				Name      string
+				Summary   string
+				Cvss      float32
+				CorpScore float32
+				Epss      *float32
+				Cve       string
+				Initiated string
+			}{v.ID, v.Name, v.Summary, v.Cvss, v.CorpScore, epssScore(v), cve, v.Dates.Initiated.Format("Mon, 02 Jan 2006 15:04:05")}
+			data = append(data, s)
+		}
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "closed":
+		vulns, err := varsapi.GetClosedVulnerabilities()
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		vulns, err = filterSortVulns(r, vulns)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if f := responseFormat(r, "csv", "xlsx", "sarif", "stix"); f != "json" {
+			exportVulns(w, r, f, v, vulns)
+			return
+		}
+		var data []interface{}
+		for _, v := range vulns {
+			cve := ""
+			cves, err := varsapi.GetCves(v.ID)
+			if err != nil {
+				if !varsapi.IsNoRowsError(err) {
 					logError.Println(err)
 					w.WriteHeader(http.StatusInternalServerError)
 					return
-				} else {
-					w.WriteHeader(http.StatusOK)
 				}
+			}
+			sort.Strings(*cves)
+			cve = strings.Join(*cves, ", ")
+			var mit string
+			if v.Dates.Mitigated.Valid {
+				mit = v.Dates.Mitigated.Time.Format("Mon, 02 Jan 2006 15:04:05")
 			} else {
-				w.WriteHeader(http.StatusUnauthorized)
+				mit = ""
+			}
+			s := struct {
+				ID        int64
+				Name      string
+				Summary   string
+				Cvss      float32
+				CorpScore float32
+				Epss      *float32
+				Cve       string
+				Initiated string
+				Mitigated string
+			}{v.ID, v.Name, v.Summary, v.Cvss, v.CorpScore, epssScore(v), cve, v.Dates.Initiated.Format("Mon, 02 Jan 2006 15:04:05"), mit}
+			data = append(data, s)
+		}
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "cve":
+		vulns, err := varsapi.FindVulnerabilitiesByCve(r.FormValue("cve"))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var data []interface{}
+		for _, v := range vulns {
+			s := struct {
+				ID   int64
+				Name string
+			}{v.ID, v.Name}
+			data = append(data, s)
+		}
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	default:
+		vid, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		vuln, err := varsapi.GetVulnerability(int64(vid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				// The vulnerability may have been merged into another one
+				keep, err := varsapi.GetMergedInto(int64(vid))
+				if err == nil {
+					http.Redirect(w, r, fmt.Sprintf("/vulnerability/%d", keep), http.StatusFound)
+					return
+				}
+				if !varsapi.IsNoRowsError(err) {
+					logError.Println(err)
+				}
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(vuln)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func handleVulnerabilityPut(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	user, err := getSession(r)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	v := ps.ByName("vuln")
+	vid, err := strconv.Atoi(v)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	field := ps.ByName("field")
+	switch field {
+	case "cve":
+		cve := r.FormValue("cve")
+		err := varsapi.AddCve(db, int64(vid), cve)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		encodeCveDuplicates(w, int64(vid), cve)
+	case "ticket":
+		ticket := r.FormValue("ticket")
+		err := varsapi.AddTicket(db, int64(vid), ticket)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "ref":
+		ref := r.FormValue("ref")
+		err := varsapi.AddRef(db, int64(vid), ref)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "affected":
+		sys := r.FormValue("system")
+		sid, err := strconv.Atoi(sys)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = varsapi.AddAffected(db, int64(vid), int64(sid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "related":
+		rv := r.FormValue("vuln")
+		rvid, err := strconv.Atoi(rv)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = varsapi.AddRelated(db, int64(vid), int64(rvid), r.FormValue("type"))
+		if err != nil {
+			if varsapi.IsInvalidValueError(err) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "task":
+		task := vars.Task{VulnID: int64(vid)}
+		err := taskFromForm(r, &task)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = varsapi.AddTask(db, &task)
+		if err != nil {
+			if varsapi.IsInvalidValueError(err) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		ist := struct {
+			ID int64
+		}{task.ID}
+		err = json.NewEncoder(w).Encode(ist)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "note":
+		note := r.FormValue("note")
+		if p := r.FormValue("parent"); p != "" {
+			parent, err := strconv.Atoi(p)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			err = varsapi.AddNoteReply(db, int64(vid), user.Emp.ID, int64(parent), note)
+		} else {
+			err = varsapi.AddNote(db, int64(vid), user.Emp.ID, note)
+		}
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "attachment":
+		r.Body = http.MaxBytesReader(w, r.Body, varsapi.GetAttachMaxSize()+(1<<20))
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		contents, err := ioutil.ReadAll(file)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		att := vars.Attachment{VulnID: int64(vid), EmpID: user.Emp.ID, FileName: filepath.Base(header.Filename)}
+		if sys := r.FormValue("system"); sys != "" {
+			sid, err := strconv.Atoi(sys)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			att.SysID = varsapi.GetVarsNullInt64(int64(sid))
+		}
+		err = varsapi.AddAttachment(db, &att, contents)
+		if err != nil {
+			if varsapi.IsAttachTooLargeError(err) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			if varsapi.IsAttachTypeNotAllowedError(err) {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			if varsapi.IsInvalidValueError(err) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		ist := struct {
+			ID int64
+		}{att.ID}
+		err = json.NewEncoder(w).Encode(ist)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "mitigated":
+		err := varsapi.CloseVulnerability(db, int64(vid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+}
+
+func handleVulnerabilityDelete(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	user, err := getSession(r)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	v := ps.ByName("vuln")
+	vid, err := strconv.Atoi(v)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	field := ps.ByName("field")
+	switch field {
+	case "cve":
+		cve := ps.ByName("item")
+		err := varsapi.DeleteCve(db, int64(vid), cve)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "ticket":
+		ticket := ps.ByName("item")
+		err := varsapi.DeleteTicket(db, int64(vid), ticket)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "ref":
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		ref := string(b)
+		err = varsapi.DeleteRef(db, int64(vid), ref)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "affected":
+		sys := ps.ByName("item")
+		sid, err := strconv.Atoi(sys)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = varsapi.DeleteAffected(db, int64(vid), int64(sid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "related":
+		rvid, err := strconv.Atoi(ps.ByName("item"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		err = varsapi.DeleteRelated(db, int64(vid), int64(rvid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "task":
+		tid, err := strconv.Atoi(ps.ByName("item"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		task, err := varsapi.GetTask(int64(tid))
+		if err != nil || task.VulnID != int64(vid) {
+			if err != nil && !varsapi.IsNoRowsError(err) {
+				logError.Println(err)
 			}
-		case "note":
-			n := ps.ByName("item")
-			nid, err := strconv.Atoi(n)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		err = varsapi.DeleteTask(db, task.ID)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "note":
+		n := ps.ByName("item")
+		nid, err := strconv.Atoi(n)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		author, err := varsapi.GetNoteAuthor(int64(nid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if user.Emp.ID == author {
+			err = varsapi.DeleteNote(db, int64(nid))
 			if err != nil {
+				logError.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	case "attachment":
+		a := ps.ByName("item")
+		aid, err := strconv.Atoi(a)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		att, err := varsapi.GetAttachment(int64(aid))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
 				w.WriteHeader(http.StatusNotFound)
 				return
 			}
-			author, err := varsapi.GetNoteAuthor(int64(nid))
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if att.VulnID != int64(vid) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if user.Emp.ID == att.EmpID {
+			err = varsapi.DeleteAttachment(db, int64(aid))
 			if err != nil {
 				logError.Println(err)
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
-			if user.Emp.Level <= StandardUser && user.Emp.ID == author {
-				err = varsapi.DeleteNote(db, int64(nid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	case "mitigated":
+		err := varsapi.ReopenVulnerability(db, int64(vid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "vuln":
+		err := varsapi.DeleteVulnerability(db, int64(vid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	default:
+		w.WriteHeader(http.StatusTeapot)
+		return
+	}
+}
+
+func handleVulnerabilityPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	logRequest(r)
+	v := ps.ByName("vuln")
+	vid, err := strconv.Atoi(v)
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	field := ps.ByName("field")
+	switch field {
+	case "task":
+		tid, err := strconv.Atoi(ps.ByName("item"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		task, err := varsapi.GetTask(int64(tid))
+		if err != nil || task.VulnID != int64(vid) {
+			if err != nil && !varsapi.IsNoRowsError(err) {
+				logError.Println(err)
 			}
-		case "mitigated":
-			if user.Emp.Level <= PrivilegedUser {
-				err := varsapi.ReopenVulnerability(db, int64(vid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		err = taskFromForm(r, task)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = varsapi.UpdateTask(db, task)
+		if err != nil {
+			if varsapi.IsInvalidValueError(err) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "autofill":
+		cve := r.FormValue("cve")
+		err := varsapi.AutofillFromCatalog(db, int64(vid), cve)
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "clone":
+		user, err := getSession(r)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		name := r.FormValue("name")
+		id, err := varsapi.CloneVulnerability(db, int64(vid), user.Emp.ID, name)
+		if err != nil {
+			if varsapi.IsNameNotAvailableError(err) {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		ist := struct {
+			ID int64
+		}{id}
+		err = json.NewEncoder(w).Encode(ist)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		return
+	case "merge":
+		dropID, err := strconv.Atoi(r.FormValue("vuln"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = varsapi.MergeVulnerabilities(db, int64(vid), int64(dropID))
+		if err != nil {
+			if varsapi.IsNoRowsError(err) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "name":
+		name := r.FormValue("name")
+		err := varsapi.UpdateVulnerabilityName(db, int64(vid), name)
+		if err != nil {
+			if varsapi.IsNameNotAvailableError(err) {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "summary":
+		summ := r.FormValue("summary")
+		err := varsapi.UpdateVulnerabilitySummary(db, int64(vid), summ)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "cve":
+		oldcve := ps.ByName("item")
+		cve := r.FormValue("cve")
+		err := varsapi.UpdateCve(db, int64(vid), oldcve, cve)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		encodeCveDuplicates(w, int64(vid), cve)
+	case "cvss":
+		cvssScore := r.FormValue("cvssScore")
+		cvssLink := r.FormValue("cvssLink")
+		cScore, err := strconv.ParseFloat(cvssScore, 32)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			err := varsapi.UpdateCvss(db, int64(vid), float32(cScore), cvssLink)
+			if err != nil {
+				logError.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
 			} else {
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusOK)
 			}
-		case "vuln":
-			if user.Emp.Level <= PrivilegedUser {
-				err := varsapi.DeleteVulnerability(db, int64(vid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
+		}
+	case "corpscore":
+		corpscore := r.FormValue("corpscore")
+		cScore, err := strconv.ParseFloat(corpscore, 32)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			err := varsapi.UpdateCorpScore(db, int64(vid), float32(cScore))
+			if err != nil {
+				logError.Println(err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
 			} else {
-				w.WriteHeader(http.StatusUnauthorized)
+				w.WriteHeader(http.StatusOK)
 			}
-		default:
-			w.WriteHeader(http.StatusTeapot)
+		}
+	case "finder":
+		finder := r.FormValue("finder")
+		eid, err := strconv.Atoi(finder)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = varsapi.UpdateFinder(db, int64(vid), int64(eid))
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	case "test":
+		test := r.FormValue("test")
+		err := varsapi.UpdateVulnerabilityTest(db, int64(vid), test)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "mitigation":
+		mitigation := r.FormValue("mitigation")
+		err := varsapi.UpdateVulnerabilityMitigation(db, int64(vid), mitigation)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
+		} else {
+			w.WriteHeader(http.StatusOK)
 		}
-	} else {
-		http.Redirect(w, r, "/login", http.StatusFound)
+	case "ticket":
+		oldticket := ps.ByName("item")
+		ticket := r.FormValue("ticket")
+		err := varsapi.UpdateTicket(db, int64(vid), oldticket, ticket)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "ref":
+		oldRef := r.FormValue("oldr")
+		newRef := r.FormValue("newr")
+		err := varsapi.UpdateReference(db, int64(vid), oldRef, newRef)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "exploitable":
+		exploitable := r.FormValue("exploitable")
+		b, err := strconv.ParseBool(exploitable)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = varsapi.UpdateExploitable(db, int64(vid), b)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "exploit":
+		exploit := r.FormValue("exploit")
+		err = varsapi.UpdateExploit(db, int64(vid), exploit)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	case "affected":
+		sys := ps.ByName("item")
+		sid, err := strconv.Atoi(sys)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		patched := r.FormValue("patched")
+		b, err := strconv.ParseBool(patched)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		err = varsapi.UpdateAffected(db, int64(vid), int64(sid), b)
+		if err != nil {
+			logError.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+	default:
+		w.WriteHeader(http.StatusTeapot)
+		return
 	}
 }
 
-func handleVulnerabilityPost(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	logRequest(r)
-	user, err := getSession(r)
+// encodeCveDuplicates writes the vulnerabilities other than vid that list the cve to w, along with the NVD catalog
+// entry of the cve if there is one. The web UI shows the duplicates as a warning after a CVE is added and offers
+// to autofill the vulnerability from the catalog entry.
+func encodeCveDuplicates(w http.ResponseWriter, vid int64, cve string) {
+	dups, err := varsapi.FindCveDuplicates(vid, cve)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	v := ps.ByName("vuln")
-	vid, err := strconv.Atoi(v)
+	var data []interface{}
+	for _, d := range dups {
+		s := struct {
+			ID   int64
+			Name string
+		}{d.ID, d.Name}
+		data = append(data, s)
+	}
+	res := struct {
+		Duplicates []interface{}
+		Catalog    *vars.CatalogEntry
+	}{Duplicates: data}
+	entry, err := varsapi.GetCatalogEntry(cve)
+	if err == nil {
+		res.Catalog = entry
+	} else if !varsapi.IsNoRowsError(err) {
+		logError.Println(err)
+	}
+	err = json.NewEncoder(w).Encode(res)
 	if err != nil {
 		logError.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	field := ps.ByName("field")
-	if user.Authed {
-		switch field {
-		case "name":
-			if user.Emp.Level <= PrivilegedUser {
-				name := r.FormValue("name")
-				err := varsapi.UpdateVulnerabilityName(db, int64(vid), name)
-				if err != nil {
-					if varsapi.IsNameNotAvailableError(err) {
-						w.WriteHeader(http.StatusNotAcceptable)
-						return
-					}
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "summary":
-			if user.Emp.Level <= StandardUser {
-				summ := r.FormValue("summary")
-				err := varsapi.UpdateVulnerabilitySummary(db, int64(vid), summ)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "cve":
-			if user.Emp.Level <= StandardUser {
-				oldcve := ps.ByName("item")
-				cve := r.FormValue("cve")
-				err := varsapi.UpdateCve(db, int64(vid), oldcve, cve)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "cvss":
-			if user.Emp.Level <= StandardUser {
-				cvssScore := r.FormValue("cvssScore")
-				cvssLink := r.FormValue("cvssLink")
-				cScore, err := strconv.ParseFloat(cvssScore, 32)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					err := varsapi.UpdateCvss(db, int64(vid), float32(cScore), cvssLink)
-					if err != nil {
-						logError.Println(err)
-						w.WriteHeader(http.StatusInternalServerError)
-						return
-					} else {
-						w.WriteHeader(http.StatusOK)
-					}
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "corpscore":
-			if user.Emp.Level <= StandardUser {
-				corpscore := r.FormValue("corpscore")
-				cScore, err := strconv.ParseFloat(corpscore, 32)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					err := varsapi.UpdateCorpScore(db, int64(vid), float32(cScore))
-					if err != nil {
-						logError.Println(err)
-						w.WriteHeader(http.StatusInternalServerError)
-						return
-					} else {
-						w.WriteHeader(http.StatusOK)
-					}
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "finder":
-			if user.Emp.Level <= PrivilegedUser {
-				finder := r.FormValue("finder")
-				eid, err := strconv.Atoi(finder)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = varsapi.UpdateFinder(db, int64(vid), int64(eid))
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				w.WriteHeader(http.StatusOK)
-				return
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "test":
-			if user.Emp.Level <= StandardUser {
-				test := r.FormValue("test")
-				err := varsapi.UpdateVulnerabilityTest(db, int64(vid), test)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "mitigation":
-			if user.Emp.Level <= StandardUser {
-				mitigation := r.FormValue("mitigation")
-				err := varsapi.UpdateVulnerabilityMitigation(db, int64(vid), mitigation)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "ticket":
-			if user.Emp.Level <= StandardUser {
-				oldticket := ps.ByName("item")
-				ticket := r.FormValue("ticket")
-				err := varsapi.UpdateTicket(db, int64(vid), oldticket, ticket)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "ref":
-			if user.Emp.Level <= StandardUser {
-				oldRef := r.FormValue("oldr")
-				newRef := r.FormValue("newr")
-				err := varsapi.UpdateReference(db, int64(vid), oldRef, newRef)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "exploitable":
-			if user.Emp.Level <= StandardUser {
-				exploitable := r.FormValue("exploitable")
-				b, err := strconv.ParseBool(exploitable)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = varsapi.UpdateExploitable(db, int64(vid), b)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "exploit":
-			if user.Emp.Level <= StandardUser {
-				exploit := r.FormValue("exploit")
-				err = varsapi.UpdateExploit(db, int64(vid), exploit)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
-			}
-		case "affected":
-			if user.Emp.Level <= StandardUser {
-				sys := ps.ByName("item")
-				sid, err := strconv.Atoi(sys)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				patched := r.FormValue("patched")
-				b, err := strconv.ParseBool(patched)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				err = varsapi.UpdateAffected(db, int64(vid), int64(sid), b)
-				if err != nil {
-					logError.Println(err)
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				} else {
-					w.WriteHeader(http.StatusOK)
+}
+
+// epssScore returns the highest EPSS score of the vulnerability or nil if none of its CVEs are scored.
+func epssScore(v *vars.Vulnerability) *float32 {
+	if v.Epss == nil {
+		return nil
+	}
+	return &v.Epss.Score
+}
+
+// exportVulns writes the vulnerabilities as a CSV, XLSX, SARIF or STIX download named after the list. The TLP level
+// of a STIX bundle is given by the tlp form value.
+func exportVulns(w http.ResponseWriter, r *http.Request, format, list string, vulns []*vars.Vulnerability) {
+	var buf bytes.Buffer
+	var err error
+	filename := "vulnerabilities-" + list + "." + format
+	switch format {
+	case "sarif":
+		err = varsapi.ExportSarif(&buf, vulns)
+	case "stix":
+		tlp := r.FormValue("tlp")
+		if _, e := stix.ParseTlp(tlp); tlp != "" && e != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		err = varsapi.ExportStix(&buf, vulns, tlp)
+		filename += ".json"
+	case "xlsx":
+		err = varsapi.ExportVulnerabilitiesXlsx(&buf, strings.Title(list)+" vulnerabilities", vulns)
+	default:
+		err = varsapi.ExportVulnerabilitiesCsv(&buf, vulns)
+	}
+	if err != nil {
+		logError.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	sendDownload(w, format, filename, buf.Bytes())
+}
+
+// filterSortVulns applies the id selection, the minepss filter and the sort key given in the request to the
+// vulnerabilities.
+func filterSortVulns(r *http.Request, vulns []*vars.Vulnerability) ([]*vars.Vulnerability, error) {
+	r.ParseForm()
+	if ids := r.Form["id"]; len(ids) > 0 {
+		var selected []*vars.Vulnerability
+		for _, v := range vulns {
+			for _, id := range ids {
+				if id == strconv.FormatInt(v.ID, 10) {
+					selected = append(selected, v)
+					break
 				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
 			}
-		default:
-			w.WriteHeader(http.StatusTeapot)
-			return
+		}
+		vulns = selected
+	}
+	if s := r.FormValue("minepss"); s != "" {
+		min, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return vulns, err
+		}
+		vulns = varsapi.FilterVulnerabilitiesByEpss(vulns, float32(min))
+	}
+	if key := r.FormValue("sort"); key != "" {
+		if err := varsapi.SortVulnerabilities(vulns, key); err != nil {
+			return vulns, err
 		}
 	}
+	return vulns, nil
 }
 
 // getSession unpacks the objects from the session cookie associated with the request and returns them.
 func getSession(r *http.Request) (*User, error) {
+	if user, ok := r.Context().Value(userKey{}).(*User); ok {
+		return user, nil
+	}
 	var user User
 	session := sessionManager.Load(r)
 	err := session.GetObject("user", &user)
 	if err != nil {
 		return &user, err
 	}
+	if user.Authed {
+		// Refresh the employee so deactivations and changes take effect on existing sessions
+		emp, err := varsapi.GetEmployeeByID(user.Emp.ID)
+		if err != nil {
+			return &user, err
+		}
+		if !emp.Active {
+			user.Authed = false
+		}
+		user.Emp = emp
+
+		// Load the roles so changes to their permissions take effect without a restart
+		user.roles, err = varsapi.GetRoles()
+		if err != nil {
+			return &user, err
+		}
+	}
 	return &user, nil
 }
 
 // logRequest logs the incoming http request including client IP and port.
 func logRequest(r *http.Request) {
-	reqDump, err := httputil.DumpRequest(r, true)
+	// Uploads are not logged, and their body is left unread so the size limits of the handlers still apply
+	body := r.ContentLength >= 0 && r.ContentLength <= maxLoggedBody
+	if ct, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil {
+		if strings.HasPrefix(ct, "multipart/") || ct == "application/octet-stream" {
+			body = false
+		}
+	}
+	reqDump, err := httputil.DumpRequest(r, body)
 	if err != nil {
 		logError.Printf("Error dumping request: %v\n", err)
 	}
@@ -2029,3 +2726,119 @@ func logRequest(r *http.Request) {
 	req = strings.Replace(req, "\r\n", " | ", -1)
 	logInfo.Println(cookRegex.ReplaceAllString(req, "session=****"))
 }
+
+// responseFormat returns the format requested by the format parameter or else the Accept header of the request if
+// it is one of the formats, and json otherwise.
+func responseFormat(r *http.Request, formats ...string) string {
+	if f := strings.ToLower(r.URL.Query().Get("format")); f != "" {
+		for _, format := range formats {
+			if f == format {
+				return f
+			}
+		}
+		return "json"
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, _, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+		for _, format := range formats {
+			if mt == mediaTypes[format] {
+				return format
+			}
+		}
+	}
+	return "json"
+}
+
+// sendDownload writes the data as an attachment of the format with the file name.
+func sendDownload(w http.ResponseWriter, format, filename string, data []byte) {
+	w.Header().Set("Content-Type", mediaTypes[format])
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.Write(data)
+}
+
+// taskFromForm updates the fields of the task that are present in the form values of the request. An empty
+// assignee, due date (YYYY-MM-DD) or system clears that field.
+func taskFromForm(r *http.Request, task *vars.Task) error {
+	r.ParseForm()
+	if _, ok := r.Form["title"]; ok {
+		task.Title = strings.TrimSpace(r.FormValue("title"))
+	}
+	if _, ok := r.Form["assignee"]; ok {
+		task.Assignee = vars.VarsNullInt64{}
+		if a := r.FormValue("assignee"); a != "" {
+			eid, err := strconv.Atoi(a)
+			if err != nil {
+				return err
+			}
+			task.Assignee = varsapi.GetVarsNullInt64(int64(eid))
+		}
+	}
+	if _, ok := r.Form["due"]; ok {
+		task.Due = vars.VarsNullTime{}
+		if d := r.FormValue("due"); d != "" {
+			due, err := time.Parse("2006-01-02", d)
+			if err != nil {
+				return err
+			}
+			task.Due = varsapi.GetVarsNullTime(due)
+		}
+	}
+	if _, ok := r.Form["done"]; ok {
+		done, err := strconv.ParseBool(r.FormValue("done"))
+		if err != nil {
+			return err
+		}
+		task.Done = done
+	}
+	if _, ok := r.Form["system"]; ok {
+		task.System = vars.VarsNullInt64{}
+		if sys := r.FormValue("system"); sys != "" {
+			sid, err := strconv.Atoi(sys)
+			if err != nil {
+				return err
+			}
+			task.System = varsapi.GetVarsNullInt64(int64(sid))
+		}
+	}
+	return nil
+}
+
+// templateFromForm builds a vars.Template from the form values of the request. The references are given as
+// one or more ref values.
+func templateFromForm(r *http.Request) (*vars.Template, error) {
+	tmpl := vars.Template{
+		Name:       strings.TrimSpace(r.FormValue("name")),
+		Summary:    r.FormValue("summary"),
+		Test:       r.FormValue("test"),
+		Mitigation: r.FormValue("mitigation"),
+	}
+	if tmpl.Name == "" {
+		return &tmpl, fmt.Errorf("template name is required")
+	}
+	if cvss := r.FormValue("cvssScore"); cvss != "" {
+		score, err := strconv.ParseFloat(cvss, 32)
+		if err != nil {
+			return &tmpl, err
+		}
+		tmpl.Cvss = float32(score)
+	}
+	if corp := r.FormValue("corpscore"); corp != "" {
+		score, err := strconv.ParseFloat(corp, 32)
+		if err != nil {
+			return &tmpl, err
+		}
+		tmpl.CorpScore = float32(score)
+	}
+	if link := r.FormValue("cvssLink"); link != "" {
+		tmpl.CvssLink = varsapi.GetVarsNullString(link)
+	}
+	for _, ref := range r.Form["ref"] {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			tmpl.References = append(tmpl.References, ref)
+		}
+	}
+	return &tmpl, nil
+}
@@ -41,9 +41,9 @@ function hideModalEdit() {
     $('#emp-modal-uname').attr('readonly', true);
     $('#emp-modal-uname').addClass('form-control-plaintext');
     $('#emp-modal-uname').removeClass('form-control');
-    $('#emp-modal-level').attr('readonly', true);
-    $('#emp-modal-level').addClass('form-control-plaintext');
-    $('#emp-modal-level').removeClass('form-control');
+    $('#emp-modal-role').attr('readonly', true);
+    $('#emp-modal-role').addClass('form-control-plaintext');
+    $('#emp-modal-role').removeClass('form-control');
     $('.eme-btn-submit').hide();
 }
 
@@ -67,14 +67,15 @@ function handleAddEmp() {
     $('#emp-modal-uname').removeClass('form-control-plaintext');
     $('#emp-modal-uname').addClass('form-control');
     $('#emp-modal-uname').val('');
-    $('#emp-modal-level').removeAttr('readonly');
-    $('#emp-modal-level').removeClass('form-control-plaintext');
-    $('#emp-modal-level').addClass('form-control');
-    $('#emp-modal-level').val('2');
+    $('#emp-modal-role').removeAttr('readonly');
+    $('#emp-modal-role').removeClass('form-control-plaintext');
+    $('#emp-modal-role').addClass('form-control');
+    $('#emp-modal-role').val('standard');
     $('.eme-btn-submit').hide();
     $('.eme-pen').hide();
     $('#modal-add-emp-btn').show();
     $('#modal-delete-emp-btn').hide();
+    $('#modal-activate-emp-btn').hide();
 }
 
 function setupHover() {
@@ -100,10 +101,10 @@ function setupHover() {
             $('.eme-btn-uname').hide();
         }
     );
-    $('#emp-modal-section-level').hover(function() {
-            $('.eme-btn-level').show();
+    $('#emp-modal-section-role').hover(function() {
+            $('.eme-btn-role').show();
         }, function() {
-            $('.eme-btn-level').hide();
+            $('.eme-btn-role').hide();
         }
     );
 }
@@ -112,7 +113,7 @@ function unbindHover() {
     $('#emp-modal-section-title').unbind('mouseenter mouseleave');
     $('#emp-modal-section-email').unbind('mouseenter mouseleave');
     $('#emp-modal-section-uname').unbind('mouseenter mouseleave');
-    $('#emp-modal-section-level').unbind('mouseenter mouseleave');
+    $('#emp-modal-section-role').unbind('mouseenter mouseleave');
 }
 
 function handleFuzzySearch() {
@@ -133,9 +134,31 @@ function handlePromptChoice(btnId, choice) {
         case 'emp':
             if (choice == 'yes') {
                 var eid = $('#emp-modal-empid').text();
+                var reassign = $('#emp-modal-reassign').val();
+                var url = '/employee/'+eid;
+                if (reassign != '') {
+                    url += '?reassign='+reassign;
+                }
                 $.ajax({
                     method : 'DELETE',
-                    url    : '/employee/'+eid,
+                    url    : url,
+                    success: function(data) {
+                        $('#emp-modal').modal('hide');
+                        $("tr[data-eid='"+eid+"']").remove();
+                    },
+                    error: function() {
+                        $('#vuln-modal-alert-danger').show();
+                        $('#vuln-modal').scrollTop(0);
+                    }
+                });
+            }
+            break;
+        case 'emp-activate':
+            if (choice == 'yes') {
+                var eid = $('#emp-modal-empid').text();
+                $.ajax({
+                    method : 'POST',
+                    url    : '/employee/'+eid+'/active',
                     success: function(data) {
                         $('#emp-modal').modal('hide');
                         $("tr[data-eid='"+eid+"']").remove();
@@ -186,12 +209,12 @@ function showModalEdit(btnID, num) {
                 hideModalEdit();
             }
             break;
-        case 'emp-modal-edit-level':
-            if ($('#emp-modal-level').is('[readonly]')) {
-                $('#emp-modal-level').removeAttr('readonly');
-                $('#emp-modal-level').removeClass('form-control-plaintext');
-                $('#emp-modal-level').addClass('form-control');
-                $('#emp-modal-form-level button').show();
+        case 'emp-modal-edit-role':
+            if ($('#emp-modal-role').is('[readonly]')) {
+                $('#emp-modal-role').removeAttr('readonly');
+                $('#emp-modal-role').removeClass('form-control-plaintext');
+                $('#emp-modal-role').addClass('form-control');
+                $('#emp-modal-form-role button').show();
             } else {
                 hideModalEdit();
             }
@@ -200,15 +223,42 @@ function showModalEdit(btnID, num) {
     hideAlerts();
 }
 
+function loadReassignList(eid) {
+    $('#emp-modal-reassign').find('option:not(:first)').remove();
+    $.ajax({
+        method   : 'GET',
+        dataType : 'json',
+        url      : '/employee/active',
+        success: function(data) {
+            if (data != null) {
+                for (i=0; i < data.length; i++) {
+                    if (data[i].ID != eid) {
+                        $('#emp-modal-reassign').append($('<option>').val(data[i].ID).text(data[i].FirstName+' '+data[i].LastName));
+                    }
+                }
+            }
+        }
+    });
+}
+
 function showModalPrompt(btnID, num) {
     hideAlerts();
     switch(btnID) {
         case 'modal-delete-emp-btn':
-            $('#emp-modal-alert-warning-item').text('Delete this employee?  ');
+            $('#emp-modal-alert-warning-item').text('Deactivate this employee? Reassign their open VAs to:  ');
+            loadReassignList($('#emp-modal-empid').text());
+            $('#emp-modal-reassign').show();
             $('#emp-modal-warning-yes').attr('onclick', 'handlePromptChoice("emp", "yes")');
             $('#emp-modal-warning-no').attr('onclick', 'handlePromptChoice("emp", "no")');
             $('#emp-modal-alert-warning').show();
             break;
+        case 'modal-activate-emp-btn':
+            $('#emp-modal-alert-warning-item').text('Re-activate this employee?  ');
+            $('#emp-modal-reassign').hide();
+            $('#emp-modal-warning-yes').attr('onclick', 'handlePromptChoice("emp-activate", "yes")');
+            $('#emp-modal-warning-no').attr('onclick', 'handlePromptChoice("emp-activate", "no")');
+            $('#emp-modal-alert-warning').show();
+            break;
     }
     $('#emp-modal').scrollTop(0);
 }
@@ -226,18 +276,20 @@ $('#emp-modal').on('show.bs.modal', function (event) {
             if(this.readyState == 4 && this.status == 200) {
                 var emp = JSON.parse(this.responseText);
                 modal.find('#modal-delete-emp-btn').show();
+                modal.find('#modal-activate-emp-btn').hide();
                 modal.find('#emp-modal-fname').val(emp.FirstName);
                 modal.find('#emp-modal-lname').val(emp.LastName);
                 modal.find('#emp-modal-empid').text(emp.ID);
                 modal.find('#emp-modal-email').val(emp.Email);
                 modal.find('#emp-modal-uname').val(emp.UserName);
-                modal.find('#emp-modal-level').val(emp.Level);
+                modal.find('#emp-modal-role').val(emp.Role);
                 hideModalEdit();
                 hideAlerts();
                 setupHover();
                 $('.eme-btn').hide();
-                if (emp.UserName == "VARSremoved") {
+                if (!emp.Active) {
                     modal.find('#modal-delete-emp-btn').hide();
+                    modal.find('#modal-activate-emp-btn').show();
                 }
             }
         };
@@ -263,7 +315,7 @@ function loadEmpTable(state) {
         success : function(data) {
             if (data != null) {
                 for (i=0; i < data.length; i++) {
-                    $('#emp-table tbody').append('<tr data-toggle="modal" data-target="#emp-modal" data-eid="'+data[i].ID+'"><td>'+data[i].FirstName+'</td><td>'+data[i].LastName+'</td><td>'+data[i].Email+'</td><td>'+data[i].UserName+'</td><td>'+data[i].Level+'</td></tr>');
+                    $('#emp-table tbody').append('<tr data-toggle="modal" data-target="#emp-modal" data-eid="'+data[i].ID+'"><td>'+data[i].FirstName+'</td><td>'+data[i].LastName+'</td><td>'+data[i].Email+'</td><td>'+data[i].UserName+'</td><td>'+data[i].Role+'</td></tr>');
                 }
             }
         },
@@ -342,21 +394,21 @@ $(document).ready(function() {
             }
 		});
 	});
-	$('#emp-modal-form-level').on('submit', function(event) {
+	$('#emp-modal-form-role').on('submit', function(event) {
 		event.preventDefault();
-		var fdata = $('#emp-modal-form-level').serialize();
+		var fdata = $('#emp-modal-form-role').serialize();
 		var eid = $('#emp-modal-empid').text();
-		var level = $('#emp-modal-level').val();
+		var role = $('#emp-modal-role').val();
 		$.ajax({
 			method : 'POST',
-			url    : '/employee/'+eid+'/level',
+			url    : '/employee/'+eid+'/role',
 			data   : fdata,
 			success: function(data) {
                 hideModalEdit();
                 hideAlerts();
 				$('#emp-modal-alert-success').show();
                 $('#emp-modal').scrollTop(0);
-				$("tr[data-eid='"+eid+"']").find("td:eq(4)").text(level);
+				$("tr[data-eid='"+eid+"']").find("td:eq(4)").text(role);
 			},
             error: function() {
                 $('#emp-modal-alert-danger').show();
@@ -369,20 +421,20 @@ $(document).ready(function() {
         var dataTitle = $('#emp-modal-form-title').serialize();
         var dataEmail = $('#emp-modal-form-email').serialize();
         var dataUname = $('#emp-modal-form-uname').serialize();
-        var dataLevel = $('#emp-modal-form-level').serialize();
-        var fdata     = dataTitle+'&'+dataEmail+'&'+dataUname+'&'+dataLevel;
+        var dataRole  = $('#emp-modal-form-role').serialize();
+        var fdata     = dataTitle+'&'+dataEmail+'&'+dataUname+'&'+dataRole;
         var fname     = $('#emp-modal-fname').val();
         var lname     = $('#emp-modal-lname').val();
         var email     = $('#emp-modal-email').val();
         var uname     = $('#emp-modal-uname').val();
-        var level     = $('#emp-modal-level').val();
+        var role      = $('#emp-modal-role').val();
 		$.ajax({
 			method  : 'PUT',
 			url     : '/employee',
             dataType: 'json',
 			data    : fdata,
 			success : function(data) {
-                $('#emp-table tbody').append('<tr data-toggle="modal" data-target="#emp-modal" data-eid="'+data.ID+'"><td>'+fname+'</td><td>'+lname+'</td><td>'+email+'</td><td>'+uname+'</td><td>'+level+'</td></tr>');
+                $('#emp-table tbody').append('<tr data-toggle="modal" data-target="#emp-modal" data-eid="'+data.ID+'"><td>'+fname+'</td><td>'+lname+'</td><td>'+email+'</td><td>'+uname+'</td><td>'+role+'</td></tr>');
                 $('#emp-modal').modal('hide');
 			},
             error: function(j, s, err) {
@@ -26,10 +26,236 @@ function hideAlerts() {
     $('#vuln-modal-alert-warning-item').text('');
     $('#vuln-modal-warning-yes').attr('onclick', 'placeholder()');
     $('#vuln-modal-warning-no').attr('onclick', 'placeholder()');
+    $('#vuln-modal-alert-duplicate').hide();
+    $('#vuln-modal-alert-duplicate-item').text('');
+    $('#vuln-modal-alert-autofill').hide();
+    $('#vuln-modal-alert-autofill-item').text('');
+}
+
+var taskAssignees = {};
+var taskSystems = {};
+
+function loadTaskAssignees(done) {
+    $('#vuln-modal-task-assignee').empty();
+    $('#vuln-modal-task-assignee').append('<option value="" selected>Unassigned</option>');
+    $.ajax({
+        method  : 'GET',
+        url     : '/employee/list',
+        dataType: 'json',
+        success : function(data) {
+            taskAssignees = {};
+            for (i = 0; i < data.length; i++) {
+                taskAssignees[data[i].ID] = data[i].Name;
+                $('#vuln-modal-task-assignee').append($('<option>').val(data[i].ID).text(data[i].Name));
+            }
+        },
+        complete: function() {
+            done();
+        }
+    });
+}
+
+function showTasks(tasks, progress) {
+    $('#vuln-modal-task-progress').css('width', progress+'%').attr('aria-valuenow', progress).text(progress+'%');
+    $('#vuln-modal-task-table').empty();
+    if (tasks == null) {
+        return;
+    }
+    for (i = 0; i < tasks.length; i++) {
+        appendTask(tasks[i]);
+    }
+}
+
+function appendTask(task) {
+    var row = $('<tr>');
+    var check = $('<input type="checkbox">').prop('checked', task.Done);
+    check.on('change', function() {
+        updateTask(task.ID, {done: $(this).is(':checked')});
+    });
+    var del = $('<button type="button" class="btn-sm bg-white text-danger border-0" aria-label="Delete"><span aria-hidden="true">&times;</span></button>');
+    del.on('click', function() {
+        deleteTask(task.ID);
+    });
+    var assignee = '';
+    if (task.Assignee != null) {
+        assignee = taskAssignees[task.Assignee] || ('Employee ' + task.Assignee);
+    }
+    var due = '';
+    if (task.Due != null) {
+        due = new Date(task.Due).toISOString().substring(0, 10);
+    }
+    var sys = 'All systems';
+    if (task.System != null) {
+        sys = taskSystems[task.System] || ('System ' + task.System);
+    }
+    row.append($('<td>').append(check));
+    row.append($('<td>').text(task.Title));
+    row.append($('<td>').text(assignee));
+    row.append($('<td>').text(due));
+    row.append($('<td>').text(sys));
+    row.append($('<td>').append(del));
+    $('#vuln-modal-task-table').append(row);
+}
+
+function reloadTasks(vid) {
+    $.ajax({
+        method  : 'GET',
+        url     : '/vulnerability/'+vid+'/task',
+        dataType: 'json',
+        success : function(data) {
+            showTasks(data.Tasks, data.Progress);
+        }
+    });
+}
+
+function updateTask(tid, fdata) {
+    var vid = $('#vuln-modal-vulnid').text();
+    $.ajax({
+        method : 'POST',
+        url    : '/vulnerability/'+vid+'/task/'+tid,
+        data   : fdata,
+        success: function(data) {
+            reloadTasks(vid);
+        },
+        error: function() {
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+            reloadTasks(vid);
+        }
+    });
+}
+
+function deleteTask(tid) {
+    var vid = $('#vuln-modal-vulnid').text();
+    $.ajax({
+        method : 'DELETE',
+        url    : '/vulnerability/'+vid+'/task/'+tid,
+        success: function(data) {
+            reloadTasks(vid);
+        },
+        error: function() {
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+        }
+    });
+}
+
+function showCveDuplicates(data) {
+    if (data != null && data.Catalog != null) {
+        showAutofill(data.Catalog);
+    }
+    if (data == null || data.Duplicates == null || data.Duplicates.length == 0) {
+        return;
+    }
+    var names = [];
+    for (i = 0; i < data.Duplicates.length; i++) {
+        names.push(data.Duplicates[i].Name + ' (' + data.Duplicates[i].ID + ')');
+    }
+    $('#vuln-modal-alert-duplicate-item').text('This CVE is also listed on: ' + names.join(', '));
+    $('#vuln-modal-alert-duplicate').show();
+}
+
+function showAutofill(entry) {
+    var msg = entry.Cve + ' is in the NVD catalog';
+    if (entry.CvssVector != null) {
+        msg += ' (CVSS ' + entry.CvssScore + ')';
+    }
+    $('#vuln-modal-alert-autofill-item').text(msg + '. Fill in the empty fields, CWEs and references from it?');
+    $('#vuln-modal-autofill-yes').off('click').on('click', function() {
+        autofillFromCatalog(entry.Cve);
+    });
+    $('#vuln-modal-alert-autofill').show();
+}
+
+function autofillFromCatalog(cve) {
+    var vid = $('#vuln-modal-vulnid').text();
+    $.ajax({
+        method : 'POST',
+        url    : '/vulnerability/'+vid+'/autofill',
+        data   : {cve: cve},
+        success: function() {
+            $('#vuln-modal-alert-autofill').hide();
+            $.ajax({
+                method  : 'GET',
+                url     : '/vulnerability/'+vid,
+                dataType: 'json',
+                success : function(vuln) {
+                    updateVulnModal(vuln, $('#vuln-modal'));
+                    $('#vuln-modal-alert-success').show();
+                    $('#vuln-modal').scrollTop(0);
+                }
+            });
+        },
+        error: function() {
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+        }
+    });
+}
+
+function relatedLabel(type) {
+    switch(type) {
+        case 'duplicate-of':
+            return 'Duplicate of';
+        case 'duplicated-by':
+            return 'Duplicated by';
+        case 'supersedes':
+            return 'Supersedes';
+        case 'superseded-by':
+            return 'Superseded by';
+        default:
+            return 'Related to';
+    }
+}
+
+function appendRelated(rel) {
+    var row = $('<div class="row justify-content-start">');
+    var btn = $('<button type="button" class="btn-sm bg-white text-danger border-0 vme-btn vme-btn-related" aria-label="Delete"><span aria-hidden="true">&times;</span></button>');
+    btn.on('click', function() {
+        deleteRelated(rel.VulnID);
+    });
+    row.append($('<div class="col-1">').append(btn));
+    row.append($('<div class="col-11">').append($('<p>').text(relatedLabel(rel.Type) + ': ' + rel.Name + ' (' + rel.VulnID + ')')));
+    $('#vuln-modal-related-list').append(row);
+}
+
+function deleteRelated(relid) {
+    var vid = $('#vuln-modal-vulnid').text();
+    $.ajax({
+        method : 'DELETE',
+        url    : '/vulnerability/'+vid+'/related/'+relid,
+        success: function(data) {
+            $('#vuln-modal-alert-success').show();
+            $('#vuln-modal').scrollTop(0);
+            reloadRelated(vid);
+        },
+        error: function() {
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+        }
+    });
+}
+
+function reloadRelated(vid) {
+    $.ajax({
+        method  : 'GET',
+        url     : '/vulnerability/'+vid,
+        dataType: 'json',
+        success : function(vuln) {
+            $('#vuln-modal-related-list').empty();
+            if (vuln.Related != null) {
+                for (i = 0; i < vuln.Related.length; i++) {
+                    appendRelated(vuln.Related[i]);
+                }
+            }
+        }
+    });
 }
 
 function hideModalEdit() {
     $('#vuln-modal-div-add-cve').hide();
+    $('#vuln-modal-div-add-related').hide();
+    $('#vuln-modal-div-add-task').hide();
     $('#vuln-modal-div-add-ticket').hide();
     $('#vuln-modal-div-add-ref').hide();
     $('#vuln-modal-div-add-affected').hide();
@@ -75,11 +301,17 @@ function undoAddHides() {
     $('#vuln-modal-section-refs').show();
     $('#vuln-modal-section-tickets').show();
     $('#vuln-modal-section-cve').show();
+    $('#vuln-modal-section-related').show();
+    $('#vuln-modal-section-tasks').show();
     $('#vuln-modal-section-date-opened').show();
     $('#vuln-modal-section-date-closed').show();
     $('#modal-close-vuln-btn').show();
     $('#modal-reopen-vuln-btn').show();
     $('#modal-delete-vuln-btn').show();
+    $('#modal-merge-vuln-div').show();
+    $('#modal-clone-vuln-div').show();
+    $('#modal-export-vuln-div').show();
+    $('#vuln-modal-section-template').hide();
 }
 
 function handleAddVuln() {
@@ -128,6 +360,10 @@ function handleAddVuln() {
     $('#vuln-modal-ticket-list').empty();
     $('#vuln-modal-section-tickets').hide();
     $('#vuln-modal-section-cve').hide();
+    $('#vuln-modal-related-list').empty();
+    $('#vuln-modal-section-related').hide();
+    $('#vuln-modal-task-table').empty();
+    $('#vuln-modal-section-tasks').hide();
     $('#vuln-modal-section-date-opened').hide();
     $('#vuln-modal-section-date-closed').hide();
     $('.vme-btn-submit').hide();
@@ -135,7 +371,81 @@ function handleAddVuln() {
     $('#modal-close-vuln-btn').hide();
     $('#modal-reopen-vuln-btn').hide();
     $('#modal-delete-vuln-btn').hide();
+    $('#modal-merge-vuln-div').hide();
+    $('#modal-clone-vuln-div').hide();
+    $('#modal-export-vuln-div').hide();
     $('#modal-add-vuln-btn').show();
+    appendTemplateList();
+    $('#vuln-modal-section-template').show();
+}
+
+function appendTemplateList() {
+    $('#vuln-modal-template').empty();
+    $('#vuln-modal-template').append('<option value="" selected>No template</option>');
+    $.ajax({
+        method  : 'GET',
+        url     : '/template/all',
+        dataType: 'json',
+        success : function(data) {
+            if (data == null) {
+                return;
+            }
+            for (i = 0; i < data.length; i++) {
+                $('#vuln-modal-template').append($('<option>').val(data[i].ID).text(data[i].Name));
+            }
+        }
+    });
+}
+
+function applyTemplate(tid) {
+    if (tid == '') {
+        return;
+    }
+    $.ajax({
+        method  : 'GET',
+        url     : '/template/'+tid,
+        dataType: 'json',
+        success : function(tmpl) {
+            $('#vuln-modal-summary').val(tmpl.Summary);
+            $('#vuln-modal-test').val(tmpl.Test);
+            $('#vuln-modal-mitigation').val(tmpl.Mitigation);
+            $('#vuln-modal-cvss-edit').val(tmpl.Cvss);
+            if (tmpl.CvssLink != null) {
+                $('#vuln-modal-cvss-link-edit').val(tmpl.CvssLink);
+            }
+            $('#vuln-modal-corpscore').val(tmpl.CorpScore);
+        },
+        error: function() {
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+        }
+    });
+}
+
+function handleCloneVuln() {
+    var vid = $('#vuln-modal-vulnid').text();
+    var name = $('#modal-clone-vuln-name').val();
+    if (name.trim() == '') {
+        return;
+    }
+    $.ajax({
+        method  : 'POST',
+        url     : '/vulnerability/'+vid+'/clone',
+        data    : {name: name},
+        dataType: 'json',
+        success : function(data) {
+            $('#modal-clone-vuln-name').val('');
+            $('#vuln-modal').modal('hide');
+            loadVulnTable('open');
+        },
+        error: function(j, s, err) {
+            if (err == 'Not Acceptable') {
+                $('#vuln-modal-alert-danger-item').text('That name is already taken');
+            }
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+        }
+    });
 }
 
 function setupHover() {
@@ -161,6 +471,18 @@ function setupHover() {
             $('.vme-btn-cve').hide();
         }
     );
+    $('#vuln-modal-section-related').hover(function() {
+            $('.vme-btn-related').show();
+        }, function() {
+            $('.vme-btn-related').hide();
+        }
+    );
+    $('#vuln-modal-section-tasks').hover(function() {
+            $('.vme-btn-tasks').show();
+        }, function() {
+            $('.vme-btn-tasks').hide();
+        }
+    );
     $('#vuln-modal-section-cvss').hover(function() {
             $('.vme-btn-cvss').show();
         }, function() {
@@ -237,7 +559,7 @@ function updateTableCve(vid) {
         url     : '/vulnerability/'+vid+'/cve',
         dataType: 'json',
         success : function(data) {
-            $("tr[data-vid='"+vid+"']").find("td:eq(4)").text(data.CVE);
+            $("tr[data-vid='"+vid+"']").find("td:eq(5)").text(data.CVE);
         },
         error: function() {
             alert('Error updating the CVE column of the vulnerability table. You may need to refresh the page.');
@@ -249,7 +571,7 @@ function handleFuzzySearch() {
     var str = $('#vuln-table-search').val().toLowerCase();
     $('#vuln-table tbody tr').each(function() {
         var name = $(this).find('td:eq(0)').text().toLowerCase();
-        var cves = $(this).find('td:eq(4)').text().toLowerCase();
+        var cves = $(this).find('td:eq(5)').text().toLowerCase();
         if (!fuzzysearch(str, name) && !fuzzysearch(str, cves)) {
             $(this).hide();
         } else {
@@ -267,6 +589,20 @@ function handleModalAddItem(btnID) {
                 $('#vuln-modal-div-add-cve').hide();
             }
             break;
+        case 'vuln-modal-add-related':
+            if ($('#vuln-modal-div-add-related').is(':hidden')) {
+                $('#vuln-modal-div-add-related').show();
+            } else {
+                $('#vuln-modal-div-add-related').hide();
+            }
+            break;
+        case 'vuln-modal-add-task':
+            if ($('#vuln-modal-div-add-task').is(':hidden')) {
+                $('#vuln-modal-div-add-task').show();
+            } else {
+                $('#vuln-modal-div-add-task').hide();
+            }
+            break;
         case 'vuln-modal-add-ticket':
             if ($('#vuln-modal-div-add-ticket').is(':hidden')) {
                 $('#vuln-modal-div-add-ticket').show();
@@ -533,6 +869,16 @@ function showModalPrompt(btnID, num) {
             $('#vuln-modal-warning-no').attr('onclick', 'handlePromptChoice("vuln", "no")');
             $('#vuln-modal-alert-warning').show();
             break;
+        case 'modal-merge-vuln-btn':
+            var dropID = parseInt($('#modal-merge-vuln-id').val(), 10);
+            if (isNaN(dropID)) {
+                return;
+            }
+            $('#vuln-modal-alert-warning-item').text('Merge vulnerability '+dropID+' into this vulnerability analysis? It will be deleted.  ');
+            $('#vuln-modal-warning-yes').attr('onclick', 'handlePromptChoice("merge", "yes", '+dropID+')');
+            $('#vuln-modal-warning-no').attr('onclick', 'handlePromptChoice("merge", "no", '+dropID+')');
+            $('#vuln-modal-alert-warning').show();
+            break;
         case 'modal-reopen-vuln-btn':
             $('#vuln-modal-alert-warning-item').text('Re-open this vulnerability analysis?  ');
             $('#vuln-modal-warning-yes').attr('onclick', 'handlePromptChoice("reopen", "yes")');
@@ -704,6 +1050,34 @@ function handlePromptChoice(btnId, choice, item, itemID) {
                 });
             }
             break;
+        case 'merge':
+            if (choice == 'yes') {
+                var vid = $('#vuln-modal-vulnid').text();
+                $.ajax({
+                    method : 'POST',
+                    url    : '/vulnerability/'+vid+'/merge',
+                    data   : {vuln: item},
+                    success: function(data) {
+                        $("tr[data-vid='"+item+"']").remove();
+                        $('#modal-merge-vuln-id').val('');
+                        $.ajax({
+                            method  : 'GET',
+                            url     : '/vulnerability/'+vid,
+                            dataType: 'json',
+                            success : function(vuln) {
+                                updateVulnModal(vuln, $('#vuln-modal'));
+                                $('#vuln-modal-alert-success').show();
+                                $('#vuln-modal').scrollTop(0);
+                            }
+                        });
+                    },
+                    error: function() {
+                        $('#vuln-modal-alert-danger').show();
+                        $('#vuln-modal').scrollTop(0);
+                    }
+                });
+            }
+            break;
         case 'reopen':
             if (choice == 'yes') {
                 var vid = $('#vuln-modal-vulnid').text();
@@ -808,9 +1182,11 @@ function appendCve(cve, num) {
             method : 'POST',
             url    : '/vulnerability/'+vid+'/cve/'+cveString,
             data   : fdata,
+            dataType: 'json',
             success: function(data) {
                 hideModalEdit();
                 $('#vuln-modal-alert-success').show();
+                showCveDuplicates(data);
                 $('#vuln-modal').scrollTop(0);
                 $('#vuln-modal-edit-cve-'+cveid+'-submit').hide();
                 $('#vuln-modal-edit-cve-'+cveid+'-btn').show();
@@ -888,8 +1264,17 @@ function appendNotes(vid) {
         success: function(data) {
             if (data != null) {
                 for (i=0; i < data.length; i++) {
+                    // Replies go in the replies of the note they answer, which was added before them
+                    var list = $('#vuln-modal-notes-list');
+                    if (data[i].Parent != null && $('#vuln-note-replies-'+data[i].Parent).length) {
+                        list = $('#vuln-note-replies-'+data[i].Parent);
+                    }
+                    var edited = (data[i].Edited) ? ' <small><a href="#" class="text-muted" onclick="showNoteRevisions('+data[i].Nid+'); return false;">(edited)</a></small>' : '';
+                    var pub = (data[i].Public) ? ' <span class="badge badge-info">public</span>' : '';
+                    var reply = (data[i].Reply) ? '<button type="button" class="btn-sm bg-dark text-info border-0" onclick="showNoteReply('+data[i].Nid+')">Reply</button><form class="form-inline" id="vuln-note-reply-form-'+data[i].Nid+'" style="display: none;"><input type="hidden" name="parent" value="'+data[i].Nid+'"><textarea class="form-control" name="note" rows="3" cols="60"></textarea><button type="submit" class="btn btn-dark">Submit</button></form>' : '';
+                    var thread = '<div class="ml-4" id="vuln-note-replies-'+data[i].Nid+'"></div>';
                     if (data[i].Editable) {
-                        $('#vuln-modal-notes-list').append('<div class="card text-white bg-dark mb-3" id="vuln-note-'+data[i].Nid+'"><div class="card-header"><button type="button" class="btn-sm bg-dark text-success border-0" id="vuln-modal-edit-note-'+data[i].Nid+'-btn" onclick="showModalEdit(\'note\','+data[i].Nid+')" aria-label="Edit"> <span aria-hidden="true">&#9998;</span></button> <button type="button" class="btn-sm bg-dark text-danger border-0" id="vuln-modal-delete-note-'+data[i].Nid+'-btn" data-delete-btn-group="ref" onclick="showModalPrompt(\'note\','+data[i].Nid+')" aria-label="Delete"><span aria-hidden="true">&times;</span></button><p class="text-right">'+data[i].Added+'</p></div><div class="card-body"><h4 class="card-title">'+data[i].Emp+'</h4><form class="form-inline" id="vuln-modal-form-note-'+data[i].Nid+'"> <textarea class="form-control-plaintext edit-note-input text-white bg-dark" readonly id="vuln-modal-edit-note-'+data[i].Nid+'"value="'+data[i].Nid+'" name="note" rows="4" cols="65">'+data[i].Note+'</textarea><button type="submit" class="btn btn-dark vme-btn-submit" id="vuln-modal-edit-note-'+data[i].Nid+'-submit">Submit</button></form></div></div></div></div>');
+                        list.append('<div class="card text-white bg-dark mb-3" id="vuln-note-'+data[i].Nid+'"><div class="card-header"><button type="button" class="btn-sm bg-dark text-success border-0" id="vuln-modal-edit-note-'+data[i].Nid+'-btn" onclick="showModalEdit(\'note\','+data[i].Nid+')" aria-label="Edit"> <span aria-hidden="true">&#9998;</span></button> <button type="button" class="btn-sm bg-dark text-danger border-0" id="vuln-modal-delete-note-'+data[i].Nid+'-btn" data-delete-btn-group="ref" onclick="showModalPrompt(\'note\','+data[i].Nid+')" aria-label="Delete"><span aria-hidden="true">&times;</span></button> <button type="button" class="btn-sm bg-dark text-info border-0" onclick="setNotePublic('+data[i].Nid+', '+!data[i].Public+')">'+(data[i].Public ? 'Make internal' : 'Make public')+'</button><p class="text-right">'+data[i].Added+'</p></div><div class="card-body"><h4 class="card-title">'+data[i].Emp+edited+pub+'</h4><form class="form-inline" id="vuln-modal-form-note-'+data[i].Nid+'"> <textarea class="form-control-plaintext edit-note-input text-white bg-dark" readonly id="vuln-modal-edit-note-'+data[i].Nid+'"value="'+data[i].Nid+'" name="note" rows="4" cols="65">'+$('<div>').text(data[i].Note).html()+'</textarea><button type="submit" class="btn btn-dark vme-btn-submit" id="vuln-modal-edit-note-'+data[i].Nid+'-submit">Submit</button></form><div id="vuln-note-revisions-'+data[i].Nid+'"></div>'+reply+'</div></div>'+thread);
                         $('#vuln-modal-form-note-'+data[i].Nid).on('submit', {noteid: data[i].Nid}, function(event) {
                             event.preventDefault();
                             var noteid = event.data.noteid;
@@ -912,8 +1297,54 @@ function appendNotes(vid) {
                         });
                         $('#vuln-modal-edit-note-'+data[i].Nid+'-submit').hide();
                     } else {
-                        $('#vuln-modal-notes-list').append('<div class="card text-white bg-dark mb-3" id="vuln-note-'+data[i].Nid+'"><div class="card-header"><p class="text-right">'+data[i].Added+'</p></div><div class="card-body"><h4 class="card-title">'+data[i].Emp+'</h4><p class="card-text">'+data[i].Note+'</p></div></div>');
+                        list.append('<div class="card text-white bg-dark mb-3" id="vuln-note-'+data[i].Nid+'"><div class="card-header"><p class="text-right">'+data[i].Added+'</p></div><div class="card-body"><h4 class="card-title">'+data[i].Emp+edited+pub+'</h4><div class="card-text">'+data[i].Html+'</div><div id="vuln-note-revisions-'+data[i].Nid+'"></div>'+reply+'</div></div>'+thread);
                     }
+                    $('#vuln-note-reply-form-'+data[i].Nid).on('submit', {noteid: data[i].Nid}, function(event) {
+                        event.preventDefault();
+                        var fdata = $('#vuln-note-reply-form-'+event.data.noteid).serialize();
+                        var vid = $('#vuln-modal-vulnid').text();
+                        $.ajax({
+                            method : 'PUT',
+                            url    : '/vulnerability/'+vid+'/note',
+                            data   : fdata,
+                            success: function(data) {
+                                $('#vuln-modal-alert-success').show();
+                                appendNotes(vid);
+                            },
+                            error: function() {
+                                $('#vuln-modal-alert-danger').show();
+                                $('#vuln-modal').scrollTop(0);
+                            }
+                        });
+                    });
+                }
+            }
+        },
+        error: function() {
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+        }
+    });
+}
+
+function showNoteReply(noteid) {
+    $('#vuln-note-reply-form-'+noteid).toggle();
+}
+
+function showNoteRevisions(noteid) {
+    var revs = $('#vuln-note-revisions-'+noteid);
+    if (!revs.is(':empty')) {
+        revs.empty();
+        return;
+    }
+    $.ajax({
+        method      : 'GET',
+        dataType    : 'json',
+        url         : '/vulnerability/'+$('#vuln-modal-vulnid').text()+'/note/'+noteid,
+        success: function(data) {
+            if (data != null) {
+                for (i=0; i < data.length; i++) {
+                    revs.append('<div class="border-top border-secondary mt-2"><small class="text-muted">Replaced '+data[i].Revised+'</small><pre class="text-white">'+$('<div>').text(data[i].Note).html()+'</pre></div>');
                 }
             }
         },
@@ -924,12 +1355,29 @@ function appendNotes(vid) {
     });
 }
 
+function setNotePublic(noteid, pub) {
+    $.ajax({
+        method : 'POST',
+        url    : '/notes/'+noteid,
+        data   : {public: pub},
+        success: function(data) {
+            appendNotes($('#vuln-modal-vulnid').text());
+        },
+        error: function() {
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+        }
+    });
+}
+
 function updateVulnModal(vuln, modal) {
     modal.find('#vuln-modal-notes').show();
     modal.find('#vuln-modal-affected').show();
     modal.find('#vuln-modal-section-refs').show();
     modal.find('#vuln-modal-section-tickets').show();
     modal.find('#vuln-modal-section-cve').show();
+    modal.find('#vuln-modal-section-related').show();
+    modal.find('#vuln-modal-section-tasks').show();
     modal.find('#vuln-modal-title').val(vuln.Name);
     modal.find('#vuln-modal-vulnid').text(vuln.ID);
     // Summary
@@ -942,6 +1390,21 @@ function updateVulnModal(vuln, modal) {
             appendCve(vuln.Cves[i], i);
         }
     }
+    // CWEs
+    if (vuln.Cwes != null && vuln.Cwes.length > 0) {
+        modal.find('#vuln-modal-cwes').text(vuln.Cwes.join(', '));
+        modal.find('#vuln-modal-div-cwes').show();
+    } else {
+        modal.find('#vuln-modal-cwes').text('');
+        modal.find('#vuln-modal-div-cwes').hide();
+    }
+    // Related
+    modal.find('#vuln-modal-related-list').empty();
+    if (vuln.Related != null) {
+        for (i = 0; i < vuln.Related.length; i++) {
+            appendRelated(vuln.Related[i]);
+        }
+    }
     // Cvss
     modal.find('#vuln-modal-cvss').text(vuln.Cvss);
     modal.find('#vuln-modal-cvss-edit').attr('value', vuln.Cvss);
@@ -951,12 +1414,31 @@ function updateVulnModal(vuln, modal) {
         modal.find('#vuln-modal-cvss').attr('href', vuln.CvssLink);
         modal.find('#vuln-modal-cvss-link-edit').attr('value', vuln.CvssLink);
     }
+    // EPSS
+    if (vuln.Epss == null) {
+        modal.find('#vuln-modal-epss').text('');
+    } else {
+        modal.find('#vuln-modal-epss').text('EPSS ' + formatEpss(vuln.Epss.Score) + ' (percentile ' + formatEpss(vuln.Epss.Percentile) + ', ' + vuln.Epss.Cve + ', ' + vuln.Epss.ModelDate.substring(0, 10) + ')');
+    }
     // CorpScore
     modal.find('#vuln-modal-corpscore').val(vuln.CorpScore);
     // Test
     modal.find('#vuln-modal-test').val(vuln.Test);
     // Mitigation
     modal.find('#vuln-modal-mitigation').val(vuln.Mitigation);
+    // Tasks
+    taskSystems = {};
+    $('#vuln-modal-task-system').empty();
+    $('#vuln-modal-task-system').append('<option value="" selected>All systems</option>');
+    if (vuln.AffSystems != null) {
+        for (i = 0; i < vuln.AffSystems.length; i++) {
+            taskSystems[vuln.AffSystems[i].Sys.ID] = vuln.AffSystems[i].Sys.Name;
+            $('#vuln-modal-task-system').append($('<option>').val(vuln.AffSystems[i].Sys.ID).text(vuln.AffSystems[i].Sys.Name));
+        }
+    }
+    loadTaskAssignees(function() {
+        showTasks(vuln.Tasks, vuln.Progress);
+    });
     // Finder
     if ($('#vuln-modal-finder').is('[data-editable]')) {
         appendFinderList(vuln);
@@ -999,6 +1481,22 @@ function updateVulnModal(vuln, modal) {
         modal.find('#vuln-modal-exploitable option[value="false"]').removeAttr('selected');
         modal.find('#vuln-modal-exploitable option[value="true"]').attr('selected', true);
     }
+    // KEV
+    modal.find('#vuln-modal-kev-list').empty();
+    if (vuln.Kev != null && vuln.Kev.length > 0) {
+        for (i = 0; i < vuln.Kev.length; i++) {
+            var k = vuln.Kev[i];
+            var item = k.Cve + ': added ' + k.DateAdded.substring(0, 10);
+            if (k.DueDate != null) {
+                item += ', due ' + k.DueDate.substring(0, 10);
+            }
+            item += ', ransomware use ' + k.Ransomware;
+            modal.find('#vuln-modal-kev-list').append($('<li>').text(item));
+        }
+        modal.find('#vuln-modal-div-kev').show();
+    } else {
+        modal.find('#vuln-modal-div-kev').hide();
+    }
     // Exploit
     if (vuln.Exploit == null) {
         modal.find('#vuln-modal-exploit').val('');
@@ -1010,11 +1508,22 @@ function updateVulnModal(vuln, modal) {
     modal.find('#vme-add-affected-list').empty();
     for (i = 0; i < vuln.AffSystems.length; i++) {
         modal.find('#vuln-modal-affected-table').append('<tr class="vuln-aff-open" id="vme-affected-'+vuln.AffSystems[i].Sys.ID+'"><td><button type="button" class="btn-sm bg-white text-danger border-0" onclick="handleAffectedAction('+vuln.AffSystems[i].Sys.ID+', \''+vuln.AffSystems[i].Sys.Name+'\', \'delete\')" aria-label="Delete"> <span aria-hidden="true">&times;</span> </button></td><td>' + vuln.AffSystems[i].Sys.Name + '</td><td>' + vuln.AffSystems[i].Sys.Description + '</td><td>'+ vuln.AffSystems[i].Sys.Location + '</td><td>'+ vuln.AffSystems[i].Sys.State + '</td><td><label class="custom-control custom-checkbox"><input type="checkbox" class="custom-control-input"><span class="custom-control-indicator"></span></label></td></tr>');
+        if (vuln.AffSystems[i].Packages != null && vuln.AffSystems[i].Packages.length > 0) {
+            var pkgs = [];
+            for (j = 0; j < vuln.AffSystems[i].Packages.length; j++) {
+                var p = vuln.AffSystems[i].Packages[j];
+                pkgs.push(p.Package+' '+p.Installed+(p.Fixed == '' ? '' : ' (fixed in '+p.Fixed+')'));
+            }
+            $('#vme-affected-'+vuln.AffSystems[i].Sys.ID).attr('title', pkgs.join(', '));
+        }
         if (vuln.AffSystems[i].Mitigated) {
             $('#vme-affected-'+vuln.AffSystems[i].Sys.ID+' input').attr('checked', true);
             $('#vme-affected-'+vuln.AffSystems[i].Sys.ID+' input').prop('checked', true);
             $('#vme-affected-'+vuln.AffSystems[i].Sys.ID).removeClass('vuln-aff-open');
             $('#vme-affected-'+vuln.AffSystems[i].Sys.ID).addClass('vuln-aff-closed');
+            if (vuln.AffSystems[i].Evidence != null) {
+                $('#vme-affected-'+vuln.AffSystems[i].Sys.ID).attr('title', 'Mitigated according to the '+vuln.AffSystems[i].Evidence.Scanner+' scan '+vuln.AffSystems[i].Evidence.Scan+' imported '+vuln.AffSystems[i].Evidence.Mitigated);
+            }
         }
         $('#vme-affected-'+vuln.AffSystems[i].Sys.ID+' input').data('sid', vuln.AffSystems[i].Sys.ID);
         $('#vme-affected-'+vuln.AffSystems[i].Sys.ID+' input').change(function() {
@@ -1067,26 +1576,89 @@ $('#vuln-modal').on('hidden.bs.modal', function (event) {
     $('#vuln-modal-div-cvss').show();
 });
 
+function formatEpss(epss) {
+    if (epss == null) {
+        return '';
+    }
+    return epss.toFixed(5);
+}
+
+function calculateCorpScore() {
+    var vid = $('#vuln-modal-vulnid').text();
+    if (parseInt(vid) < 0) {
+        return;
+    }
+    $.ajax({
+        method  : 'GET',
+        url     : '/vulnerability/'+vid+'/corpscore',
+        dataType: 'json',
+        success : function(data) {
+            $('#vuln-modal-corpscore').val(data.CorpScore);
+        },
+        error: function() {
+            $('#vuln-modal-alert-danger').show();
+            $('#vuln-modal').scrollTop(0);
+        }
+    });
+}
+
+function exportVuln(format) {
+    var vid = $('#vuln-modal-vulnid').text();
+    if (format == 'stix') {
+        window.location = '/vulnerability/all?'+$.param({format: format, id: vid});
+        return;
+    }
+    window.location = '/vulnerability/'+vid+'/'+format;
+}
+
+function openDossier(format) {
+    var vid = $('#vuln-modal-vulnid').text();
+    if (format == 'pdf') {
+        window.location = '/vulnerability/'+vid+'/dossier?format=pdf';
+        return;
+    }
+    window.open('/vulnerability/'+vid+'/dossier', '_blank');
+}
+
+function exportVulnTable(format) {
+    var state = window.location.hash.replace('#', '').trim();
+    if (state != 'all' && state != 'closed') {
+        state = 'open';
+    }
+    var params = {format: format};
+    var minEpss = $('#vuln-table-minepss').val();
+    if (minEpss != null && minEpss != '') {
+        params.minepss = minEpss;
+    }
+    window.location = '/vulnerability/'+state+'?'+$.param(params);
+}
+
 function loadVulnTable(state) {
     $('#vuln-table tbody').empty();
-    $('#vuln-table tr th:nth-child(7), table tr td:nth-child(7)').show();
+    $('#vuln-table tr th:nth-child(8), table tr td:nth-child(8)').show();
+    var params = {};
+    var minEpss = $('#vuln-table-minepss').val();
+    if (minEpss != null && minEpss != '') {
+        params.minepss = minEpss;
+    }
     $.ajax({
         method  : 'GET',
         dataType: 'json',
         url     : '/vulnerability/'+state,
+        data    : params,
         success : function(data) {
             if (data != null) {
                 for (i=0; i < data.length; i++) {
-                    $('#vuln-table tbody').append('<tr data-toggle="modal" data-target="#vuln-modal" data-vid="'+data[i].ID+'"><td>'+data[i].Name+'</td><td>'+data[i].Summary+'</td><td>'+data[i].Cvss+'</td><td>'+data[i].CorpScore+'</td><td>'+data[i].Cve+'</td><td>'+data[i].Initiated+'</td><td>'+data[i].Mitigated+'</td></tr>');
+                    $('#vuln-table tbody').append('<tr data-toggle="modal" data-target="#vuln-modal" data-vid="'+data[i].ID+'"><td>'+data[i].Name+'</td><td>'+data[i].Summary+'</td><td>'+data[i].Cvss+'</td><td>'+data[i].CorpScore+'</td><td>'+formatEpss(data[i].Epss)+'</td><td>'+data[i].Cve+'</td><td>'+data[i].Initiated+'</td><td>'+data[i].Mitigated+'</td></tr>');
                 }
             }
             switch (state) {
                 case 'open':
-                    $('#vuln-table tr th:nth-child(7), table tr td:nth-child(7)').hide();
+                    $('#vuln-table tr th:nth-child(8), table tr td:nth-child(8)').hide();
                     break;
                 case 'closed':
                 case 'all':
-                    $('#vuln-table tr th:nth-child(7), table tr td:nth-child(7)').show();
+                    $('#vuln-table tr th:nth-child(8), table tr td:nth-child(8)').show();
                     break;
             }
         },
@@ -1198,9 +1770,11 @@ $(document).ready(function() {
 			method : 'PUT',
 			url    : '/vulnerability/'+vid+'/cve',
 			data   : fdata,
+			dataType: 'json',
 			success: function(data) {
 				$('#vuln-modal-div-add-cve').hide();
 				$('#vuln-modal-alert-success').show();
+				showCveDuplicates(data);
                 $('#vuln-modal').scrollTop(0);
                 var cveID = $('#vuln-modal-cve-list').children().length - 1;
                 appendCve(cve, cveID);
@@ -1213,6 +1787,47 @@ $(document).ready(function() {
             }
 		});
 	});
+	$('#vuln-modal-form-add-task').on('submit', function(event) {
+		event.preventDefault();
+		var fdata = $('#vuln-modal-form-add-task').serialize();
+		var vid = $('#vuln-modal-vulnid').text();
+		$.ajax({
+			method : 'PUT',
+			url    : '/vulnerability/'+vid+'/task',
+			data   : fdata,
+			success: function(data) {
+				$('#vuln-modal-form-add-task')[0].reset();
+				$('#vuln-modal-div-add-task').hide();
+                hideModalEdit();
+                reloadTasks(vid);
+			},
+            error: function() {
+                $('#vuln-modal-alert-danger').show();
+                $('#vuln-modal').scrollTop(0);
+            }
+		});
+	});
+	$('#vuln-modal-form-add-related').on('submit', function(event) {
+		event.preventDefault();
+		var fdata = $('#vuln-modal-form-add-related').serialize();
+		var vid = $('#vuln-modal-vulnid').text();
+		$.ajax({
+			method : 'PUT',
+			url    : '/vulnerability/'+vid+'/related',
+			data   : fdata,
+			success: function(data) {
+				$('#vuln-modal-div-add-related').hide();
+				$('#vuln-modal-alert-success').show();
+                $('#vuln-modal').scrollTop(0);
+                hideModalEdit();
+                reloadRelated(vid);
+			},
+            error: function() {
+                $('#vuln-modal-alert-danger').show();
+                $('#vuln-modal').scrollTop(0);
+            }
+		});
+	});
 	$('#vuln-modal-form-test').on('submit', function(event) {
 		event.preventDefault();
 		var fdata = $('#vuln-modal-form-test').serialize();
@@ -1424,7 +2039,8 @@ $(document).ready(function() {
         var dataMit   = $('#vuln-modal-form-mitigation').serialize();
         var dataExpb  = $('#vuln-modal-form-exploitable').serialize();
         var dataExp   = $('#vuln-modal-form-exploit').serialize();
-        var fdata     = dataTitle+'&'+dataSum+'&'+dataCvss+'&'+dataCorp+'&'+dataTest+'&'+dataFind+'&'+dataMit+'&'+dataExpb+'&'+dataExp;
+        var dataTmpl  = $('#vuln-modal-form-template').serialize();
+        var fdata     = dataTitle+'&'+dataSum+'&'+dataCvss+'&'+dataCorp+'&'+dataTest+'&'+dataFind+'&'+dataMit+'&'+dataExpb+'&'+dataExp+'&'+dataTmpl;
         var name      = $('#vuln-modal-title').val();
         var summ      = $('#vuln-modal-summary').val();
         var cvss      = $('#vuln-modal-cvss-edit').val();
@@ -1436,11 +2052,11 @@ $(document).ready(function() {
             dataType: 'json',
 			data    : fdata,
 			success : function(data) {
-                $('#vuln-table tbody').prepend('<tr data-toggle="modal" data-target="#vuln-modal" data-vid="'+data.ID+'"><td>'+name+'</td><td>'+summ+'</td><td>'+cvss+'</td><td>'+corp+'</td><td></td><td>'+init.toUTCString()+'</td><td></td></tr>');
+                $('#vuln-table tbody').prepend('<tr data-toggle="modal" data-target="#vuln-modal" data-vid="'+data.ID+'"><td>'+name+'</td><td>'+summ+'</td><td>'+cvss+'</td><td>'+corp+'</td><td></td><td></td><td>'+init.toUTCString()+'</td><td></td></tr>');
                 $('#vuln-modal').modal('hide');
                 var state = window.location.hash.replace('#', '').trim();
                 if (state != 'all' && state != 'closed') {
-                    $('#vuln-table tr th:nth-child(7), table tr td:nth-child(7)').hide();
+                    $('#vuln-table tr th:nth-child(8), table tr td:nth-child(8)').hide();
                 }
 			},
             error: function(j, s, err) {
@@ -1452,9 +2068,19 @@ $(document).ready(function() {
             }
 		});
 	});
+    $('#vuln-modal-template').on('change', function() {
+        applyTemplate($(this).val());
+    });
     $('#vuln-table-search').keyup(function() {
         handleFuzzySearch();
     });
+    $('#vuln-table-minepss').on('change', function() {
+        var state = window.location.hash.replace('#', '').trim();
+        if (state != 'all' && state != 'closed') {
+            state = 'open';
+        }
+        loadVulnTable(state);
+    });
     // Load vuln table
     var state = window.location.hash.replace('#', '').trim();
     switch(state) {
@@ -33,6 +33,9 @@ const (
 	noRowsInserted errType = iota
 	noRowsUpdated
 	NameNotAvailable
+	AttachTooLarge
+	AttachTypeNotAllowed
+	InvalidValue
 	unknownType
 	genericVars
 )
@@ -44,6 +47,12 @@ var (
 	ErrNoRowsUpdated = errors.New("No rows were updated")
 	//ErrNameNotAvailable is used when the provided vulnnerability name is not available
 	ErrNameNotAvailable = errors.New("The provided vulnerability name is not available")
+	//ErrAttachTooLarge is used when the attachment is larger than the configured maximum size
+	ErrAttachTooLarge = errors.New("The attachment exceeds the maximum allowed size")
+	//ErrAttachTypeNotAllowed is used when the type of the attachment is not in the configured list of types
+	ErrAttachTypeNotAllowed = errors.New("The attachment type is not allowed")
+	//ErrInvalidValue is used when a value provided by the user is not valid
+	ErrInvalidValue = errors.New("The provided value is not valid")
 	//ErrUknownType is used for the default case of the type switch
 	ErrUnknownType = errors.New("The interface type is not supported")
 	//ErrGenericVars is used when the error is too generic
@@ -71,6 +80,12 @@ func newErr(errT errType, parents ...string) Err {
 		err.err = ErrNoRowsUpdated
 	case NameNotAvailable:
 		err.err = ErrNameNotAvailable
+	case AttachTooLarge:
+		err.err = ErrAttachTooLarge
+	case AttachTypeNotAllowed:
+		err.err = ErrAttachTypeNotAllowed
+	case InvalidValue:
+		err.err = ErrInvalidValue
 	case unknownType:
 		err.err = ErrUnknownType
 	default:
@@ -115,6 +130,30 @@ func IsNameNotAvailableError(err error) bool {
 	return false
 }
 
+// IsAttachTooLargeError returns true if the error is caused by an attachment exceeding the maximum size
+func IsAttachTooLargeError(err error) bool {
+	if varsErr, ok := err.(Err); ok {
+		return varsErr.err == ErrAttachTooLarge
+	}
+	return false
+}
+
+// IsAttachTypeNotAllowedError returns true if the error is caused by an attachment type that is not allowed
+func IsAttachTypeNotAllowedError(err error) bool {
+	if varsErr, ok := err.(Err); ok {
+		return varsErr.err == ErrAttachTypeNotAllowed
+	}
+	return false
+}
+
+// IsInvalidValueError returns true if the error is caused by a value provided by the user that is not valid
+func IsInvalidValueError(err error) bool {
+	if varsErr, ok := err.(Err); ok {
+		return varsErr.err == ErrInvalidValue
+	}
+	return false
+}
+
 // IsNoRowsError returns true if the error is caused by no rows being effected
 func (e Err) IsNoRowsError() bool {
 	if e.err.Error() == ErrNoRowsInserted.Error() || e.err.Error() == ErrNoRowsUpdated.Error() || e.err.Error() == sql.ErrNoRows.Error() {
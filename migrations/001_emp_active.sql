@@ -0,0 +1,14 @@
+--
+-- Adds the active flag and deactivation date to the emp table. Employees that were
+-- removed by renaming them to VARSremoved are marked as inactive. Their original
+-- usernames were not kept, so they have to be restored by hand before reactivating.
+--
+
+BEGIN;
+
+ALTER TABLE emp ADD COLUMN active boolean DEFAULT true NOT NULL;
+ALTER TABLE emp ADD COLUMN deactivated timestamp without time zone;
+
+UPDATE emp SET active = false, deactivated = now() WHERE username = 'VARSremoved';
+
+COMMIT;
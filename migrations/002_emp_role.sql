@@ -0,0 +1,29 @@
+--
+-- Replaces the numeric emp.level with a named role and adds the rolepermissions table
+-- used to define roles in the database. The four levels map to the default roles:
+--   0 -> admin, 1 -> privileged, 2 -> standard, 3 -> reporter
+-- The permissions of the default roles are built into VARS, so the rolepermissions
+-- table only needs rows for custom roles or to override a default role.
+--
+
+BEGIN;
+
+ALTER TABLE emp ADD COLUMN role text;
+UPDATE emp SET role = CASE level
+    WHEN 0 THEN 'admin'
+    WHEN 1 THEN 'privileged'
+    WHEN 2 THEN 'standard'
+    ELSE 'reporter'
+END;
+ALTER TABLE emp ALTER COLUMN role SET NOT NULL;
+ALTER TABLE emp DROP COLUMN level;
+
+CREATE TABLE rolepermissions (
+    role text NOT NULL,
+    permission text NOT NULL
+);
+ALTER TABLE rolepermissions OWNER TO vars;
+ALTER TABLE ONLY rolepermissions
+    ADD CONSTRAINT rolepermissions_pkey PRIMARY KEY (role, permission);
+
+COMMIT;
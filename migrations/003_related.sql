@@ -0,0 +1,22 @@
+--
+-- Adds the related table used to link vulnerabilities to each other.
+--
+
+BEGIN;
+
+CREATE TABLE related (
+    vulnid integer NOT NULL,
+    relvulnid integer NOT NULL,
+    reltype text NOT NULL,
+    CONSTRAINT related_reltype_check CHECK ((reltype = ANY (ARRAY['duplicate-of'::text, 'supersedes'::text, 'related'::text]))),
+    CONSTRAINT related_self_check CHECK ((vulnid <> relvulnid))
+);
+ALTER TABLE related OWNER TO vars;
+ALTER TABLE ONLY related
+    ADD CONSTRAINT related_pkey PRIMARY KEY (vulnid, relvulnid);
+ALTER TABLE ONLY related
+    ADD CONSTRAINT related_relvulnid_fkey FOREIGN KEY (relvulnid) REFERENCES vuln(vulnid);
+ALTER TABLE ONLY related
+    ADD CONSTRAINT related_vulnid_fkey FOREIGN KEY (vulnid) REFERENCES vuln(vulnid);
+
+COMMIT;
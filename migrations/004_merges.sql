@@ -0,0 +1,19 @@
+--
+-- Adds the merges table used to redirect the IDs of vulnerabilities that were merged into another.
+--
+
+BEGIN;
+
+CREATE TABLE merges (
+    dropid integer NOT NULL,
+    dropname text NOT NULL,
+    keepid integer NOT NULL,
+    merged timestamp without time zone NOT NULL
+);
+ALTER TABLE merges OWNER TO vars;
+ALTER TABLE ONLY merges
+    ADD CONSTRAINT merges_pkey PRIMARY KEY (dropid);
+ALTER TABLE ONLY merges
+    ADD CONSTRAINT merges_keepid_fkey FOREIGN KEY (keepid) REFERENCES vuln(vulnid);
+
+COMMIT;
@@ -0,0 +1,40 @@
+--
+-- Adds the templates and templaterefs tables used to prefill new vulnerability assessments.
+--
+
+BEGIN;
+
+CREATE TABLE templates (
+    templateid integer NOT NULL,
+    name text NOT NULL,
+    summary text NOT NULL,
+    test text NOT NULL,
+    mitigation text NOT NULL,
+    cvss numeric NOT NULL,
+    cvsslink text,
+    corpscore numeric NOT NULL
+);
+ALTER TABLE templates OWNER TO vars;
+CREATE SEQUENCE templates_templateid_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+ALTER TABLE templates_templateid_seq OWNER TO vars;
+ALTER SEQUENCE templates_templateid_seq OWNED BY templates.templateid;
+ALTER TABLE ONLY templates ALTER COLUMN templateid SET DEFAULT nextval('templates_templateid_seq'::regclass);
+ALTER TABLE ONLY templates
+    ADD CONSTRAINT templates_pkey PRIMARY KEY (templateid);
+
+CREATE TABLE templaterefs (
+    templateid integer NOT NULL,
+    url text NOT NULL
+);
+ALTER TABLE templaterefs OWNER TO vars;
+ALTER TABLE ONLY templaterefs
+    ADD CONSTRAINT templaterefs_pkey PRIMARY KEY (templateid, url);
+ALTER TABLE ONLY templaterefs
+    ADD CONSTRAINT templaterefs_templateid_fkey FOREIGN KEY (templateid) REFERENCES templates(templateid);
+
+COMMIT;
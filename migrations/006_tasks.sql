@@ -0,0 +1,35 @@
+--
+-- Adds the tasks table used for the remediation checklists of vulnerabilities.
+--
+
+BEGIN;
+
+CREATE TABLE tasks (
+    taskid integer NOT NULL,
+    vulnid integer NOT NULL,
+    title text NOT NULL,
+    assignee integer,
+    due timestamp without time zone,
+    done boolean DEFAULT false NOT NULL,
+    sysid integer
+);
+ALTER TABLE tasks OWNER TO vars;
+CREATE SEQUENCE tasks_taskid_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+ALTER TABLE tasks_taskid_seq OWNER TO vars;
+ALTER SEQUENCE tasks_taskid_seq OWNED BY tasks.taskid;
+ALTER TABLE ONLY tasks ALTER COLUMN taskid SET DEFAULT nextval('tasks_taskid_seq'::regclass);
+ALTER TABLE ONLY tasks
+    ADD CONSTRAINT tasks_pkey PRIMARY KEY (taskid);
+ALTER TABLE ONLY tasks
+    ADD CONSTRAINT tasks_assignee_fkey FOREIGN KEY (assignee) REFERENCES emp(empid);
+ALTER TABLE ONLY tasks
+    ADD CONSTRAINT tasks_sysid_fkey FOREIGN KEY (sysid) REFERENCES systems(sysid);
+ALTER TABLE ONLY tasks
+    ADD CONSTRAINT tasks_vulnid_fkey FOREIGN KEY (vulnid) REFERENCES vuln(vulnid);
+
+COMMIT;
@@ -0,0 +1,49 @@
+--
+-- Adds the cve_catalog tables filled from the NVD feeds and the cwes table of vulnerabilities.
+--
+
+BEGIN;
+
+CREATE TABLE cve_catalog (
+    cve text NOT NULL,
+    summary text NOT NULL,
+    published timestamp without time zone,
+    lastmodified timestamp without time zone NOT NULL,
+    cvssvector text,
+    cvssscore numeric NOT NULL
+);
+ALTER TABLE cve_catalog OWNER TO vars;
+ALTER TABLE ONLY cve_catalog
+    ADD CONSTRAINT cve_catalog_pkey PRIMARY KEY (cve);
+
+CREATE TABLE cve_catalog_cwes (
+    cve text NOT NULL,
+    cwe text NOT NULL
+);
+ALTER TABLE cve_catalog_cwes OWNER TO vars;
+ALTER TABLE ONLY cve_catalog_cwes
+    ADD CONSTRAINT cve_catalog_cwes_pkey PRIMARY KEY (cve, cwe);
+ALTER TABLE ONLY cve_catalog_cwes
+    ADD CONSTRAINT cve_catalog_cwes_cve_fkey FOREIGN KEY (cve) REFERENCES cve_catalog(cve);
+
+CREATE TABLE cve_catalog_refs (
+    cve text NOT NULL,
+    url text NOT NULL
+);
+ALTER TABLE cve_catalog_refs OWNER TO vars;
+ALTER TABLE ONLY cve_catalog_refs
+    ADD CONSTRAINT cve_catalog_refs_pkey PRIMARY KEY (cve, url);
+ALTER TABLE ONLY cve_catalog_refs
+    ADD CONSTRAINT cve_catalog_refs_cve_fkey FOREIGN KEY (cve) REFERENCES cve_catalog(cve);
+
+CREATE TABLE cwes (
+    vulnid integer NOT NULL,
+    cwe text NOT NULL
+);
+ALTER TABLE cwes OWNER TO vars;
+ALTER TABLE ONLY cwes
+    ADD CONSTRAINT cwes_pkey PRIMARY KEY (vulnid, cwe);
+ALTER TABLE ONLY cwes
+    ADD CONSTRAINT cwes_vulnid_fkey FOREIGN KEY (vulnid) REFERENCES vuln(vulnid);
+
+COMMIT;
@@ -0,0 +1,35 @@
+--
+-- Adds the kev table filled from the CISA Known Exploited Vulnerabilities catalog and the kevmatches table
+-- recording when a vulnerability was first matched to it.
+--
+
+BEGIN;
+
+CREATE TABLE kev (
+    cve text NOT NULL,
+    vendor text NOT NULL,
+    product text NOT NULL,
+    name text NOT NULL,
+    action text NOT NULL,
+    dateadded timestamp without time zone NOT NULL,
+    duedate timestamp without time zone,
+    ransomware text NOT NULL
+);
+ALTER TABLE kev OWNER TO vars;
+ALTER TABLE ONLY kev
+    ADD CONSTRAINT kev_pkey PRIMARY KEY (cve);
+
+CREATE TABLE kevmatches (
+    vulnid integer NOT NULL,
+    cve text NOT NULL,
+    matched timestamp without time zone NOT NULL
+);
+ALTER TABLE kevmatches OWNER TO vars;
+ALTER TABLE ONLY kevmatches
+    ADD CONSTRAINT kevmatches_pkey PRIMARY KEY (vulnid, cve);
+ALTER TABLE ONLY kevmatches
+    ADD CONSTRAINT kevmatches_cve_fkey FOREIGN KEY (cve) REFERENCES kev(cve);
+ALTER TABLE ONLY kevmatches
+    ADD CONSTRAINT kevmatches_vulnid_fkey FOREIGN KEY (vulnid) REFERENCES vuln(vulnid);
+
+COMMIT;
@@ -0,0 +1,18 @@
+--
+-- Adds the epss table filled from the FIRST EPSS daily scores.
+--
+
+BEGIN;
+
+CREATE TABLE epss (
+    cve text NOT NULL,
+    score numeric NOT NULL,
+    percentile numeric NOT NULL,
+    modeldate timestamp without time zone NOT NULL,
+    modelversion text
+);
+ALTER TABLE epss OWNER TO vars;
+ALTER TABLE ONLY epss
+    ADD CONSTRAINT epss_pkey PRIMARY KEY (cve);
+
+COMMIT;
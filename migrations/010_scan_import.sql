@@ -0,0 +1,29 @@
+--
+-- Adds the scanrefs table mapping scanner plugin IDs to vulnerabilities and the sysaddrs table holding the
+-- addresses scanners use for systems.
+--
+
+BEGIN;
+
+CREATE TABLE scanrefs (
+    scanner text NOT NULL,
+    pluginid text NOT NULL,
+    vulnid integer NOT NULL
+);
+ALTER TABLE scanrefs OWNER TO vars;
+ALTER TABLE ONLY scanrefs
+    ADD CONSTRAINT scanrefs_pkey PRIMARY KEY (scanner, pluginid);
+ALTER TABLE ONLY scanrefs
+    ADD CONSTRAINT scanrefs_vulnid_fkey FOREIGN KEY (vulnid) REFERENCES vuln(vulnid);
+
+CREATE TABLE sysaddrs (
+    sysid integer NOT NULL,
+    addr text NOT NULL
+);
+ALTER TABLE sysaddrs OWNER TO vars;
+ALTER TABLE ONLY sysaddrs
+    ADD CONSTRAINT sysaddrs_pkey PRIMARY KEY (addr);
+ALTER TABLE ONLY sysaddrs
+    ADD CONSTRAINT sysaddrs_sysid_fkey FOREIGN KEY (sysid) REFERENCES systems(sysid);
+
+COMMIT;
@@ -0,0 +1,19 @@
+--
+-- Adds the sysports table holding the open ports of systems found by the Nmap import.
+--
+
+BEGIN;
+
+CREATE TABLE sysports (
+    sysid integer NOT NULL,
+    port integer NOT NULL,
+    protocol text NOT NULL,
+    service text NOT NULL
+);
+ALTER TABLE sysports OWNER TO vars;
+ALTER TABLE ONLY sysports
+    ADD CONSTRAINT sysports_pkey PRIMARY KEY (sysid, port, protocol);
+ALTER TABLE ONLY sysports
+    ADD CONSTRAINT sysports_sysid_fkey FOREIGN KEY (sysid) REFERENCES systems(sysid);
+
+COMMIT;
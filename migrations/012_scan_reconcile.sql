@@ -0,0 +1,20 @@
+--
+-- Adds the scanmitigations table recording the affected systems that a scan import marked mitigated.
+--
+
+BEGIN;
+
+CREATE TABLE scanmitigations (
+    vulnid integer NOT NULL,
+    sysid integer NOT NULL,
+    scanner text NOT NULL,
+    scan text NOT NULL,
+    mitigated timestamp without time zone NOT NULL
+);
+ALTER TABLE scanmitigations OWNER TO vars;
+ALTER TABLE ONLY scanmitigations
+    ADD CONSTRAINT scanmitigations_pkey PRIMARY KEY (vulnid, sysid);
+ALTER TABLE ONLY scanmitigations
+    ADD CONSTRAINT scanmitigations_vulnid_fkey FOREIGN KEY (vulnid, sysid) REFERENCES affected(vulnid, sysid);
+
+COMMIT;
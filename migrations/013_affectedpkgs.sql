@@ -0,0 +1,20 @@
+--
+-- Adds the affectedpkgs table holding the vulnerable packages of affected systems, such as container images.
+--
+
+BEGIN;
+
+CREATE TABLE affectedpkgs (
+    vulnid integer NOT NULL,
+    sysid integer NOT NULL,
+    package text NOT NULL,
+    installed text NOT NULL,
+    fixed text NOT NULL
+);
+ALTER TABLE affectedpkgs OWNER TO vars;
+ALTER TABLE ONLY affectedpkgs
+    ADD CONSTRAINT affectedpkgs_pkey PRIMARY KEY (vulnid, sysid, package, installed);
+ALTER TABLE ONLY affectedpkgs
+    ADD CONSTRAINT affectedpkgs_vulnid_fkey FOREIGN KEY (vulnid, sysid) REFERENCES affected(vulnid, sysid);
+
+COMMIT;
@@ -0,0 +1,19 @@
+--
+-- Adds the syscomponents table holding the software components of systems imported from SBOMs.
+--
+
+BEGIN;
+
+CREATE TABLE syscomponents (
+    sysid integer NOT NULL,
+    name text NOT NULL,
+    version text NOT NULL,
+    purl text NOT NULL
+);
+ALTER TABLE syscomponents OWNER TO vars;
+ALTER TABLE ONLY syscomponents
+    ADD CONSTRAINT syscomponents_pkey PRIMARY KEY (sysid, name, version);
+ALTER TABLE ONLY syscomponents
+    ADD CONSTRAINT syscomponents_sysid_fkey FOREIGN KEY (sysid) REFERENCES systems(sysid);
+
+COMMIT;
@@ -0,0 +1,10 @@
+--
+-- Adds the public flag to the notes table. Public notes are included in the advisories exported for
+-- publication, such as CSAF documents.
+--
+
+BEGIN;
+
+ALTER TABLE notes ADD COLUMN public boolean DEFAULT false NOT NULL;
+
+COMMIT;
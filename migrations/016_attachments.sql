@@ -0,0 +1,39 @@
+--
+-- Adds the attachments table holding the metadata of the files attached to vulnerabilities and
+-- their affected systems. The contents are kept in the configured blob store under storekey.
+--
+
+BEGIN;
+
+CREATE TABLE attachments (
+    attachid integer NOT NULL,
+    vulnid integer NOT NULL,
+    sysid integer,
+    empid integer NOT NULL,
+    filename text NOT NULL,
+    mimetype text NOT NULL,
+    size bigint NOT NULL,
+    sha256 character(64) NOT NULL,
+    storekey text NOT NULL,
+    added timestamp without time zone NOT NULL
+);
+ALTER TABLE attachments OWNER TO vars;
+CREATE SEQUENCE attachments_attachid_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+ALTER TABLE attachments_attachid_seq OWNER TO vars;
+ALTER SEQUENCE attachments_attachid_seq OWNED BY attachments.attachid;
+ALTER TABLE ONLY attachments ALTER COLUMN attachid SET DEFAULT nextval('attachments_attachid_seq'::regclass);
+ALTER TABLE ONLY attachments
+    ADD CONSTRAINT attachments_pkey PRIMARY KEY (attachid);
+ALTER TABLE ONLY attachments
+    ADD CONSTRAINT attachments_empid_fkey FOREIGN KEY (empid) REFERENCES emp(empid);
+ALTER TABLE ONLY attachments
+    ADD CONSTRAINT attachments_sysid_fkey FOREIGN KEY (sysid) REFERENCES systems(sysid);
+ALTER TABLE ONLY attachments
+    ADD CONSTRAINT attachments_vulnid_fkey FOREIGN KEY (vulnid) REFERENCES vuln(vulnid);
+
+COMMIT;
@@ -0,0 +1,36 @@
+--
+-- Adds replies, soft deletion, mentions and edit history to notes. A reply refers to the note it
+-- answers with parent, deleted notes keep their row with the date they were deleted, notementions
+-- holds the employees mentioned (@username) in a note and noterevisions holds the previous
+-- versions of edited notes.
+--
+
+BEGIN;
+
+ALTER TABLE notes ADD COLUMN parent integer;
+ALTER TABLE notes ADD COLUMN deleted timestamp without time zone;
+ALTER TABLE ONLY notes
+    ADD CONSTRAINT notes_parent_fkey FOREIGN KEY (parent) REFERENCES notes(noteid);
+
+CREATE TABLE notementions (
+    noteid integer NOT NULL,
+    empid integer NOT NULL
+);
+ALTER TABLE notementions OWNER TO vars;
+ALTER TABLE ONLY notementions
+    ADD CONSTRAINT notementions_pkey PRIMARY KEY (noteid, empid);
+ALTER TABLE ONLY notementions
+    ADD CONSTRAINT notementions_empid_fkey FOREIGN KEY (empid) REFERENCES emp(empid);
+ALTER TABLE ONLY notementions
+    ADD CONSTRAINT notementions_noteid_fkey FOREIGN KEY (noteid) REFERENCES notes(noteid);
+
+CREATE TABLE noterevisions (
+    noteid integer NOT NULL,
+    revised timestamp without time zone NOT NULL,
+    note text NOT NULL
+);
+ALTER TABLE noterevisions OWNER TO vars;
+ALTER TABLE ONLY noterevisions
+    ADD CONSTRAINT noterevisions_noteid_fkey FOREIGN KEY (noteid) REFERENCES notes(noteid);
+
+COMMIT;
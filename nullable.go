@@ -121,3 +121,33 @@ func (v *VarsNullBool) UnmarshalJSON(data []byte) error {
 	}
 	return nil
 }
+
+// VarsNullInt64 holds a sql.NullInt64. Needed for marshaling/unmarshaling.
+type VarsNullInt64 struct {
+	sql.NullInt64
+}
+
+// MarshalJSON will marshal the integer if it is valid.
+func (v VarsNullInt64) MarshalJSON() ([]byte, error) {
+	if v.Valid {
+		return json.Marshal(v.Int64)
+	} else {
+		return json.Marshal(nil)
+	}
+}
+
+// UnmarshalJSON will unmarshal the integer if it is valid and set valid to true, otherwise valid is set to false.
+func (v *VarsNullInt64) UnmarshalJSON(data []byte) error {
+	// Unmarshalling into a pointer will let us detect null
+	var x *int64
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	if x != nil {
+		v.Valid = true
+		v.Int64 = *x
+	} else {
+		v.Valid = false
+	}
+	return nil
+}
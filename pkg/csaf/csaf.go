@@ -0,0 +1,334 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package csaf converts vulnerabilities to CSAF 2.0 security advisories and VEX documents.
+package csaf
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cbelk/vars"
+)
+
+const (
+	// CategoryAdvisory is the document category of the security advisory profile.
+	CategoryAdvisory = "csaf_security_advisory"
+	// CategoryVex is the document category of the VEX profile.
+	CategoryVex = "csaf_vex"
+	// Version is the CSAF version of the documents.
+	Version = "2.0"
+)
+
+// Document is a CSAF document.
+type Document struct {
+	Document        Meta             `json:"document"`
+	ProductTree     *ProductTree     `json:"product_tree,omitempty"`
+	Vulnerabilities []*Vulnerability `json:"vulnerabilities,omitempty"`
+}
+
+// Meta holds the document level metadata.
+type Meta struct {
+	Category    string       `json:"category"`
+	CsafVersion string       `json:"csaf_version"`
+	Lang        string       `json:"lang"`
+	Publisher   Publisher    `json:"publisher"`
+	Title       string       `json:"title"`
+	Tracking    Tracking     `json:"tracking"`
+	Notes       []*Note      `json:"notes,omitempty"`
+	References  []*Reference `json:"references,omitempty"`
+}
+
+// Publisher identifies the organization publishing the document.
+type Publisher struct {
+	Category  string `json:"category"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// Tracking holds the identity and the revision of the document.
+type Tracking struct {
+	ID                 string      `json:"id"`
+	Status             string      `json:"status"`
+	Version            string      `json:"version"`
+	InitialReleaseDate string      `json:"initial_release_date"`
+	CurrentReleaseDate string      `json:"current_release_date"`
+	RevisionHistory    []*Revision `json:"revision_history"`
+	Generator          *Generator  `json:"generator,omitempty"`
+}
+
+// Revision is an entry of the revision history.
+type Revision struct {
+	Date    string `json:"date"`
+	Number  string `json:"number"`
+	Summary string `json:"summary"`
+}
+
+// Generator names the tool that generated the document.
+type Generator struct {
+	Date   string `json:"date"`
+	Engine struct {
+		Name string `json:"name"`
+	} `json:"engine"`
+}
+
+// Note is a text note of the document or of a vulnerability.
+type Note struct {
+	Category string `json:"category"`
+	Title    string `json:"title,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Reference is a link to more information.
+type Reference struct {
+	Category string `json:"category"`
+	Summary  string `json:"summary"`
+	URL      string `json:"url"`
+}
+
+// ProductTree lists the products the document refers to. The affected systems are the products.
+type ProductTree struct {
+	FullProductNames []*Product `json:"full_product_names"`
+}
+
+// Product is a product of the product tree.
+type Product struct {
+	Name      string `json:"name"`
+	ProductID string `json:"product_id"`
+}
+
+// Vulnerability holds a CVE and its impact on the products.
+type Vulnerability struct {
+	Cve           string         `json:"cve,omitempty"`
+	IDs           []*ID          `json:"ids,omitempty"`
+	Title         string         `json:"title,omitempty"`
+	Notes         []*Note        `json:"notes,omitempty"`
+	ProductStatus *ProductStatus `json:"product_status,omitempty"`
+	Remediations  []*Remediation `json:"remediations,omitempty"`
+	Scores        []*Score       `json:"scores,omitempty"`
+}
+
+// ID is an identifier of a vulnerability that isn't a CVE.
+type ID struct {
+	SystemName string `json:"system_name"`
+	Text       string `json:"text"`
+}
+
+// ProductStatus lists the products by their status for a vulnerability.
+type ProductStatus struct {
+	KnownAffected []string `json:"known_affected,omitempty"`
+	Fixed         []string `json:"fixed,omitempty"`
+}
+
+// Remediation describes how the products are remediated.
+type Remediation struct {
+	Category   string   `json:"category"`
+	Details    string   `json:"details"`
+	ProductIDs []string `json:"product_ids"`
+}
+
+// Score holds the CVSS score of a vulnerability for the products.
+type Score struct {
+	Products []string `json:"products"`
+	CvssV2   *Cvss    `json:"cvss_v2,omitempty"`
+	CvssV3   *Cvss    `json:"cvss_v3,omitempty"`
+}
+
+// Cvss holds a CVSS vector and its base score.
+type Cvss struct {
+	Version      string  `json:"version"`
+	VectorString string  `json:"vectorString"`
+	BaseScore    float32 `json:"baseScore"`
+	BaseSeverity string  `json:"baseSeverity,omitempty"`
+}
+
+// Options holds the inputs of a document besides the vulnerability.
+type Options struct {
+	Vex       bool
+	Publisher Publisher
+	Notes     []*vars.Note                  // Notes to publish with the vulnerability
+	Catalog   map[string]*vars.CatalogEntry // CVE catalog entries of the CVEs, for the CVSS vectors
+	Date      time.Time                     // Generation date
+}
+
+// FromVulnerability returns the CSAF document of the vulnerability. Each affected system is a product, listed as
+// known_affected or fixed depending on whether it is mitigated, and each CVE is a vulnerability of the document.
+// The mitigation is the remediation of the affected products. CVSS scores are only included for the CVEs with a
+// vector in the catalog, as CSAF requires one. The vulnerability must have its affected systems loaded.
+func FromVulnerability(v *vars.Vulnerability, opts *Options) *Document {
+	id := "VARS-" + strconv.FormatInt(v.ID, 10)
+	initial := v.Dates.Initiated
+	if v.Dates.Published.Valid {
+		initial = v.Dates.Published.Time
+	}
+	status := "interim"
+	if v.Dates.Mitigated.Valid {
+		status = "final"
+	}
+	history := revisions(v, initial, opts)
+	doc := Document{Document: Meta{
+		Category:    CategoryAdvisory,
+		CsafVersion: Version,
+		Lang:        "en",
+		Publisher:   opts.Publisher,
+		Title:       v.Name,
+		Tracking: Tracking{
+			ID:                 id,
+			Status:             status,
+			Version:            history[len(history)-1].Number,
+			InitialReleaseDate: formatDate(initial),
+			CurrentReleaseDate: history[len(history)-1].Date,
+			RevisionHistory:    history,
+			Generator:          &Generator{Date: formatDate(opts.Date)},
+		},
+	}}
+	doc.Document.Tracking.Generator.Engine.Name = "VARS"
+	if opts.Vex {
+		doc.Document.Category = CategoryVex
+	}
+	if v.Summary != "" {
+		doc.Document.Notes = append(doc.Document.Notes, &Note{Category: "summary", Title: "Summary", Text: v.Summary})
+	}
+	for _, n := range opts.Notes {
+		doc.Document.Notes = append(doc.Document.Notes, &Note{Category: "general", Title: "Note of " + formatDate(n.Added), Text: n.Note})
+	}
+	for _, ref := range v.References {
+		doc.Document.References = append(doc.Document.References, &Reference{Category: "external", Summary: ref, URL: ref})
+	}
+
+	// The affected systems are the products
+	var affected, fixed, all []string
+	if len(v.AffSystems) > 0 {
+		doc.ProductTree = &ProductTree{}
+	}
+	for _, a := range v.AffSystems {
+		pid := "VARS-SYS-" + strconv.FormatInt(a.Sys.ID, 10)
+		doc.ProductTree.FullProductNames = append(doc.ProductTree.FullProductNames, &Product{Name: a.Sys.Name, ProductID: pid})
+		all = append(all, pid)
+		if a.Mitigated {
+			fixed = append(fixed, pid)
+		} else {
+			affected = append(affected, pid)
+		}
+	}
+
+	cves := v.Cves
+	if len(cves) == 0 {
+		cves = []string{""}
+	}
+	for _, cve := range cves {
+		vuln := Vulnerability{Cve: strings.ToUpper(cve), Title: v.Name}
+		if cve == "" {
+			vuln.IDs = []*ID{{SystemName: "VARS", Text: id}}
+		}
+		if v.Summary != "" {
+			vuln.Notes = []*Note{{Category: "description", Title: "Summary", Text: v.Summary}}
+		}
+		if len(all) > 0 {
+			vuln.ProductStatus = &ProductStatus{KnownAffected: affected, Fixed: fixed}
+		}
+		if len(affected) > 0 {
+			rem := Remediation{Category: "mitigation", Details: v.Mitigation, ProductIDs: affected}
+			if v.Mitigation == "" {
+				rem.Category = "none_available"
+				rem.Details = "No remediation is available yet"
+			}
+			vuln.Remediations = []*Remediation{&rem}
+		}
+		if entry, ok := opts.Catalog[vuln.Cve]; ok && entry.CvssVector.Valid && len(all) > 0 {
+			if score := toScore(entry, all); score != nil {
+				vuln.Scores = []*Score{score}
+			}
+		}
+		doc.Vulnerabilities = append(doc.Vulnerabilities, &vuln)
+	}
+	return &doc
+}
+
+// formatDate formats the date as RFC 3339 in UTC.
+func formatDate(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// revisions returns the revision history of the document of the vulnerability. The document is revised by the
+// dated changes to the vulnerability: the public notes, the systems found mitigated by a scan and the mitigation on
+// all systems. Exporting the same vulnerability again gives the same version unless one of those changed.
+func revisions(v *vars.Vulnerability, initial time.Time, opts *Options) []*Revision {
+	type change struct {
+		date    time.Time
+		summary string
+	}
+	var changes []change
+	for _, n := range opts.Notes {
+		changes = append(changes, change{n.Added, "Note added"})
+	}
+	for _, a := range v.AffSystems {
+		if a.Evidence != nil {
+			changes = append(changes, change{a.Evidence.Mitigated, a.Sys.Name + " mitigated"})
+		}
+	}
+	if v.Dates.Mitigated.Valid {
+		changes = append(changes, change{v.Dates.Mitigated.Time, "Mitigated on all systems"})
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].date.Before(changes[j].date) })
+
+	history := []*Revision{{Date: formatDate(initial), Number: "1", Summary: "Initial version"}}
+	for _, c := range changes {
+		if c.date.Before(initial) {
+			c.date = initial
+		}
+		history = append(history, &Revision{Date: formatDate(c.date), Number: strconv.Itoa(len(history) + 1), Summary: c.summary})
+	}
+	return history
+}
+
+// severity returns the CVSSv3 severity of the base score.
+func severity(score float32) string {
+	switch {
+	case score >= 9:
+		return "CRITICAL"
+	case score >= 7:
+		return "HIGH"
+	case score >= 4:
+		return "MEDIUM"
+	case score > 0:
+		return "LOW"
+	}
+	return "NONE"
+}
+
+// toScore returns the score of the catalog entry for the products. Vectors starting with CVSS:3 are CVSSv3 and
+// vectors with the CVSSv2 metrics are CVSSv2. Other vectors, such as CVSS:4.0, have no score in CSAF 2.0 and nil
+// is returned.
+func toScore(entry *vars.CatalogEntry, products []string) *Score {
+	vector := entry.CvssVector.String
+	score := Score{Products: products}
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3."):
+		score.CvssV3 = &Cvss{Version: vector[5:8], VectorString: vector, BaseScore: entry.CvssScore, BaseSeverity: severity(entry.CvssScore)}
+	case strings.HasPrefix(vector, "AV:") && strings.Contains(vector, "/Au:"):
+		score.CvssV2 = &Cvss{Version: "2.0", VectorString: vector, BaseScore: entry.CvssScore}
+	default:
+		return nil
+	}
+	return &score
+}
@@ -0,0 +1,149 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package epss reads the FIRST EPSS daily score files.
+package epss
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cbelk/vars"
+)
+
+// Layouts of the score_date in the comment line of the files.
+var dateLayouts = []string{
+	"2006-01-02T15:04:05-0700",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// Parse reads an EPSS score file, optionally gzip compressed. The file starts with a comment line holding the
+// model version and score date, followed by the cve,epss,percentile header and the scores.
+func Parse(r io.Reader) ([]*vars.EpssScore, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	// Read the model version and score date from the comment line
+	var version vars.VarsNullString
+	var date time.Time
+	if b, err := br.Peek(1); err == nil && b[0] == '#' {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range strings.Split(strings.TrimSpace(strings.TrimPrefix(line, "#")), ",") {
+			i := strings.Index(kv, ":")
+			if i < 0 {
+				continue
+			}
+			switch kv[:i] {
+			case "model_version":
+				version = vars.ToVarsNullString(kv[i+1:])
+			case "score_date":
+				if date, err = parseDate(kv[i+1:]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if date.IsZero() {
+		return nil, errors.New("epss: Parse: Missing the score_date comment line")
+	}
+
+	cr := csv.NewReader(br)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		cols[strings.TrimSpace(h)] = i
+	}
+	cveCol, ok1 := cols["cve"]
+	epssCol, ok2 := cols["epss"]
+	pctCol, ok3 := cols["percentile"]
+	if !ok1 || !ok2 || !ok3 {
+		return nil, errors.New("epss: Parse: Missing the cve, epss or percentile column")
+	}
+
+	scores := []*vars.EpssScore{}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		score, err := strconv.ParseFloat(row[epssCol], 32)
+		if err != nil {
+			return nil, err
+		}
+		pct, err := strconv.ParseFloat(row[pctCol], 32)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, &vars.EpssScore{
+			Cve:          strings.ToUpper(row[cveCol]),
+			Score:        float32(score),
+			Percentile:   float32(pct),
+			ModelDate:    date,
+			ModelVersion: version,
+		})
+	}
+	return scores, nil
+}
+
+// ParseFile reads the EPSS score file at path.
+func ParseFile(path string) ([]*vars.EpssScore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// parseDate parses the score date of the file.
+func parseDate(s string) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
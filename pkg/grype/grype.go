@@ -0,0 +1,200 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package grype reads the JSON output of the Grype container image scanner.
+package grype
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cbelk/vars"
+)
+
+// Scanner is the name the Grype findings are recorded under.
+const Scanner = "grype"
+
+// SystemType is the type of the systems the scanned images are recorded as.
+const SystemType = "container-image"
+
+type document struct {
+	Matches []match `json:"matches"`
+	Source  *source `json:"source"`
+	Distro  distro  `json:"distro"`
+}
+
+type match struct {
+	Vulnerability          vulnerability   `json:"vulnerability"`
+	RelatedVulnerabilities []vulnerability `json:"relatedVulnerabilities"`
+	Artifact               artifact        `json:"artifact"`
+}
+
+type vulnerability struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	URLs        []string `json:"urls"`
+	Cvss        []cvss   `json:"cvss"`
+	Fix         fix      `json:"fix"`
+}
+
+type cvss struct {
+	Version string `json:"version"`
+	Metrics struct {
+		BaseScore float32 `json:"baseScore"`
+	} `json:"metrics"`
+}
+
+type fix struct {
+	Versions []string `json:"versions"`
+}
+
+type artifact struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type source struct {
+	Type   string          `json:"type"`
+	Target json.RawMessage `json:"target"`
+}
+
+type imageTarget struct {
+	UserInput      string   `json:"userInput"`
+	ImageID        string   `json:"imageID"`
+	ManifestDigest string   `json:"manifestDigest"`
+	RepoDigests    []string `json:"repoDigests"`
+}
+
+type distro struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Parse reads the JSON output of a Grype scan. The image is returned as the only host, named by its repository and
+// digest. A vulnerability matched for several packages is returned as one finding listing the packages. The CVEs
+// of a vulnerability that isn't a CVE (e.g. a GitHub advisory) are taken from its related vulnerabilities.
+func Parse(r io.Reader) (*vars.ScanReport, error) {
+	var doc document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.Source == nil {
+		return nil, errors.New("grype: Parse: The file is not a Grype report")
+	}
+
+	name := sourceName(doc.Source)
+	if name == "" {
+		return nil, errors.New("grype: Parse: The report does not name the scanned image")
+	}
+	host := vars.ScanHost{Name: name, Type: SystemType, OpSys: strings.TrimSpace(doc.Distro.Name + " " + doc.Distro.Version)}
+	findings := make(map[string]*vars.ScanFinding)
+	for i := range doc.Matches {
+		m := &doc.Matches[i]
+		if m.Vulnerability.ID == "" {
+			continue
+		}
+		f, ok := findings[m.Vulnerability.ID]
+		if !ok {
+			f = toFinding(m)
+			findings[m.Vulnerability.ID] = f
+			host.Findings = append(host.Findings, f)
+		}
+		p := vars.AffectedPkg{Package: m.Artifact.Name, Installed: m.Artifact.Version}
+		if len(m.Vulnerability.Fix.Versions) > 0 {
+			p.Fixed = m.Vulnerability.Fix.Versions[0]
+		}
+		f.Packages = append(f.Packages, &p)
+	}
+	for _, f := range host.Findings {
+		f.Solution = vars.UpgradeSolution(f.Packages)
+	}
+	return &vars.ScanReport{Scanner: Scanner, Name: name, Hosts: []*vars.ScanHost{&host}}, nil
+}
+
+// ParseFile reads the Grype JSON output at path.
+func ParseFile(path string) (*vars.ScanReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// sourceName returns the repository and digest of the scanned image. The image ID is used when the image has no
+// repository digest, and the user input on its own when the scan wasn't of an image.
+func sourceName(src *source) string {
+	var target imageTarget
+	if err := json.Unmarshal(src.Target, &target); err != nil {
+		// Directory and file scans have the path as the target
+		var path string
+		json.Unmarshal(src.Target, &path)
+		return path
+	}
+	if len(target.RepoDigests) > 0 {
+		return target.RepoDigests[0]
+	}
+	name := target.UserInput
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	for _, digest := range []string{target.ManifestDigest, target.ImageID} {
+		if digest != "" {
+			return name + "@" + digest
+		}
+	}
+	return target.UserInput
+}
+
+// toFinding converts the match into a ScanFinding. The highest CVSSv3 score of the vulnerability or its related
+// vulnerabilities is used, or the highest CVSSv2 score if there is none.
+func toFinding(m *match) *vars.ScanFinding {
+	v := &m.Vulnerability
+	f := vars.ScanFinding{
+		PluginID:   v.ID,
+		Name:       v.ID,
+		Summary:    strings.TrimSpace(v.Description),
+		References: v.URLs,
+	}
+	var v2 float32
+	for _, vuln := range append([]vulnerability{*v}, m.RelatedVulnerabilities...) {
+		id := strings.ToUpper(vuln.ID)
+		if strings.HasPrefix(id, "CVE-") && !vars.ContainsString(f.Cves, id) {
+			f.Cves = append(f.Cves, id)
+		}
+		if f.Summary == "" {
+			f.Summary = strings.TrimSpace(vuln.Description)
+		}
+		for _, c := range vuln.Cvss {
+			if strings.HasPrefix(c.Version, "3") && c.Metrics.BaseScore > f.Cvss {
+				f.Cvss = c.Metrics.BaseScore
+			} else if strings.HasPrefix(c.Version, "2") && c.Metrics.BaseScore > v2 {
+				v2 = c.Metrics.BaseScore
+			}
+		}
+	}
+	if f.Cvss == 0 {
+		f.Cvss = v2
+	}
+	return &f
+}
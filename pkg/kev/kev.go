@@ -0,0 +1,182 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package kev reads the CISA Known Exploited Vulnerabilities catalog in its JSON or CSV format.
+package kev
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cbelk/vars"
+)
+
+// dateLayout is the layout of the dates in the catalog.
+const dateLayout = "2006-01-02"
+
+type catalog struct {
+	Vulnerabilities []record `json:"vulnerabilities"`
+}
+
+type record struct {
+	CveID                      string `json:"cveID"`
+	VendorProject              string `json:"vendorProject"`
+	Product                    string `json:"product"`
+	VulnerabilityName          string `json:"vulnerabilityName"`
+	DateAdded                  string `json:"dateAdded"`
+	RequiredAction             string `json:"requiredAction"`
+	DueDate                    string `json:"dueDate"`
+	KnownRansomwareCampaignUse string `json:"knownRansomwareCampaignUse"`
+}
+
+// Parse reads the catalog in either format. The format is detected from the first character of the file.
+func Parse(r io.Reader) ([]*vars.KevEntry, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n', 0xef, 0xbb, 0xbf:
+			// Skip leading whitespace and the UTF-8 byte order mark
+			br.ReadByte()
+			continue
+		case '{':
+			return parseJSON(br)
+		}
+		return parseCSV(br)
+	}
+}
+
+// ParseFile reads the catalog at path.
+func ParseFile(path string) ([]*vars.KevEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// parseCSV reads the catalog in the CSV format. The columns are looked up by the names in the header row.
+func parseCSV(r io.Reader) ([]*vars.KevEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		cols[strings.TrimSpace(h)] = i
+	}
+	if _, ok := cols["cveID"]; !ok {
+		return nil, errors.New("kev: parseCSV: Missing the cveID column")
+	}
+	field := func(row []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	entries := []*vars.KevEntry{}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := record{
+			CveID:                      field(row, "cveID"),
+			VendorProject:              field(row, "vendorProject"),
+			Product:                    field(row, "product"),
+			VulnerabilityName:          field(row, "vulnerabilityName"),
+			DateAdded:                  field(row, "dateAdded"),
+			RequiredAction:             field(row, "requiredAction"),
+			DueDate:                    field(row, "dueDate"),
+			KnownRansomwareCampaignUse: field(row, "knownRansomwareCampaignUse"),
+		}
+		e, err := toEntry(&rec)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// parseJSON reads the catalog in the JSON format.
+func parseJSON(r io.Reader) ([]*vars.KevEntry, error) {
+	var c catalog
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	entries := make([]*vars.KevEntry, 0, len(c.Vulnerabilities))
+	for i := range c.Vulnerabilities {
+		e, err := toEntry(&c.Vulnerabilities[i])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// toEntry converts the record of the catalog into a KevEntry.
+func toEntry(rec *record) (*vars.KevEntry, error) {
+	if rec.CveID == "" {
+		return nil, errors.New("kev: toEntry: Vulnerability without a cveID")
+	}
+	e := vars.KevEntry{
+		Cve:        strings.ToUpper(rec.CveID),
+		Vendor:     rec.VendorProject,
+		Product:    rec.Product,
+		Name:       rec.VulnerabilityName,
+		Action:     rec.RequiredAction,
+		Ransomware: rec.KnownRansomwareCampaignUse,
+	}
+	if e.Ransomware == "" {
+		e.Ransomware = "Unknown"
+	}
+	added, err := time.Parse(dateLayout, rec.DateAdded)
+	if err != nil {
+		return nil, err
+	}
+	e.DateAdded = added
+	if rec.DueDate != "" {
+		due, err := time.Parse(dateLayout, rec.DueDate)
+		if err != nil {
+			return nil, err
+		}
+		e.DueDate.Time = due
+		e.DueDate.Valid = true
+	}
+	return &e, nil
+}
@@ -0,0 +1,161 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package nessus reads Nessus v2 (.nessus) scan reports.
+package nessus
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cbelk/vars"
+)
+
+// Scanner is the name the Nessus findings are recorded under.
+const Scanner = "nessus"
+
+type clientData struct {
+	XMLName xml.Name `xml:"NessusClientData_v2"`
+	Reports []report `xml:"Report"`
+}
+
+type report struct {
+	Name  string       `xml:"name,attr"`
+	Hosts []reportHost `xml:"ReportHost"`
+}
+
+type reportHost struct {
+	Name       string       `xml:"name,attr"`
+	Properties []tag        `xml:"HostProperties>tag"`
+	Items      []reportItem `xml:"ReportItem"`
+}
+
+type tag struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:",chardata"`
+}
+
+type reportItem struct {
+	PluginID    string   `xml:"pluginID,attr"`
+	PluginName  string   `xml:"pluginName,attr"`
+	Severity    int      `xml:"severity,attr"`
+	Cves        []string `xml:"cve"`
+	Cvss3       string   `xml:"cvss3_base_score"`
+	Cvss        string   `xml:"cvss_base_score"`
+	Synopsis    string   `xml:"synopsis"`
+	Description string   `xml:"description"`
+	Solution    string   `xml:"solution"`
+	SeeAlso     []string `xml:"see_also"`
+}
+
+// Parse reads a Nessus v2 report. Informational findings (severity 0) are skipped and a plugin reported on
+// several ports of a host is only returned once for the host.
+func Parse(r io.Reader) (*vars.ScanReport, error) {
+	var data clientData
+	if err := xml.NewDecoder(r).Decode(&data); err != nil {
+		return nil, err
+	}
+	if len(data.Reports) == 0 {
+		return nil, errors.New("nessus: Parse: The file does not contain a report")
+	}
+
+	rep := vars.ScanReport{Scanner: Scanner, Name: data.Reports[0].Name}
+	for _, report := range data.Reports {
+		for i := range report.Hosts {
+			rep.Hosts = append(rep.Hosts, toHost(&report.Hosts[i]))
+		}
+	}
+	return &rep, nil
+}
+
+// ParseFile reads the Nessus v2 report at path.
+func ParseFile(path string) (*vars.ScanReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// toFinding converts the report item into a ScanFinding. The CVSSv3 base score is preferred over the CVSSv2 one.
+func toFinding(item *reportItem) *vars.ScanFinding {
+	f := vars.ScanFinding{
+		PluginID: item.PluginID,
+		Name:     strings.TrimSpace(item.PluginName),
+		Summary:  strings.TrimSpace(item.Synopsis),
+		Solution: strings.TrimSpace(item.Solution),
+	}
+	if f.Summary == "" {
+		f.Summary = strings.TrimSpace(item.Description)
+	}
+	for _, cve := range item.Cves {
+		if cve = strings.ToUpper(strings.TrimSpace(cve)); cve != "" && !vars.ContainsString(f.Cves, cve) {
+			f.Cves = append(f.Cves, cve)
+		}
+	}
+	for _, score := range []string{item.Cvss3, item.Cvss} {
+		if c, err := strconv.ParseFloat(strings.TrimSpace(score), 32); err == nil {
+			f.Cvss = float32(c)
+			break
+		}
+	}
+	for _, sa := range item.SeeAlso {
+		for _, ref := range strings.Fields(sa) {
+			f.References = append(f.References, ref)
+		}
+	}
+	return &f
+}
+
+// toHost converts the report host into a ScanHost. The host-fqdn and host-ip properties are preferred over the
+// name of the report host, which is whatever the scan target was given as.
+func toHost(rh *reportHost) *vars.ScanHost {
+	h := vars.ScanHost{Name: rh.Name}
+	for _, t := range rh.Properties {
+		v := strings.TrimSpace(t.Value)
+		switch t.Name {
+		case "host-fqdn":
+			h.Name = v
+		case "host-ip":
+			h.Address = v
+		case "operating-system":
+			h.OpSys = strings.SplitN(v, "\n", 2)[0]
+		}
+	}
+	if h.Address == "" {
+		h.Address = rh.Name
+	}
+
+	seen := make(map[string]bool)
+	for i := range rh.Items {
+		item := &rh.Items[i]
+		if item.Severity == 0 || item.PluginID == "" || seen[item.PluginID] {
+			continue
+		}
+		seen[item.PluginID] = true
+		h.Findings = append(h.Findings, toFinding(item))
+	}
+	return &h
+}
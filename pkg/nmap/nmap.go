@@ -0,0 +1,167 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package nmap reads Nmap XML (-oX) output.
+package nmap
+
+import (
+	"encoding/xml"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cbelk/vars"
+)
+
+// Scanner is the name the Nmap results are recorded under.
+const Scanner = "nmap"
+
+type nmapRun struct {
+	XMLName xml.Name `xml:"nmaprun"`
+	Args    string   `xml:"args,attr"`
+	Hosts   []host   `xml:"host"`
+}
+
+type host struct {
+	Status    status     `xml:"status"`
+	Addresses []address  `xml:"address"`
+	Hostnames []hostname `xml:"hostnames>hostname"`
+	Ports     []port     `xml:"ports>port"`
+	OsMatches []osMatch  `xml:"os>osmatch"`
+}
+
+type status struct {
+	State string `xml:"state,attr"`
+}
+
+type address struct {
+	Addr     string `xml:"addr,attr"`
+	AddrType string `xml:"addrtype,attr"`
+}
+
+type hostname struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type port struct {
+	Protocol string  `xml:"protocol,attr"`
+	PortID   int     `xml:"portid,attr"`
+	State    status  `xml:"state"`
+	Service  service `xml:"service"`
+}
+
+type service struct {
+	Name string `xml:"name,attr"`
+}
+
+type osMatch struct {
+	Name     string `xml:"name,attr"`
+	Accuracy int    `xml:"accuracy,attr"`
+}
+
+// Parse reads Nmap XML output. Only the hosts that were up are returned as hosts, with their open ports and best
+// OS guess. The targets are the networks and addresses on the Nmap command line and the addresses of all of the
+// hosts in the output, including the ones that were down.
+func Parse(r io.Reader) (*vars.ScanReport, error) {
+	var run nmapRun
+	if err := xml.NewDecoder(r).Decode(&run); err != nil {
+		return nil, err
+	}
+
+	rep := vars.ScanReport{Scanner: Scanner}
+	for _, arg := range strings.Fields(run.Args) {
+		if _, _, err := net.ParseCIDR(arg); err == nil {
+			rep.Targets = append(rep.Targets, arg)
+		} else if net.ParseIP(arg) != nil {
+			rep.Targets = append(rep.Targets, arg)
+		}
+	}
+	for i := range run.Hosts {
+		h := &run.Hosts[i]
+		addr := hostAddress(h)
+		if addr == "" {
+			continue
+		}
+		rep.Targets = append(rep.Targets, addr)
+		if h.Status.State != "up" {
+			continue
+		}
+		rep.Hosts = append(rep.Hosts, toHost(h, addr))
+	}
+	return &rep, nil
+}
+
+// ParseFile reads the Nmap XML output at path.
+func ParseFile(path string) (*vars.ScanReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// hostAddress returns the IPv4 address of the host, or its IPv6 address if it has none.
+func hostAddress(h *host) string {
+	var addr string
+	for _, a := range h.Addresses {
+		switch a.AddrType {
+		case "ipv4":
+			return a.Addr
+		case "ipv6":
+			if addr == "" {
+				addr = a.Addr
+			}
+		}
+	}
+	return addr
+}
+
+// toHost converts the host into a ScanHost. A user supplied host name is preferred over the PTR record.
+func toHost(h *host, addr string) *vars.ScanHost {
+	sh := vars.ScanHost{Address: addr}
+	for _, hn := range h.Hostnames {
+		if sh.Name == "" || hn.Type == "user" {
+			sh.Name = hn.Name
+		}
+	}
+	best := -1
+	for _, m := range h.OsMatches {
+		if m.Accuracy > best {
+			sh.OpSys, best = m.Name, m.Accuracy
+		}
+	}
+	for _, p := range h.Ports {
+		if p.State.State != "open" {
+			continue
+		}
+		sh.Ports = append(sh.Ports, &vars.SysPort{Port: p.PortID, Protocol: p.Protocol, Service: p.Service.Name})
+	}
+	sort.Slice(sh.Ports, func(i, j int) bool {
+		if sh.Ports[i].Protocol != sh.Ports[j].Protocol {
+			return sh.Ports[i].Protocol < sh.Ports[j].Protocol
+		}
+		return sh.Ports[i].Port < sh.Ports[j].Port
+	})
+	return &sh
+}
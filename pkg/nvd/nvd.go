@@ -0,0 +1,212 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package nvd reads the NVD CVE JSON 2.0 feeds into VARS catalog entries.
+package nvd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cbelk/vars"
+)
+
+// Layouts of the timestamps used in the feeds.
+var timeLayouts = []string{
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+	time.RFC3339Nano,
+}
+
+type feed struct {
+	Vulnerabilities []struct {
+		Cve cve `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type cve struct {
+	ID           string `json:"id"`
+	Published    string `json:"published"`
+	LastModified string `json:"lastModified"`
+	Descriptions []struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Metrics struct {
+		V40 []metric `json:"cvssMetricV40"`
+		V31 []metric `json:"cvssMetricV31"`
+		V30 []metric `json:"cvssMetricV30"`
+		V2  []metric `json:"cvssMetricV2"`
+	} `json:"metrics"`
+	Weaknesses []struct {
+		Description []struct {
+			Lang  string `json:"lang"`
+			Value string `json:"value"`
+		} `json:"description"`
+	} `json:"weaknesses"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+}
+
+type metric struct {
+	Type     string `json:"type"`
+	CvssData struct {
+		VectorString string  `json:"vectorString"`
+		BaseScore    float32 `json:"baseScore"`
+	} `json:"cvssData"`
+}
+
+// CalculatorLink returns the link to the FIRST (CVSS v3 and v4) or NVD (CVSS v2) calculator for the vector.
+func CalculatorLink(vector string) string {
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.0/"):
+		return "https://www.first.org/cvss/calculator/3.0#" + vector
+	case strings.HasPrefix(vector, "CVSS:3.1/"):
+		return "https://www.first.org/cvss/calculator/3.1#" + vector
+	case strings.HasPrefix(vector, "CVSS:4.0/"):
+		return "https://www.first.org/cvss/calculator/4.0#" + vector
+	}
+	return "https://nvd.nist.gov/vuln-metrics/cvss/v2-calculator?vector=(" + vector + ")"
+}
+
+// Parse reads a NVD CVE JSON 2.0 feed, optionally gzip compressed, and returns its CVEs as catalog entries.
+func Parse(r io.Reader) ([]*vars.CatalogEntry, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var f feed
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+	entries := make([]*vars.CatalogEntry, 0, len(f.Vulnerabilities))
+	for _, v := range f.Vulnerabilities {
+		e, err := toEntry(&v.Cve)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// ParseFile reads the NVD CVE JSON 2.0 feed at path.
+func ParseFile(path string) ([]*vars.CatalogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// parseTime parses a timestamp of the feed in UTC.
+func parseTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// preferredMetric returns the metric used for the score of the CVE: the newest CVSS v3 metric if there is one,
+// then v4 and then v2. The primary (NVD) metric of a version is preferred over the secondary ones.
+func preferredMetric(c *cve) *metric {
+	for _, ms := range [][]metric{c.Metrics.V31, c.Metrics.V30, c.Metrics.V40, c.Metrics.V2} {
+		if len(ms) == 0 {
+			continue
+		}
+		for i := range ms {
+			if ms[i].Type == "Primary" {
+				return &ms[i]
+			}
+		}
+		return &ms[0]
+	}
+	return nil
+}
+
+// toEntry converts the CVE of the feed into a catalog entry.
+func toEntry(c *cve) (*vars.CatalogEntry, error) {
+	if c.ID == "" {
+		return nil, errors.New("nvd: toEntry: CVE without an id")
+	}
+	e := vars.CatalogEntry{Cve: strings.ToUpper(c.ID)}
+
+	mod, err := parseTime(c.LastModified)
+	if err != nil {
+		return nil, err
+	}
+	e.LastModified = mod
+	if c.Published != "" {
+		pub, err := parseTime(c.Published)
+		if err != nil {
+			return nil, err
+		}
+		e.Published.Time = pub
+		e.Published.Valid = true
+	}
+
+	for _, d := range c.Descriptions {
+		if d.Lang == "en" {
+			e.Summary = d.Value
+			break
+		}
+	}
+
+	if m := preferredMetric(c); m != nil {
+		e.CvssVector = vars.ToVarsNullString(m.CvssData.VectorString)
+		e.CvssScore = m.CvssData.BaseScore
+	}
+
+	// NVD-CWE-Other and NVD-CWE-noinfo are not CWE IDs
+	for _, w := range c.Weaknesses {
+		for _, d := range w.Description {
+			if strings.HasPrefix(d.Value, "CWE-") && !vars.ContainsString(e.Cwes, d.Value) {
+				e.Cwes = append(e.Cwes, d.Value)
+			}
+		}
+	}
+
+	for _, ref := range c.References {
+		if ref.URL != "" && !vars.ContainsString(e.References, ref.URL) {
+			e.References = append(e.References, ref.URL)
+		}
+	}
+	return &e, nil
+}
@@ -0,0 +1,231 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package openvas reads the XML reports of OpenVAS / Greenbone Vulnerability Management (GVM).
+package openvas
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cbelk/vars"
+)
+
+// Scanner is the name the OpenVAS findings are recorded under.
+const Scanner = "openvas"
+
+type result struct {
+	Host        resultHost `xml:"host"`
+	Nvt         nvt        `xml:"nvt"`
+	Severity    string     `xml:"severity"`
+	Description string     `xml:"description"`
+}
+
+type resultHost struct {
+	Address  string `xml:",chardata"`
+	Hostname string `xml:"hostname"`
+}
+
+type nvt struct {
+	Oid      string `xml:"oid,attr"`
+	Name     string `xml:"name"`
+	CvssBase string `xml:"cvss_base"`
+	Cve      string `xml:"cve"`  // Comma separated CVEs of GVM 8 and earlier
+	Xref     string `xml:"xref"` // Comma separated "URL:" references of GVM 8 and earlier
+	Tags     string `xml:"tags"`
+	Solution string `xml:"solution"`
+	Refs     []ref  `xml:"refs>ref"`
+}
+
+type ref struct {
+	Type string `xml:"type,attr"`
+	ID   string `xml:"id,attr"`
+}
+
+type host struct {
+	IP      string   `xml:"ip"`
+	Details []detail `xml:"detail"`
+}
+
+type detail struct {
+	Name  string `xml:"name"`
+	Value string `xml:"value"`
+}
+
+// Parse reads a GVM XML report, either on its own or in a get_reports response. Results with a severity of 0 (log)
+// or below (false positives) are skipped and an NVT reported on several ports of a host is only returned once for
+// the host.
+func Parse(r io.Reader) (*vars.ScanReport, error) {
+	rep := vars.ScanReport{Scanner: Scanner}
+	hosts := make(map[string]*vars.ScanHost)
+	seen := make(map[string]bool)
+	getHost := func(addr string) *vars.ScanHost {
+		h, ok := hosts[addr]
+		if !ok {
+			h = &vars.ScanHost{Address: addr}
+			hosts[addr] = h
+			rep.Hosts = append(rep.Hosts, h)
+		}
+		return h
+	}
+
+	// The results and the host details are decoded as they are found, wherever they are nested
+	found := false
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "report":
+			found = true
+			for _, attr := range se.Attr {
+				if attr.Name.Local == "id" && rep.Name == "" {
+					rep.Name = attr.Value
+				}
+			}
+		case "result":
+			var res result
+			if err := dec.DecodeElement(&res, &se); err != nil {
+				return nil, err
+			}
+			addr := strings.TrimSpace(res.Host.Address)
+			if addr == "" || res.Nvt.Oid == "" {
+				continue
+			}
+			h := getHost(addr)
+			if hn := strings.TrimSpace(res.Host.Hostname); hn != "" {
+				h.Name = hn
+			}
+			if sev, err := strconv.ParseFloat(strings.TrimSpace(res.Severity), 32); err == nil && sev <= 0 {
+				continue
+			}
+			if seen[addr+" "+res.Nvt.Oid] {
+				continue
+			}
+			seen[addr+" "+res.Nvt.Oid] = true
+			h.Findings = append(h.Findings, toFinding(&res))
+		case "host":
+			var hst host
+			if err := dec.DecodeElement(&hst, &se); err != nil {
+				return nil, err
+			}
+			addr := strings.TrimSpace(hst.IP)
+			if addr == "" {
+				continue
+			}
+			h := getHost(addr)
+			for _, d := range hst.Details {
+				v := strings.TrimSpace(d.Value)
+				switch d.Name {
+				case "hostname":
+					if h.Name == "" {
+						h.Name = v
+					}
+				case "best_os_txt":
+					h.OpSys = v
+				}
+			}
+		}
+	}
+	if !found {
+		return nil, errors.New("openvas: Parse: The file does not contain a report")
+	}
+	return &rep, nil
+}
+
+// ParseFile reads the GVM XML report at path.
+func ParseFile(path string) (*vars.ScanReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// parseTags splits the tags of an NVT (key=value pairs separated by |) into a map.
+func parseTags(tags string) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range strings.Split(tags, "|") {
+		if i := strings.Index(kv, "="); i > 0 {
+			m[kv[:i]] = strings.TrimSpace(kv[i+1:])
+		}
+	}
+	return m
+}
+
+// toFinding converts the result into a ScanFinding. The CVEs and references are read from the refs of the NVT,
+// or from its cve and xref elements for reports of older versions.
+func toFinding(res *result) *vars.ScanFinding {
+	tags := parseTags(res.Nvt.Tags)
+	f := vars.ScanFinding{
+		PluginID: res.Nvt.Oid,
+		Name:     strings.TrimSpace(res.Nvt.Name),
+		Summary:  tags["summary"],
+		Solution: strings.TrimSpace(res.Nvt.Solution),
+	}
+	if f.Summary == "" {
+		f.Summary = strings.TrimSpace(res.Description)
+	}
+	if f.Solution == "" {
+		f.Solution = tags["solution"]
+	}
+	if c, err := strconv.ParseFloat(strings.TrimSpace(res.Nvt.CvssBase), 32); err == nil {
+		f.Cvss = float32(c)
+	} else if c, err := strconv.ParseFloat(strings.TrimSpace(res.Severity), 32); err == nil {
+		f.Cvss = float32(c)
+	}
+
+	addCve := func(cve string) {
+		if cve = strings.ToUpper(strings.TrimSpace(cve)); strings.HasPrefix(cve, "CVE-") && !vars.ContainsString(f.Cves, cve) {
+			f.Cves = append(f.Cves, cve)
+		}
+	}
+	for _, r := range res.Nvt.Refs {
+		switch r.Type {
+		case "cve":
+			addCve(r.ID)
+		case "url":
+			f.References = append(f.References, strings.TrimSpace(r.ID))
+		}
+	}
+	for _, cve := range strings.Split(res.Nvt.Cve, ",") {
+		addCve(cve)
+	}
+	for _, x := range strings.Split(res.Nvt.Xref, ",") {
+		if x = strings.TrimSpace(x); strings.HasPrefix(x, "URL:") {
+			f.References = append(f.References, strings.TrimPrefix(x, "URL:"))
+		}
+	}
+	return &f
+}
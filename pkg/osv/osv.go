@@ -0,0 +1,449 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package osv reads and writes vulnerability records in the OSV format (https://ossf.github.io/osv-schema/).
+package osv
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cbelk/vars"
+)
+
+// SchemaVersion is the version of the OSV schema of the exported records.
+const SchemaVersion = "1.6.0"
+
+// Severity types of the OSV schema.
+const (
+	CvssV2 = "CVSS_V2"
+	CvssV3 = "CVSS_V3"
+	CvssV4 = "CVSS_V4"
+)
+
+// ecosystems maps the package URL types to the OSV ecosystems.
+var ecosystems = map[string]string{
+	"apk":      "Alpine",
+	"cargo":    "crates.io",
+	"composer": "Packagist",
+	"deb":      "Debian",
+	"gem":      "RubyGems",
+	"golang":   "Go",
+	"hex":      "Hex",
+	"maven":    "Maven",
+	"npm":      "npm",
+	"nuget":    "NuGet",
+	"pub":      "Pub",
+	"pypi":     "PyPI",
+}
+
+// Entry is an OSV vulnerability record.
+type Entry struct {
+	SchemaVersion    string            `json:"schema_version,omitempty"`
+	ID               string            `json:"id"`
+	Modified         string            `json:"modified"`
+	Published        string            `json:"published,omitempty"`
+	Withdrawn        string            `json:"withdrawn,omitempty"`
+	Aliases          []string          `json:"aliases,omitempty"`
+	Summary          string            `json:"summary,omitempty"`
+	Details          string            `json:"details,omitempty"`
+	Severity         []*Severity       `json:"severity,omitempty"`
+	Affected         []*Affected       `json:"affected,omitempty"`
+	References       []*Reference      `json:"references,omitempty"`
+	DatabaseSpecific *DatabaseSpecific `json:"database_specific,omitempty"`
+}
+
+// Severity holds a CVSS vector of the record.
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// Affected holds a package and the versions of it that are affected.
+type Affected struct {
+	Package          *Package          `json:"package,omitempty"`
+	Ranges           []*Range          `json:"ranges,omitempty"`
+	Versions         []string          `json:"versions,omitempty"`
+	DatabaseSpecific *AffectedSpecific `json:"database_specific,omitempty"`
+}
+
+// AffectedSpecific holds a package whose ecosystem is not known, which OSV has no package for.
+type AffectedSpecific struct {
+	Package string   `json:"package"`
+	Fixed   []string `json:"fixed,omitempty"` // Versions the package is fixed in
+}
+
+// Package identifies a package in an ecosystem.
+type Package struct {
+	Ecosystem string `json:"ecosystem,omitempty"`
+	Name      string `json:"name"`
+	Purl      string `json:"purl,omitempty"`
+}
+
+// Range is a range of affected versions given by its events.
+type Range struct {
+	Type   string   `json:"type"`
+	Events []*Event `json:"events"`
+}
+
+// Event is a version at which the package became affected or fixed.
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// Reference is a link to more information.
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// DatabaseSpecific holds the fields of the record specific to the database that published it. Only those known
+// to VARS are kept.
+type DatabaseSpecific struct {
+	CweIDs     []string `json:"cwe_ids,omitempty"`
+	Mitigation string   `json:"mitigation,omitempty"`
+}
+
+// Cves returns the CVE IDs of the record, its own ID first if it is a CVE and then the CVE aliases.
+func (e *Entry) Cves() []string {
+	var cves []string
+	for _, id := range append([]string{e.ID}, e.Aliases...) {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if strings.HasPrefix(id, "CVE-") && !vars.ContainsString(cves, id) {
+			cves = append(cves, id)
+		}
+	}
+	return cves
+}
+
+// Vector returns the preferred CVSS vector of the record and its base score: the CVSS v3 vector if there is one
+// and then the CVSS v2 vector. An empty vector is returned if the record has neither.
+func (e *Entry) Vector() (string, float32) {
+	for _, t := range []string{CvssV3, CvssV2} {
+		for _, s := range e.Severity {
+			if s.Type != t {
+				continue
+			}
+			if score, ok := BaseScore(s.Score); ok {
+				return s.Score, score
+			}
+		}
+	}
+	return "", 0
+}
+
+// EcosystemFromPurl returns the OSV ecosystem of the package URL, or an empty string if it is not known.
+func EcosystemFromPurl(purl string) string {
+	if !strings.HasPrefix(purl, "pkg:") {
+		return ""
+	}
+	t := strings.TrimPrefix(purl, "pkg:")
+	if i := strings.Index(t, "/"); i >= 0 {
+		t = t[:i]
+	}
+	return ecosystems[strings.ToLower(t)]
+}
+
+// Parse reads an OSV JSON record.
+func Parse(r io.Reader) (*Entry, error) {
+	var e Entry
+	if err := json.NewDecoder(r).Decode(&e); err != nil {
+		return nil, err
+	}
+	if e.ID == "" {
+		return nil, errors.New("osv: Parse: Record without an id")
+	}
+	return &e, nil
+}
+
+// ParseDir reads the OSV JSON records (*.json) in the directory and its subdirectories, as found in the
+// ecosystem dumps of osv.dev once unzipped.
+func ParseDir(dir string) ([]*Entry, error) {
+	var entries []*Entry
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+		e, err := ParseFile(path)
+		if err != nil {
+			return errors.New(path + ": " + err.Error())
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+// ParseFile reads the OSV JSON record at path.
+func ParseFile(path string) (*Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// ToCatalogEntries converts the record into a catalog entry for each of its CVEs. The details of the record are
+// used as the summary if it has them. An error is returned if the dates of the record can't be parsed.
+func ToCatalogEntries(e *Entry) ([]*vars.CatalogEntry, error) {
+	var entries []*vars.CatalogEntry
+	mod, err := time.Parse(time.RFC3339, e.Modified)
+	if err != nil {
+		return nil, err
+	}
+	var pub vars.VarsNullTime
+	if e.Published != "" {
+		t, err := time.Parse(time.RFC3339, e.Published)
+		if err != nil {
+			return nil, err
+		}
+		pub.Time = t.UTC()
+		pub.Valid = true
+	}
+	summary := e.Details
+	if summary == "" {
+		summary = e.Summary
+	}
+	vector, score := e.Vector()
+	for _, cve := range e.Cves() {
+		c := vars.CatalogEntry{Cve: cve, Summary: summary, Published: pub, LastModified: mod.UTC(), CvssScore: score}
+		if vector != "" {
+			c.CvssVector.String = vector
+			c.CvssVector.Valid = true
+		}
+		if e.DatabaseSpecific != nil {
+			c.Cwes = e.DatabaseSpecific.CweIDs
+		}
+		for _, ref := range e.References {
+			if ref.URL != "" && !vars.ContainsString(c.References, ref.URL) {
+				c.References = append(c.References, ref.URL)
+			}
+		}
+		entries = append(entries, &c)
+	}
+	return entries, nil
+}
+
+// FromVulnerability returns the OSV record of the vulnerability. The CVEs are the aliases and the vectors are the
+// severity. The vulnerable packages found on the affected systems are the affected packages, with a range per
+// version the scanners reported them fixed in; purls maps a package name to its package URL, from which the
+// ecosystem is derived. OSV packages need an ecosystem, so a package without one only lists its versions and
+// keeps its name and fixed versions in the database specific fields. The record is modified at the given time, as
+// VARS doesn't track the changes of a vulnerability.
+func FromVulnerability(v *vars.Vulnerability, vectors []string, purls map[string]string, modified time.Time) *Entry {
+	published := v.Dates.Initiated
+	if v.Dates.Published.Valid {
+		published = v.Dates.Published.Time
+	}
+	e := Entry{
+		SchemaVersion: SchemaVersion,
+		ID:            "VARS-" + strconv.FormatInt(v.ID, 10),
+		Modified:      formatDate(modified),
+		Published:     formatDate(published),
+		Summary:       v.Name,
+		Details:       v.Summary,
+	}
+	for _, cve := range v.Cves {
+		e.Aliases = append(e.Aliases, strings.ToUpper(cve))
+	}
+	for _, vector := range vectors {
+		t := CvssV2
+		switch {
+		case strings.HasPrefix(vector, "CVSS:3."):
+			t = CvssV3
+		case strings.HasPrefix(vector, "CVSS:4."):
+			t = CvssV4
+		}
+		e.Severity = append(e.Severity, &Severity{Type: t, Score: vector})
+	}
+
+	// Group the installed and fixed versions of the vulnerable packages of the affected systems by name
+	installed := make(map[string][]string)
+	fixed := make(map[string][]string)
+	var names []string
+	for _, a := range v.AffSystems {
+		for _, p := range a.Packages {
+			if _, ok := installed[p.Package]; !ok {
+				installed[p.Package] = []string{}
+				names = append(names, p.Package)
+			}
+			if p.Installed != "" && !vars.ContainsString(installed[p.Package], p.Installed) {
+				installed[p.Package] = append(installed[p.Package], p.Installed)
+			}
+			if p.Fixed != "" && !vars.ContainsString(fixed[p.Package], p.Fixed) {
+				fixed[p.Package] = append(fixed[p.Package], p.Fixed)
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sort.Strings(installed[name])
+		sort.Strings(fixed[name])
+		aff := Affected{Versions: installed[name]}
+		purl := purlWithoutVersion(purls[name])
+		if eco := EcosystemFromPurl(purl); eco != "" {
+			aff.Package = &Package{Ecosystem: eco, Name: name, Purl: purl}
+			for _, f := range fixed[name] {
+				aff.Ranges = append(aff.Ranges, &Range{Type: "ECOSYSTEM", Events: []*Event{{Introduced: "0"}, {Fixed: f}}})
+			}
+		} else {
+			aff.DatabaseSpecific = &AffectedSpecific{Package: name, Fixed: fixed[name]}
+		}
+		e.Affected = append(e.Affected, &aff)
+	}
+
+	for _, cve := range e.Aliases {
+		e.References = append(e.References, &Reference{Type: "ADVISORY", URL: "https://nvd.nist.gov/vuln/detail/" + cve})
+	}
+	for _, ref := range v.References {
+		e.References = append(e.References, &Reference{Type: "WEB", URL: ref})
+	}
+	if len(v.Cwes) > 0 || v.Mitigation != "" {
+		e.DatabaseSpecific = &DatabaseSpecific{CweIDs: v.Cwes, Mitigation: v.Mitigation}
+	}
+	return &e
+}
+
+// BaseScore calculates the base score of a CVSS v3.x or v2 vector. False is returned if the vector can't be
+// parsed.
+func BaseScore(vector string) (float32, bool) {
+	metrics := make(map[string]string)
+	parts := strings.Split(vector, "/")
+	v3 := strings.HasPrefix(vector, "CVSS:3.")
+	if v3 {
+		parts = parts[1:]
+	}
+	for _, p := range parts {
+		kv := strings.SplitN(p, ":", 2)
+		if len(kv) != 2 {
+			return 0, false
+		}
+		metrics[kv[0]] = kv[1]
+	}
+	if v3 {
+		return cvss3Score(metrics)
+	}
+	return cvss2Score(metrics)
+}
+
+// cvss2Score calculates the CVSS v2 base score of the metrics.
+func cvss2Score(m map[string]string) (float32, bool) {
+	weights := []map[string]float64{
+		{"L": 0.395, "A": 0.646, "N": 1},
+		{"H": 0.35, "M": 0.61, "L": 0.71},
+		{"M": 0.45, "S": 0.56, "N": 0.704},
+		{"N": 0, "P": 0.275, "C": 0.66},
+		{"N": 0, "P": 0.275, "C": 0.66},
+		{"N": 0, "P": 0.275, "C": 0.66},
+	}
+	var w [6]float64
+	for i, name := range []string{"AV", "AC", "Au", "C", "I", "A"} {
+		var ok bool
+		if w[i], ok = weights[i][m[name]]; !ok {
+			return 0, false
+		}
+	}
+	impact := 10.41 * (1 - (1-w[3])*(1-w[4])*(1-w[5]))
+	exploitability := 20 * w[0] * w[1] * w[2]
+	if impact == 0 {
+		return 0, true
+	}
+	score := (0.6*impact + 0.4*exploitability - 1.5) * 1.176
+	return float32(math.Floor(score*10+0.5) / 10), true
+}
+
+// cvss3Score calculates the CVSS v3.x base score of the metrics.
+func cvss3Score(m map[string]string) (float32, bool) {
+	changed := m["S"] == "C"
+	if !changed && m["S"] != "U" {
+		return 0, false
+	}
+	pr := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	if changed {
+		pr = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+	}
+	cia := map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+	weights := []map[string]float64{
+		{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		{"L": 0.77, "H": 0.44},
+		pr,
+		{"N": 0.85, "R": 0.62},
+		cia, cia, cia,
+	}
+	var w [7]float64
+	for i, name := range []string{"AV", "AC", "PR", "UI", "C", "I", "A"} {
+		var ok bool
+		if w[i], ok = weights[i][m[name]]; !ok {
+			return 0, false
+		}
+	}
+	iss := 1 - (1-w[4])*(1-w[5])*(1-w[6])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	score := impact + 8.22*w[0]*w[1]*w[2]*w[3]
+	if changed {
+		score *= 1.08
+	}
+	return float32(roundUp(math.Min(score, 10))), true
+}
+
+// formatDate formats the date as RFC 3339 in UTC.
+func formatDate(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// purlWithoutVersion returns the package URL without its version, qualifiers and subpath, as OSV expects.
+func purlWithoutVersion(purl string) string {
+	if i := strings.IndexAny(purl, "?#"); i >= 0 {
+		purl = purl[:i]
+	}
+	if i := strings.LastIndex(purl, "@"); i >= 0 {
+		purl = purl[:i]
+	}
+	return purl
+}
+
+// roundUp rounds up to one decimal as defined by CVSS v3.1.
+func roundUp(f float64) float64 {
+	i := int64(math.Floor(f*100000 + 0.5))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return (math.Floor(float64(i)/10000) + 1) / 10
+}
@@ -0,0 +1,354 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package pdf writes simple text documents (headings, paragraphs, label/value fields and tables) as PDF. Only the
+// standard Helvetica fonts are used, so no font is embedded, and text is limited to the WinAnsi character set.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Layout of the US Letter pages, in points.
+const (
+	pageWidth  = 612
+	pageHeight = 792
+	margin     = 54
+	textWidth  = pageWidth - 2*margin
+	cellPad    = 4
+)
+
+// Fonts of the documents.
+const (
+	regular = iota
+	bold
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold"}
+
+// widths holds the widths of the characters 32 to 126 of the fonts, in thousandths of the font size.
+var widths = [][]int{
+	{278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, 556, 556, 556, 556, 556, 556,
+		556, 556, 556, 556, 278, 278, 584, 584, 584, 556, 1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667,
+		556, 833, 722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, 333, 556,
+		556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, 556, 556, 333, 500, 278, 556, 500, 722,
+		500, 500, 500, 334, 260, 334, 584},
+	{278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, 556, 556, 556, 556, 556, 556,
+		556, 556, 556, 556, 333, 333, 584, 584, 584, 611, 975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722,
+		611, 833, 722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, 333, 556,
+		611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, 611, 611, 389, 556, 333, 611, 556, 778,
+		556, 556, 500, 389, 280, 389, 584},
+}
+
+// winAnsi maps the characters outside of Latin-1 that WinAnsiEncoding has to their codes.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96,
+	'—': 0x97, '™': 0x99,
+}
+
+// Document is a PDF document being written. The methods add content below the previous content, starting new
+// pages as needed.
+type Document struct {
+	title   string
+	created time.Time
+	pages   []*bytes.Buffer
+	y       float64 // Baseline of the next line on the current page
+}
+
+// New returns a document starting with the title.
+func New(title string) *Document {
+	d := Document{title: title, created: time.Now()}
+	d.newPage()
+	for _, line := range wrap(title, bold, 18, textWidth) {
+		d.line(margin, bold, 18, line)
+		d.y -= 22
+	}
+	d.y -= 6
+	return &d
+}
+
+// Heading adds a section heading with a rule under it.
+func (d *Document) Heading(s string) {
+	d.ensure(50)
+	d.y -= 10
+	d.line(margin, bold, 13, s)
+	fmt.Fprintf(d.page(), "0.6 G 0.5 w %d %.2f m %d %.2f l S 0 G\n", margin, d.y-4, pageWidth-margin, d.y-4)
+	d.y -= 20
+}
+
+// Paragraph adds the text, wrapped to the width of the page. Line breaks of the text are kept.
+func (d *Document) Paragraph(s string) {
+	for _, line := range wrap(s, regular, 10, textWidth) {
+		d.ensure(13)
+		d.line(margin, regular, 10, line)
+		d.y -= 13
+	}
+	d.y -= 5
+}
+
+// Fields adds the label and value pairs, with the labels in bold on the left.
+func (d *Document) Fields(fields [][2]string) {
+	const labelWidth = 130
+	for _, f := range fields {
+		lines := wrap(f[1], regular, 10, textWidth-labelWidth)
+		for i, line := range lines {
+			d.ensure(13)
+			if i == 0 {
+				d.line(margin, bold, 10, f[0])
+			}
+			d.line(margin+labelWidth, regular, 10, line)
+			d.y -= 13
+		}
+	}
+	d.y -= 5
+}
+
+// Table adds a table with the header and rows. The widths are the fractions of the width of the page given to the
+// columns. The header is repeated on each page the table spans, and rows too long for a page are split.
+func (d *Document) Table(cols []float64, header []string, rows [][]string) {
+	const leading = 11
+	split := func(cells []string, font int) ([][]string, int) {
+		var lines [][]string
+		n := 0
+		for i, cell := range cells {
+			lines = append(lines, wrap(cell, font, 9, cols[i]*textWidth-2*cellPad))
+			if len(lines[i]) > n {
+				n = len(lines[i])
+			}
+		}
+		return lines, n
+	}
+	// draw adds a row of n lines taken from the lines of the cells, shaded if it is in bold, and returns the lines
+	// that are left.
+	draw := func(lines [][]string, n, font int) [][]string {
+		h := float64(n*leading + 2*cellPad)
+		top := d.y + 9
+		if font == bold {
+			fmt.Fprintf(d.page(), "0.9 g %d %.2f %d %.2f re f 0 g\n", margin, top-h, textWidth, h)
+		}
+		fmt.Fprintf(d.page(), "0.75 G 0.5 w %d %.2f m %d %.2f l S 0 G\n", margin, top-h, pageWidth-margin, top-h)
+		x := float64(margin)
+		rest := make([][]string, len(lines))
+		for i, cell := range lines {
+			for j := 0; j < n && j < len(cell); j++ {
+				d.linef(x+cellPad, d.y-cellPad-float64(j*leading), font, 9, cell[j])
+			}
+			if n < len(cell) {
+				rest[i] = cell[n:]
+			}
+			x += cols[i] * textWidth
+		}
+		d.y -= h
+		return rest
+	}
+	fits := func() int {
+		return int((d.y - margin - 20 - 2*cellPad) / leading)
+	}
+
+	head, hn := split(header, bold)
+	d.ensure(float64((hn+1)*leading + 4*cellPad + 10))
+	draw(head, hn, bold)
+	for _, cells := range rows {
+		lines, n := split(cells, regular)
+		fresh := false
+		for n > 0 {
+			// Rows that fit on a page are moved to the next page rather than split
+			fit := fits()
+			if fit < n && !fresh && (fit < 1 || n <= int(pageHeight-2*margin-20-4*cellPad)/leading-hn) {
+				d.newPage()
+				draw(head, hn, bold)
+				fresh = true
+				continue
+			}
+			fresh = false
+			if fit > n {
+				fit = n
+			} else if fit < 1 {
+				fit = 1
+			}
+			lines = draw(lines, fit, regular)
+			n -= fit
+		}
+	}
+	d.y -= 10
+}
+
+// WriteTo writes the document to w, adding the title and the page number at the bottom of each page.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-5 are the catalog, the page tree, the fonts and the info, then each page and its contents
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 6+2*i))
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, name := range fontNames {
+		obj("<< /Type /Font /Subtype /Type1 /BaseFont /" + name + " /Encoding /WinAnsiEncoding >>")
+	}
+	obj(fmt.Sprintf("<< /Title (%s) /Producer (VARS) /CreationDate (D:%s) >>", escape(d.title), d.created.UTC().Format("20060102150405Z")))
+	for i, page := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		fmt.Fprintf(page, "0.4 g\n")
+		writeText(page, margin, margin-20, regular, 8, d.title)
+		writeText(page, pageWidth-margin-textWidthOf(footer, regular, 8), margin-20, regular, 8, footer)
+
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(page.Bytes())
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F0 3 0 R /F1 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 7+2*i))
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// ensure starts a new page if there is less than h points left on the current one.
+func (d *Document) ensure(h float64) {
+	if d.y-h < margin+20 {
+		d.newPage()
+	}
+}
+
+// line writes a line of text at the current baseline.
+func (d *Document) line(x float64, font int, size float64, s string) {
+	writeText(d.page(), x, d.y, font, size, s)
+}
+
+// linef writes a line of text at the given position.
+func (d *Document) linef(x, y float64, font int, size float64, s string) {
+	writeText(d.page(), x, y, font, size, s)
+}
+
+// newPage starts a new page.
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+// page returns the content stream of the current page.
+func (d *Document) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// encode converts the text to WinAnsi, replacing the characters it doesn't have with a question mark.
+func encode(s string) []byte {
+	var b []byte
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			b = append(b, ' ')
+		case r >= 32 && r < 127, r >= 0xa0 && r <= 0xff:
+			b = append(b, byte(r))
+		case winAnsi[r] != 0:
+			b = append(b, winAnsi[r])
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// escape encodes the text as the contents of a PDF string.
+func escape(s string) string {
+	var b bytes.Buffer
+	for _, c := range encode(s) {
+		if c == '\\' || c == '(' || c == ')' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// textWidthOf returns the width of the text in the font, in points.
+func textWidthOf(s string, font int, size float64) float64 {
+	w := 0
+	for _, c := range encode(s) {
+		if c >= 32 && c < 127 {
+			w += widths[font][c-32]
+		} else {
+			w += 556
+		}
+	}
+	return float64(w) * size / 1000
+}
+
+// wrap splits the text into lines no wider than width. Line breaks of the text are kept, and words that are too
+// long for a line are split.
+func wrap(s string, font int, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for textWidthOf(word, font, size) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				n := len([]rune(word)) - 1
+				for n > 1 && textWidthOf(string([]rune(word)[:n]), font, size) > width {
+					n--
+				}
+				lines = append(lines, string([]rune(word)[:n]))
+				word = string([]rune(word)[n:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case textWidthOf(line+" "+word, font, size) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// writeText writes a line of text at the position to the content stream.
+func writeText(page *bytes.Buffer, x, y float64, font int, size float64, s string) {
+	fmt.Fprintf(page, "BT /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
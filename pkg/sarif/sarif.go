@@ -0,0 +1,183 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package sarif converts vulnerabilities to the SARIF 2.1.0 format read by code scanning and developer platforms.
+package sarif
+
+import (
+	"strconv"
+
+	"github.com/cbelk/vars"
+)
+
+const (
+	// Schema is the URI of the SARIF 2.1.0 JSON schema.
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+	// Version is the SARIF version of the logs.
+	Version = "2.1.0"
+)
+
+// Log is a SARIF log file.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []*Run `json:"runs"`
+}
+
+// Run holds the rules and results of one run of a tool.
+type Run struct {
+	Tool    Tool      `json:"tool"`
+	Results []*Result `json:"results"`
+}
+
+// Tool describes the tool that produced a run.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver describes the tool component that produced a run and the rules it checks.
+type Driver struct {
+	Name           string  `json:"name"`
+	InformationURI string  `json:"informationUri,omitempty"`
+	Rules          []*Rule `json:"rules"`
+}
+
+// Message is a SARIF text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Rule describes a vulnerability.
+type Rule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *Message               `json:"shortDescription,omitempty"`
+	FullDescription      *Message               `json:"fullDescription,omitempty"`
+	Help                 *Message               `json:"help,omitempty"`
+	HelpURI              string                 `json:"helpUri,omitempty"`
+	DefaultConfiguration *Configuration         `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Configuration holds the default level of the results of a rule.
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+// Result is a vulnerability found on a system.
+type Result struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             Message                `json:"message"`
+	Locations           []*Location            `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Location is where a result was found. Systems are logical locations.
+type Location struct {
+	LogicalLocations []*LogicalLocation `json:"logicalLocations"`
+}
+
+// LogicalLocation is a named location that isn't a file, such as a system.
+type LogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// FromVulnerabilities returns a log with a single VARS run. Each vulnerability is a rule with its CVEs and CVSS
+// score in the properties and its mitigation as the help text, and each of its affected systems that is not
+// mitigated is a result. The vulnerabilities must have their affected systems loaded.
+func FromVulnerabilities(vulns []*vars.Vulnerability) *Log {
+	run := Run{
+		Tool:    Tool{Driver: Driver{Name: "VARS", InformationURI: "https://github.com/cbelk/vars", Rules: []*Rule{}}},
+		Results: []*Result{},
+	}
+	for _, v := range vulns {
+		rule := toRule(v)
+		index := len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		for _, a := range v.AffSystems {
+			if a.Mitigated {
+				continue
+			}
+			run.Results = append(run.Results, &Result{
+				RuleID:    rule.ID,
+				RuleIndex: index,
+				Level:     rule.DefaultConfiguration.Level,
+				Message:   Message{Text: a.Sys.Name + " is affected by " + v.Name},
+				Locations: []*Location{{LogicalLocations: []*LogicalLocation{{
+					Name: a.Sys.Name,
+					Kind: "module",
+				}}}},
+				PartialFingerprints: map[string]string{"varsAffected/v1": strconv.FormatInt(v.ID, 10) + ":" + strconv.FormatInt(a.Sys.ID, 10)},
+				Properties: map[string]interface{}{
+					"system":     a.Sys.Name,
+					"systemType": a.Sys.Type,
+					"os":         a.Sys.OpSys,
+				},
+			})
+		}
+	}
+	return &Log{Schema: Schema, Version: Version, Runs: []*Run{&run}}
+}
+
+// level returns the SARIF level of a CVSS score: error from 7.0, warning from 4.0 and note below.
+func level(cvss float32) string {
+	switch {
+	case cvss >= 7:
+		return "error"
+	case cvss >= 4:
+		return "warning"
+	}
+	return "note"
+}
+
+// toRule returns the rule describing the vulnerability.
+func toRule(v *vars.Vulnerability) *Rule {
+	rule := Rule{
+		ID:                   "VARS-" + strconv.FormatInt(v.ID, 10),
+		Name:                 v.Name,
+		ShortDescription:     &Message{Text: v.Name},
+		DefaultConfiguration: &Configuration{Level: level(v.Cvss)},
+		Properties: map[string]interface{}{
+			"cvss":              v.Cvss,
+			"security-severity": strconv.FormatFloat(float64(v.Cvss), 'f', 1, 32),
+			"tags":              append([]string{"security"}, v.Cves...),
+		},
+	}
+	if len(v.Cves) > 0 {
+		rule.Properties["cves"] = v.Cves
+	}
+	if len(v.Cwes) > 0 {
+		rule.Properties["cwes"] = v.Cwes
+	}
+	if v.Summary != "" {
+		rule.FullDescription = &Message{Text: v.Summary}
+	}
+	if v.Mitigation != "" {
+		rule.Help = &Message{Text: v.Mitigation}
+	}
+	if len(v.References) > 0 {
+		rule.HelpURI = v.References[0]
+	}
+	return &rule
+}
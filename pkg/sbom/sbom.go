@@ -0,0 +1,214 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package sbom reads the components listed by CycloneDX (JSON or XML) and SPDX (JSON) software bills of materials.
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cbelk/vars"
+)
+
+const (
+	// CycloneDX is the format of CycloneDX documents.
+	CycloneDX = "cyclonedx"
+	// SPDX is the format of SPDX documents.
+	SPDX = "spdx"
+)
+
+var cveRE = regexp.MustCompile(`(?i)CVE-\d{4}-\d{4,}`)
+
+// ErrUnknownFormat is returned when the document is neither a CycloneDX nor an SPDX document.
+var ErrUnknownFormat = errors.New("sbom: Parse: The file is not a CycloneDX or SPDX document")
+
+type cdxComponent struct {
+	BomRef     string         `json:"bom-ref" xml:"bom-ref,attr"`
+	Group      string         `json:"group" xml:"group"`
+	Name       string         `json:"name" xml:"name"`
+	Version    string         `json:"version" xml:"version"`
+	Purl       string         `json:"purl" xml:"purl"`
+	Components []cdxComponent `json:"components" xml:"components>component"`
+}
+
+type cdxVulnerability struct {
+	ID      string `json:"id" xml:"id"`
+	Affects []struct {
+		Ref string `json:"ref" xml:"ref"`
+	} `json:"affects" xml:"affects>target"`
+}
+
+type cdxDocument struct {
+	BomFormat string `json:"bomFormat"`
+	Metadata  struct {
+		Component cdxComponent `json:"component" xml:"component"`
+	} `json:"metadata" xml:"metadata"`
+	Components      []cdxComponent     `json:"components" xml:"components>component"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities" xml:"vulnerabilities>vulnerability"`
+}
+
+type spdxDocument struct {
+	SpdxVersion string `json:"spdxVersion"`
+	Name        string `json:"name"`
+	Packages    []struct {
+		Name         string `json:"name"`
+		VersionInfo  string `json:"versionInfo"`
+		ExternalRefs []struct {
+			ReferenceCategory string `json:"referenceCategory"`
+			ReferenceType     string `json:"referenceType"`
+			ReferenceLocator  string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+// Parse reads a CycloneDX JSON or XML document or an SPDX JSON document. The format is detected from the contents.
+// Components listed more than once are returned once.
+func Parse(r io.Reader) (*vars.Sbom, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '<' {
+		var doc cdxDocument
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return fromCycloneDX(&doc), nil
+	}
+
+	var probe struct {
+		BomFormat   string `json:"bomFormat"`
+		SpdxVersion string `json:"spdxVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	switch {
+	case probe.BomFormat == "CycloneDX":
+		var doc cdxDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return fromCycloneDX(&doc), nil
+	case probe.SpdxVersion != "":
+		var doc spdxDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		return fromSPDX(&doc), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ParseFile reads the SBOM at path.
+func ParseFile(path string) (*vars.Sbom, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// addComponent appends the component to the SBOM unless it is already listed, in which case its CVEs are merged
+// into the listed one.
+func addComponent(bom *vars.Sbom, seen map[string]*vars.Component, c *vars.Component) {
+	key := c.Name + "@" + c.Version
+	if s, ok := seen[key]; ok {
+		for _, cve := range c.Cves {
+			if !vars.ContainsString(s.Cves, cve) {
+				s.Cves = append(s.Cves, cve)
+			}
+		}
+		if s.Purl == "" {
+			s.Purl = c.Purl
+		}
+		return
+	}
+	seen[key] = c
+	bom.Components = append(bom.Components, c)
+}
+
+// fromCycloneDX returns the components of the CycloneDX document, including nested components. The CVEs of the
+// vulnerabilities section are attached to the components they affect.
+func fromCycloneDX(doc *cdxDocument) *vars.Sbom {
+	bom := vars.Sbom{Format: CycloneDX, Name: strings.TrimSpace(doc.Metadata.Component.Name + " " + doc.Metadata.Component.Version)}
+	cves := make(map[string][]string)
+	for _, v := range doc.Vulnerabilities {
+		id := strings.ToUpper(v.ID)
+		if !cveRE.MatchString(id) {
+			continue
+		}
+		for _, a := range v.Affects {
+			cves[a.Ref] = append(cves[a.Ref], id)
+		}
+	}
+	seen := make(map[string]*vars.Component)
+	var walk func(comps []cdxComponent)
+	walk = func(comps []cdxComponent) {
+		for _, c := range comps {
+			name := c.Name
+			if c.Group != "" {
+				name = c.Group + ":" + c.Name
+			}
+			if name != "" {
+				addComponent(&bom, seen, &vars.Component{Name: name, Version: c.Version, Purl: c.Purl, Cves: cves[c.BomRef]})
+			}
+			walk(c.Components)
+		}
+	}
+	walk(doc.Components)
+	return &bom
+}
+
+// fromSPDX returns the packages of the SPDX document as components. The purl is taken from the package manager
+// references, and CVEs from the security advisory references.
+func fromSPDX(doc *spdxDocument) *vars.Sbom {
+	bom := vars.Sbom{Format: SPDX, Name: doc.Name}
+	seen := make(map[string]*vars.Component)
+	for _, p := range doc.Packages {
+		if p.Name == "" {
+			continue
+		}
+		c := vars.Component{Name: p.Name, Version: p.VersionInfo}
+		for _, ref := range p.ExternalRefs {
+			switch {
+			case ref.ReferenceType == "purl":
+				c.Purl = ref.ReferenceLocator
+			case ref.ReferenceCategory == "SECURITY" && ref.ReferenceType == "advisory":
+				cve := strings.ToUpper(cveRE.FindString(ref.ReferenceLocator))
+				if cve != "" && !vars.ContainsString(c.Cves, cve) {
+					c.Cves = append(c.Cves, cve)
+				}
+			}
+		}
+		addComponent(&bom, seen, &c)
+	}
+	return &bom
+}
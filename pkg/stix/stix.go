@@ -0,0 +1,300 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package stix converts vulnerabilities to STIX 2.1 bundles.
+package stix
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cbelk/vars"
+)
+
+// TLP levels of the marking policy, from the least to the most restricted.
+const (
+	TlpWhite = iota
+	TlpGreen
+	TlpAmber
+	TlpRed
+)
+
+// Fields of the vulnerabilities that are restricted by the marking policy.
+const (
+	FieldCvss       = "cvss"       // CVSS and corporate scores
+	FieldNotes      = "notes"      // Notes marked public
+	FieldSystems    = "systems"    // Affected systems and their relationships
+	FieldSysDetails = "sysdetails" // Operating system, location, description and addresses of the systems
+	FieldTest       = "test"       // Test for the vulnerability
+	FieldExploit    = "exploit"    // Exploit and whether the vulnerability is exploitable
+	FieldTickets    = "tickets"    // Internal tickets
+)
+
+// Policy maps the restricted fields to the lowest TLP level a bundle must be marked with to include them. The
+// other fields (name, summary, CVEs, CWEs, references and mitigation) are always included.
+var Policy = map[string]int{
+	FieldCvss:       TlpGreen,
+	FieldNotes:      TlpGreen,
+	FieldSystems:    TlpAmber,
+	FieldSysDetails: TlpRed,
+	FieldTest:       TlpRed,
+	FieldExploit:    TlpRed,
+	FieldTickets:    TlpRed,
+}
+
+// tlpNames maps the TLP names to the levels. CLEAR is the TLP 2.0 name of WHITE.
+var tlpNames = map[string]int{"white": TlpWhite, "clear": TlpWhite, "green": TlpGreen, "amber": TlpAmber, "red": TlpRed}
+
+// tlpMarkings are the marking definitions of the TLP levels predefined by STIX 2.1.
+var tlpMarkings = []*Object{
+	{ID: "marking-definition--613f2e26-407d-48c7-9eca-b8e91df99dc9", Name: "TLP:WHITE", tlp: "white"},
+	{ID: "marking-definition--34098fce-860f-48ae-8e50-ebd3cc5e41da", Name: "TLP:GREEN", tlp: "green"},
+	{ID: "marking-definition--f88d31f6-486f-44da-b317-01333bde0b82", Name: "TLP:AMBER", tlp: "amber"},
+	{ID: "marking-definition--5e57c739-391a-4eb3-b6be-7d15ca92d5ed", Name: "TLP:RED", tlp: "red"},
+}
+
+// namespace is the UUID namespace of the IDs of the objects, which are derived from the VARS IDs so that the
+// objects keep their ID across exports.
+var namespace = [16]byte{0x7c, 0x0e, 0xde, 0x83, 0x38, 0x54, 0x4a, 0xfe, 0x94, 0xd2, 0xd1, 0x90, 0x48, 0x45, 0xeb, 0x15}
+
+// Bundle is a STIX bundle.
+type Bundle struct {
+	Type    string    `json:"type"`
+	ID      string    `json:"id"`
+	Objects []*Object `json:"objects"`
+}
+
+// Object is a STIX domain, relationship or marking definition object. Only the properties of the object type are
+// set.
+type Object struct {
+	Type               string               `json:"type"`
+	SpecVersion        string               `json:"spec_version"`
+	ID                 string               `json:"id"`
+	CreatedByRef       string               `json:"created_by_ref,omitempty"`
+	Created            string               `json:"created"`
+	Modified           string               `json:"modified,omitempty"`
+	Name               string               `json:"name,omitempty"`
+	Description        string               `json:"description,omitempty"`
+	IdentityClass      string               `json:"identity_class,omitempty"`
+	InfrastructureType []string             `json:"infrastructure_types,omitempty"`
+	Content            string               `json:"content,omitempty"`
+	ObjectRefs         []string             `json:"object_refs,omitempty"`
+	RelationshipType   string               `json:"relationship_type,omitempty"`
+	SourceRef          string               `json:"source_ref,omitempty"`
+	TargetRef          string               `json:"target_ref,omitempty"`
+	DefinitionType     string               `json:"definition_type,omitempty"`
+	Definition         map[string]string    `json:"definition,omitempty"`
+	ExternalReferences []*ExternalReference `json:"external_references,omitempty"`
+	ObjectMarkingRefs  []string             `json:"object_marking_refs,omitempty"`
+
+	// VARS specific properties
+	Cvss        float32  `json:"x_vars_cvss,omitempty"`
+	CorpScore   float32  `json:"x_vars_corporate_score,omitempty"`
+	Test        string   `json:"x_vars_test,omitempty"`
+	Exploit     string   `json:"x_vars_exploit,omitempty"`
+	Exploitable *bool    `json:"x_vars_exploitable,omitempty"`
+	Tickets     []string `json:"x_vars_tickets,omitempty"`
+	Addresses   []string `json:"x_vars_addresses,omitempty"`
+
+	tlp string
+}
+
+// ExternalReference is a reference to a CVE, a CWE or a URL.
+type ExternalReference struct {
+	SourceName string `json:"source_name"`
+	ExternalID string `json:"external_id,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+// Options holds the inputs of a bundle besides the vulnerabilities.
+type Options struct {
+	Tlp      string                 // TLP level of the bundle, see Policy
+	Producer string                 // Name of the organization producing the bundle
+	Notes    map[int64][]*vars.Note // Notes to publish with the vulnerabilities, by vulnid
+	Date     time.Time              // Generation date
+}
+
+// ParseTlp returns the level of the TLP name (white, clear, green, amber or red), ignoring the case and a TLP:
+// prefix.
+func ParseTlp(name string) (int, error) {
+	level, ok := tlpNames[strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "tlp:")]
+	if !ok {
+		return 0, errors.New("stix: ParseTlp: Unknown TLP level " + name)
+	}
+	return level, nil
+}
+
+// FromVulnerabilities returns the STIX bundle of the vulnerabilities marked with the TLP level of the options. Each
+// vulnerability is a vulnerability object with the CVEs and CWEs as external references, and its mitigation is a
+// course-of-action that mitigates it. If the level allows it, the affected systems are infrastructure objects that
+// have the vulnerability. The producer is the identity that created the objects. The fields of the policy above
+// the level are left out. The vulnerabilities must have their affected systems loaded.
+func FromVulnerabilities(vulns []*vars.Vulnerability, opts *Options) (*Bundle, error) {
+	level, err := ParseTlp(opts.Tlp)
+	if err != nil {
+		return nil, err
+	}
+	allowed := func(field string) bool { return level >= Policy[field] }
+	now := formatDate(opts.Date)
+	m := *tlpMarkings[level]
+	marking := &m
+	marking.Type = "marking-definition"
+	marking.SpecVersion = "2.1"
+	marking.Created = "2017-01-20T00:00:00.000Z"
+	marking.DefinitionType = "tlp"
+	marking.Definition = map[string]string{"tlp": marking.tlp}
+	markings := []string{marking.ID}
+
+	producer := &Object{
+		Type:              "identity",
+		SpecVersion:       "2.1",
+		ID:                id("identity", "producer:"+opts.Producer),
+		Created:           now,
+		Modified:          now,
+		Name:              opts.Producer,
+		IdentityClass:     "organization",
+		ObjectMarkingRefs: markings,
+	}
+	bundle := Bundle{Type: "bundle", ID: id("bundle", now), Objects: []*Object{marking, producer}}
+	add := func(o *Object) {
+		o.SpecVersion = "2.1"
+		o.CreatedByRef = producer.ID
+		o.ObjectMarkingRefs = markings
+		if o.Modified == "" {
+			o.Modified = now
+		}
+		bundle.Objects = append(bundle.Objects, o)
+	}
+	relate := func(rel, src, tgt, desc string) {
+		add(&Object{Type: "relationship", ID: id("relationship", rel+":"+src+":"+tgt), Created: now, RelationshipType: rel,
+			SourceRef: src, TargetRef: tgt, Description: desc})
+	}
+
+	// The systems are added once all the vulnerabilities that affect them are known
+	systems := make(map[int64]*Object)
+	var sids []int64
+	var has []*Object
+	for _, v := range vulns {
+		vid := strconv.FormatInt(v.ID, 10)
+		vo := &Object{Type: "vulnerability", ID: id("vulnerability", "vuln:"+vid), Created: formatDate(v.Dates.Initiated), Name: v.Name, Description: v.Summary}
+		cves := append([]string{}, v.Cves...)
+		sort.Strings(cves)
+		for _, cve := range cves {
+			vo.ExternalReferences = append(vo.ExternalReferences, &ExternalReference{SourceName: "cve", ExternalID: strings.ToUpper(cve)})
+		}
+		for _, cwe := range v.Cwes {
+			vo.ExternalReferences = append(vo.ExternalReferences, &ExternalReference{SourceName: "cwe", ExternalID: cwe})
+		}
+		for _, ref := range v.References {
+			vo.ExternalReferences = append(vo.ExternalReferences, &ExternalReference{SourceName: "url", URL: ref})
+		}
+		if allowed(FieldCvss) {
+			vo.Cvss, vo.CorpScore = v.Cvss, v.CorpScore
+		}
+		if allowed(FieldTest) {
+			vo.Test = v.Test
+		}
+		if allowed(FieldExploit) {
+			vo.Exploit = v.Exploit.String
+			if v.Exploitable.Valid {
+				vo.Exploitable = &v.Exploitable.Bool
+			}
+		}
+		if allowed(FieldTickets) {
+			vo.Tickets = v.Tickets
+		}
+		add(vo)
+
+		if v.Mitigation != "" {
+			co := &Object{Type: "course-of-action", ID: id("course-of-action", "mitigation:"+vid), Created: vo.Created, Name: "Mitigation of " + v.Name, Description: v.Mitigation}
+			add(co)
+			relate("mitigates", co.ID, vo.ID, "")
+		}
+		if allowed(FieldNotes) {
+			for _, n := range opts.Notes[v.ID] {
+				add(&Object{Type: "note", ID: id("note", "note:"+strconv.FormatInt(n.ID, 10)), Created: formatDate(n.Added), Content: n.Note, ObjectRefs: []string{vo.ID}})
+			}
+		}
+		if !allowed(FieldSystems) {
+			continue
+		}
+		for _, a := range v.AffSystems {
+			so, ok := systems[a.Sys.ID]
+			if !ok {
+				so = &Object{Type: "infrastructure", ID: id("infrastructure", "system:"+strconv.FormatInt(a.Sys.ID, 10)), Created: now, Name: a.Sys.Name, InfrastructureType: []string{"unknown"}}
+				if allowed(FieldSysDetails) {
+					so.Description = systemDescription(&a.Sys)
+					so.Addresses = a.Sys.Addresses
+				}
+				systems[a.Sys.ID] = so
+				sids = append(sids, a.Sys.ID)
+			}
+			status := "Not mitigated"
+			if a.Mitigated {
+				status = "Mitigated"
+			}
+			has = append(has, &Object{SourceRef: so.ID, TargetRef: vo.ID, Description: status})
+		}
+	}
+	sort.Slice(sids, func(i, j int) bool { return sids[i] < sids[j] })
+	for _, sid := range sids {
+		add(systems[sid])
+	}
+	for _, rel := range has {
+		relate("has", rel.SourceRef, rel.TargetRef, rel.Description)
+	}
+	return &bundle, nil
+}
+
+// formatDate formats the date as a STIX timestamp.
+func formatDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// id returns the STIX ID of the object type with a version 5 UUID derived from the name.
+func id(typ, name string) string {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+	u := h.Sum(nil)[:16]
+	u[6] = u[6]&0x0f | 0x50
+	u[8] = u[8]&0x3f | 0x80
+	s := hex.EncodeToString(u)
+	return typ + "--" + s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// systemDescription returns the description of the infrastructure object of the system.
+func systemDescription(sys *vars.System) string {
+	var lines []string
+	for _, f := range [][2]string{{"Type", sys.Type}, {"Operating system", sys.OpSys}, {"Location", sys.Location}} {
+		if f[1] != "" && f[1] != "unknown" {
+			lines = append(lines, f[0]+": "+f[1])
+		}
+	}
+	if sys.Description != "" {
+		lines = append(lines, sys.Description)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,174 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+// Package trivy reads the JSON output of the Trivy container image scanner.
+package trivy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cbelk/vars"
+)
+
+// Scanner is the name the Trivy findings are recorded under.
+const Scanner = "trivy"
+
+// SystemType is the type of the systems the scanned images are recorded as.
+const SystemType = "container-image"
+
+type report struct {
+	ArtifactName string   `json:"ArtifactName"`
+	Metadata     metadata `json:"Metadata"`
+	Results      []result `json:"Results"`
+}
+
+type metadata struct {
+	OS          *osInfo  `json:"OS"`
+	ImageID     string   `json:"ImageID"`
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+type osInfo struct {
+	Family string `json:"Family"`
+	Name   string `json:"Name"`
+}
+
+type result struct {
+	Target          string          `json:"Target"`
+	Vulnerabilities []vulnerability `json:"Vulnerabilities"`
+}
+
+type vulnerability struct {
+	VulnerabilityID  string          `json:"VulnerabilityID"`
+	PkgName          string          `json:"PkgName"`
+	InstalledVersion string          `json:"InstalledVersion"`
+	FixedVersion     string          `json:"FixedVersion"`
+	Title            string          `json:"Title"`
+	Description      string          `json:"Description"`
+	CVSS             map[string]cvss `json:"CVSS"`
+	References       []string        `json:"References"`
+}
+
+type cvss struct {
+	V2Score float32 `json:"V2Score"`
+	V3Score float32 `json:"V3Score"`
+}
+
+// Parse reads the JSON output of a Trivy image scan. The image is returned as the only host, named by its
+// repository and digest. A vulnerability reported for several packages is returned as one finding listing
+// the packages.
+func Parse(r io.Reader) (*vars.ScanReport, error) {
+	var rep report
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, err
+	}
+	if rep.ArtifactName == "" {
+		return nil, errors.New("trivy: Parse: The file is not a Trivy report")
+	}
+
+	host := vars.ScanHost{Name: imageName(&rep), Type: SystemType}
+	if rep.Metadata.OS != nil {
+		host.OpSys = strings.TrimSpace(rep.Metadata.OS.Family + " " + rep.Metadata.OS.Name)
+	}
+	findings := make(map[string]*vars.ScanFinding)
+	for _, res := range rep.Results {
+		for i := range res.Vulnerabilities {
+			v := &res.Vulnerabilities[i]
+			if v.VulnerabilityID == "" {
+				continue
+			}
+			f, ok := findings[v.VulnerabilityID]
+			if !ok {
+				f = toFinding(v)
+				findings[v.VulnerabilityID] = f
+				host.Findings = append(host.Findings, f)
+			}
+			f.Packages = append(f.Packages, &vars.AffectedPkg{Package: v.PkgName, Installed: v.InstalledVersion, Fixed: v.FixedVersion})
+		}
+	}
+	for _, f := range host.Findings {
+		f.Solution = vars.UpgradeSolution(f.Packages)
+	}
+	return &vars.ScanReport{Scanner: Scanner, Name: rep.ArtifactName, Hosts: []*vars.ScanHost{&host}}, nil
+}
+
+// ParseFile reads the Trivy JSON output at path.
+func ParseFile(path string) (*vars.ScanReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// imageName returns the repository and digest of the image. The image ID is used when the image has no repository
+// digest (e.g. it was never pushed), and the artifact name on its own when the scan wasn't of an image.
+func imageName(rep *report) string {
+	if len(rep.Metadata.RepoDigests) > 0 {
+		return rep.Metadata.RepoDigests[0]
+	}
+	name := rep.ArtifactName
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	if rep.Metadata.ImageID != "" {
+		return name + "@" + rep.Metadata.ImageID
+	}
+	return rep.ArtifactName
+}
+
+// toFinding converts the vulnerability into a ScanFinding. The NVD CVSSv3 score is preferred, then the CVSSv3 score
+// of any other source and then CVSSv2 scores.
+func toFinding(v *vulnerability) *vars.ScanFinding {
+	f := vars.ScanFinding{
+		PluginID:   v.VulnerabilityID,
+		Name:       v.VulnerabilityID,
+		Summary:    strings.TrimSpace(v.Description),
+		References: v.References,
+	}
+	if v.Title != "" {
+		f.Name = v.VulnerabilityID + ": " + strings.TrimSpace(v.Title)
+	}
+	if strings.HasPrefix(strings.ToUpper(v.VulnerabilityID), "CVE-") {
+		f.Cves = []string{strings.ToUpper(v.VulnerabilityID)}
+	}
+	if c, ok := v.CVSS["nvd"]; ok && c.V3Score > 0 {
+		f.Cvss = c.V3Score
+		return &f
+	}
+	for _, c := range v.CVSS {
+		if c.V3Score > f.Cvss {
+			f.Cvss = c.V3Score
+		}
+	}
+	if f.Cvss == 0 {
+		for _, c := range v.CVSS {
+			if c.V2Score > f.Cvss {
+				f.Cvss = c.V2Score
+			}
+		}
+	}
+	return &f
+}
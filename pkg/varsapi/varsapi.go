@@ -21,14 +21,78 @@
 package varsapi
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cbelk/vars"
+	"github.com/cbelk/vars/pkg/csaf"
+	"github.com/cbelk/vars/pkg/grype"
+	"github.com/cbelk/vars/pkg/nessus"
+	"github.com/cbelk/vars/pkg/nmap"
+	"github.com/cbelk/vars/pkg/nvd"
+	"github.com/cbelk/vars/pkg/openvas"
+	"github.com/cbelk/vars/pkg/osv"
+	"github.com/cbelk/vars/pkg/pdf"
+	"github.com/cbelk/vars/pkg/sarif"
+	"github.com/cbelk/vars/pkg/sbom"
+	"github.com/cbelk/vars/pkg/stix"
+	"github.com/cbelk/vars/pkg/trivy"
+	"github.com/cbelk/vars/pkg/xlsx"
 	"github.com/lib/pq"
 )
 
+// MentionRegexp matches a mention of an employee (@username) in a note. The username is the first submatch.
+var MentionRegexp = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9][A-Za-z0-9._-]*[A-Za-z0-9]|[A-Za-z0-9])`)
+
+// Regular expressions used by plainText to strip the Markdown of a note.
+var (
+	mdBlockMark = regexp.MustCompile(`^\s*(?:#{1,6}\s+|>\s?)`)
+	mdLinkMark  = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdSpanMark  = regexp.MustCompile("\\*\\*|__|`|\\*([^*\\s][^*]*)\\*")
+)
+
+// SystemsCsvColumns are the columns of the systems CSV, in the order ExportSystemsCsv writes them. The addresses
+// of a system are separated by semicolons.
+var SystemsCsvColumns = []string{"name", "type", "os", "location", "description", "state", "addresses"}
+
+// VulnerabilityColumns are the columns of the vulnerability list exports. Multi-valued fields are sorted and joined
+// with "; ", and dates are written as YYYY-MM-DD HH:MM:SS or left empty.
+var VulnerabilityColumns = []string{"ID", "Name", "CVEs", "CVSS", "Corporate Score", "EPSS", "Known Exploited",
+	"Published", "Initiated", "Mitigated", "Affected Systems", "Mitigated Systems", "Tickets", "Assignee"}
+
+// systemsCsvAliases maps other header names accepted by ImportSystemsCsv to the columns.
+var systemsCsvAliases = map[string]string{
+	"address":  "addresses",
+	"hostname": "name",
+	"opsys":    "os",
+	"sysname":  "name",
+	"system":   "name",
+	"systype":  "type",
+}
+
 // AddAffected adds a new vulnerability/system pair to the affected table
 func AddAffected(db *sql.DB, vid, sid int64) error {
 	//Start transaction and set rollback function
@@ -57,7 +121,79 @@ func AddAffected(db *sql.DB, vid, sid int64) error {
 	return nil
 }
 
-// AddCve adds the given cve to the impact table for vulnid
+// AddAttachment validates the size and type of data, stores it in the configured blob store and adds the
+// attachment metadata to VARS. If att.SysID is set the system must be affected by the vulnerability. The Size,
+// MimeType, Sha256, StoreKey, Added and ID fields of att are set.
+func AddAttachment(db *sql.DB, att *vars.Attachment, data []byte) error {
+	// Check the size and type of the contents
+	if int64(len(data)) > GetAttachMaxSize() {
+		return vars.NewErr(vars.AttachTooLarge, "VARS", "varsapi", "AddAttachment")
+	}
+	mtype := http.DetectContentType(data)
+	if !attachTypeAllowed(mtype) {
+		return vars.NewErr(vars.AttachTypeNotAllowed, "VARS", "varsapi", "AddAttachment")
+	}
+	sum := sha256.Sum256(data)
+	att.Size = int64(len(data))
+	att.MimeType = mtype
+	att.Sha256 = hex.EncodeToString(sum[:])
+	att.Added = time.Now()
+
+	store, err := vars.GetBlobStore()
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+			// Contents outside of the database are not removed by the rollback
+			if att.StoreKey != "" && !store.Transactional() {
+				store.Delete(nil, att.StoreKey)
+			}
+		}
+	}()
+
+	// The system must be one of the affected systems of the vulnerability
+	if att.SysID.Valid {
+		_, err = vars.GetAffectedMitigatedtx(tx, att.VulnID, att.SysID.Int64)
+		if vars.IsNoRowsError(err) {
+			return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", "AddAttachment", "The system is not affected by the vulnerability")
+		}
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+
+	// Store the contents
+	key, err := store.Put(tx, data)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	att.StoreKey = key
+
+	// Add attachment
+	err = vars.InsertAttachment(tx, att)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Commit the transaction. The contents are removed by the deferred rollback if the commit fails.
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	rollback = false
+	return nil
+}
+
+// AddCve adds the given cve to the impact table for vulnid. If the CVE is in the KEV catalog the vulnerability is
+// flagged as exploitable.
 func AddCve(db *sql.DB, vid int64, cve string) error {
 	//Start transaction and set rollback function
 	tx, err := db.Begin()
@@ -75,6 +211,10 @@ func AddCve(db *sql.DB, vid int64, cve string) error {
 	if !vars.IsNilErr(err) {
 		return err
 	}
+	_, err = matchKev(tx)
+	if !vars.IsNilErr(err) {
+		return err
+	}
 
 	// Commit the transaction
 	rollback = false
@@ -98,8 +238,12 @@ func AddEmployee(db *sql.DB, emp *vars.Employee) error {
 		}
 	}()
 
+	if err = checkRole(emp.Role); err != nil {
+		return err
+	}
+
 	// Add employee
-	err = vars.InsertEmployee(tx, emp.FirstName, emp.LastName, emp.Email, emp.UserName, emp.Level)
+	err = vars.InsertEmployee(tx, emp.FirstName, emp.LastName, emp.Email, emp.UserName, emp.Role)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -121,6 +265,26 @@ func AddEmployee(db *sql.DB, emp *vars.Employee) error {
 
 // AddNote inserts a new note into the database.
 func AddNote(db *sql.DB, vid, eid int64, note string) error {
+	return addNote(db, vid, eid, note, vars.VarsNullInt64{})
+}
+
+// AddNoteReply inserts a new note into the database as a reply to the parent note.
+func AddNoteReply(db *sql.DB, vid, eid, parent int64, note string) error {
+	p, err := vars.GetNote(parent)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	if p.VulnID != vid {
+		return errors.New("Varsapi: AddNoteReply: The parent note belongs to a different vulnerability")
+	}
+	if p.Deleted.Valid {
+		return errors.New("Varsapi: AddNoteReply: The parent note has been deleted")
+	}
+	return addNote(db, vid, eid, note, GetVarsNullInt64(parent))
+}
+
+// addNote inserts the note and records the employees mentioned in it.
+func addNote(db *sql.DB, vid, eid int64, note string, parent vars.VarsNullInt64) error {
 	//Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -134,7 +298,13 @@ func AddNote(db *sql.DB, vid, eid int64, note string) error {
 	}()
 
 	// Add note
-	err = vars.InsertNote(tx, vid, eid, note)
+	nid, err := vars.InsertNote(tx, vid, eid, note, parent)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Add mentions
+	err = setNoteMentions(tx, nid, note)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -174,6 +344,53 @@ func AddRef(db *sql.DB, vid int64, ref string) error {
 	return nil
 }
 
+// AddRelated links the vulnerability vid to rvid with the given type of link (vars.RelDuplicateOf,
+// vars.RelSupersedes or vars.RelRelated). Only one link can exist between two vulnerabilities. An InvalidValue error
+// is returned for any other type of link, for a link to itself and for a second link.
+func AddRelated(db *sql.DB, vid, rvid int64, reltype string) error {
+	switch reltype {
+	case vars.RelDuplicateOf, vars.RelSupersedes, vars.RelRelated:
+	default:
+		return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", "AddRelated", "Unknown link type "+reltype)
+	}
+	if vid == rvid {
+		return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", "AddRelated", "A vulnerability cannot be linked to itself")
+	}
+	rels, err := vars.GetRelated(vid)
+	if err != nil {
+		return err
+	}
+	for _, rel := range rels {
+		if rel.VulnID == rvid {
+			return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", "AddRelated", "The vulnerabilities are already linked")
+		}
+	}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.InsertRelated(tx, vid, rvid, reltype)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
 // AddTicket adds the given ticket to the ticket table for vulnid
 func AddTicket(db *sql.DB, vid int64, ticket string) error {
 	//Start transaction and set rollback function
@@ -251,26 +468,41 @@ func AddSystem(db *sql.DB, sys *vars.System) error {
 	return nil
 }
 
-// CreateEmployee creates an employee object with the given parameters.
-func CreateEmployee(firstname, lastname, email, username string, level int) *vars.Employee {
-	emp := vars.Employee{FirstName: firstname, LastName: lastname, Email: email, UserName: username, Level: level}
-	return &emp
-}
+// AddTask adds the remediation task to its vulnerability and sets the ID of t. An InvalidValue error is returned if
+// the task has no title or its vulnerability, assignee or system doesn't exist.
+func AddTask(db *sql.DB, t *vars.Task) error {
+	if err := validateTask("AddTask", t); err != nil {
+		return err
+	}
 
-// CreateSystem creates a system object with the given parameters.
-func CreateSystem(name, tp, opsys, loc, desc, state string) *vars.System {
-	sys := vars.System{Name: name, Type: tp, OpSys: opsys, Location: loc, Description: desc, State: state}
-	return &sys
-}
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
 
-// CreateVulnerability creates a vulnerability object with the given parameters.
-func CreateVulnerability(name, summary, cvssLink, test, mitigation, exploit string, exploitable bool, cvss, corpscore float32) *vars.Vulnerability {
-	vuln := vars.Vulnerability{Name: name, Cvss: cvss, CorpScore: corpscore, CvssLink: GetVarsNullString(cvssLink), Summary: summary, Test: test, Mitigation: mitigation, Exploit: GetVarsNullString(exploit), Exploitable: GetVarsNullBool(exploitable)}
-	return &vuln
+	id, err := vars.InsertTask(tx, t)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	t.ID = id
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
 }
 
-// AddVulnerability starts a new VA
-func AddVulnerability(db *sql.DB, vuln *vars.Vulnerability) error {
+// AddTemplate adds a new vulnerability template to the database and sets the ID of t.
+func AddTemplate(db *sql.DB, t *vars.Template) error {
 	//Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -283,60 +515,190 @@ func AddVulnerability(db *sql.DB, vuln *vars.Vulnerability) error {
 		}
 	}()
 
-	// Check if vulnerability name is available
-	a, err := vars.NameIsAvailable("vuln", vuln.Name)
+	// Check if template name is available
+	a, err := vars.NameIsAvailable("template", t.Name)
 	if !vars.IsNilErr(err) {
 		return err
 	}
 	if !a {
-		return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "AddVulnerability")
+		return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "AddTemplate")
 	}
 
-	// Insert the vulnerability into the database
-	err = vars.InsertVulnerability(tx, vuln.Name, vuln.Finder, vuln.Initiator, vuln.Summary, vuln.Test, vuln.Mitigation)
+	// Add template
+	id, err := vars.InsertTemplate(tx, t)
 	if !vars.IsNilErr(err) {
 		return err
 	}
+	t.ID = id
+	for _, ref := range t.References {
+		err = vars.InsertTemplateRef(tx, t.ID, ref)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
 
-	// Update the vulnid
-	vid, err := vars.GetVulnIDtx(tx, vuln.Name)
-	if !vars.IsNilErr(err) {
-		return err
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
 	}
-	vuln.ID = vid
+	return nil
+}
 
-	// Insert the values in the impact table
-	err = vars.InsertImpact(tx, vuln.ID, vuln.Cvss, vuln.CorpScore, vuln.CvssLink)
+// ApplyTemplate fills in the fields of vuln that were left empty with the values of the template. The references
+// of the template are added to the references of vuln.
+func ApplyTemplate(vuln *vars.Vulnerability, tid int64) error {
+	t, err := GetTemplate(tid)
 	if !vars.IsNilErr(err) {
 		return err
 	}
+	if vuln.Summary == "" {
+		vuln.Summary = t.Summary
+	}
+	if vuln.Test == "" {
+		vuln.Test = t.Test
+	}
+	if vuln.Mitigation == "" {
+		vuln.Mitigation = t.Mitigation
+	}
+	if vuln.Cvss == 0 {
+		vuln.Cvss = t.Cvss
+	}
+	if !vuln.CvssLink.Valid || vuln.CvssLink.String == "" {
+		vuln.CvssLink = t.CvssLink
+	}
+	if vuln.CorpScore == 0 {
+		vuln.CorpScore = t.CorpScore
+	}
+	for _, ref := range t.References {
+		if !stringInSlice(ref, &vuln.References) {
+			vuln.References = append(vuln.References, ref)
+		}
+	}
+	return nil
+}
 
-	// Insert the values in the dates table
-	err = vars.InsertDates(tx, vuln.ID, time.Now(), vuln.Dates.Published, vuln.Dates.Mitigated)
+// AutofillFromCatalog fills in the fields of the vulnerability that are still empty (summary, published date, CVSS
+// score and link) from the catalog entry of the CVE and adds the CWEs and references of the entry to the
+// vulnerability.
+func AutofillFromCatalog(db *sql.DB, vid int64, cve string) error {
+	vuln, err := GetVulnerability(vid)
 	if !vars.IsNilErr(err) {
 		return err
 	}
-
-	// Insert the values in the cves table
-	err = vars.SetCves(tx, vuln)
+	entry, err := vars.GetCatalogEntry(cve)
 	if !vars.IsNilErr(err) {
 		return err
 	}
 
-	// Insert the values in the ticket table
-	err = vars.SetTickets(tx, vuln)
-	if !vars.IsNilErr(err) {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
 		return err
 	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
 
-	// Insert the values in the reference table
-	err = vars.SetReferences(tx, vuln)
-	if !vars.IsNilErr(err) {
+	if vuln.Summary == "" && entry.Summary != "" {
+		err = vars.UpdateSummary(tx, vid, entry.Summary)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if !vuln.Dates.Published.Valid && entry.Published.Valid {
+		err = vars.UpdatePubDate(tx, vid, entry.Published)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if vuln.Cvss == 0 && entry.CvssVector.Valid {
+		err = vars.UpdateCvss(tx, vid, entry.CvssScore)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		if !vuln.CvssLink.Valid || vuln.CvssLink.String == "" {
+			err = vars.UpdateCvssLink(tx, vid, vars.ToVarsNullString(nvd.CalculatorLink(entry.CvssVector.String)))
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	}
+	for _, cwe := range entry.Cwes {
+		if !stringInSlice(cwe, &vuln.Cwes) {
+			err = vars.InsertCwe(tx, vid, cwe)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	}
+	for _, ref := range entry.References {
+		if !stringInSlice(ref, &vuln.References) {
+			err = vars.InsertRef(tx, vid, ref)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// CalculateCorpScore returns the corporate score of the vulnerability suggested by the corporate score calculator,
+// rounded to one decimal. The score is 60% the CVSS score, 30% the highest EPSS score scaled to 0-10 and 10% a 10
+// if one of the CVEs is in the KEV catalog.
+func CalculateCorpScore(vuln *vars.Vulnerability) float32 {
+	score := 0.6 * vuln.Cvss
+	if vuln.Epss != nil {
+		score += 0.3 * vuln.Epss.Score * 10
+	}
+	if len(vuln.Kev) > 0 {
+		score += 0.1 * 10
+	}
+	return float32(math.Floor(float64(score)*10+0.5) / 10)
+}
+
+// CreateEmployee creates an employee object with the given parameters.
+func CreateEmployee(firstname, lastname, email, username, role string) *vars.Employee {
+	emp := vars.Employee{FirstName: firstname, LastName: lastname, Email: email, UserName: username, Role: role, Active: true}
+	return &emp
+}
+
+// CreateSystem creates a system object with the given parameters.
+func CreateSystem(name, tp, opsys, loc, desc, state string) *vars.System {
+	sys := vars.System{Name: name, Type: tp, OpSys: opsys, Location: loc, Description: desc, State: state}
+	return &sys
+}
+
+// CreateVulnerability creates a vulnerability object with the given parameters.
+func CreateVulnerability(name, summary, cvssLink, test, mitigation, exploit string, exploitable bool, cvss, corpscore float32) *vars.Vulnerability {
+	vuln := vars.Vulnerability{Name: name, Cvss: cvss, CorpScore: corpscore, CvssLink: GetVarsNullString(cvssLink), Summary: summary, Test: test, Mitigation: mitigation, Exploit: GetVarsNullString(exploit), Exploitable: GetVarsNullBool(exploitable)}
+	return &vuln
+}
+
+// AddVulnerability starts a new VA
+func AddVulnerability(db *sql.DB, vuln *vars.Vulnerability) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
 		return err
 	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
 
-	// Insert the values in the exploits table
-	err = vars.SetExploit(tx, vuln)
+	err = addVulnerability(tx, vuln)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -405,10 +767,81 @@ func CloseVulnerability(db *sql.DB, vid int64) error {
 	return nil
 }
 
-// CloseDB is a way to close connections to the database safely
-func CloseDB(db *sql.DB) {
-	vars.CloseDB(db)
-}
+// CloneVulnerability starts a new VA named newName that is a copy of the given vulnerability. The notes, tickets,
+// attachments and mitigation state are not copied: the affected systems are added as not mitigated, the tasks as
+// not done and the new VA is open. The employee with the ID initiator is recorded as having started it. It returns
+// the ID of the new vulnerability.
+func CloneVulnerability(db *sql.DB, vid, initiator int64, newName string) (int64, error) {
+	orig, err := GetVulnerability(vid)
+	if !vars.IsNilErr(err) {
+		return 0, err
+	}
+	clone := vars.Vulnerability{
+		Name:        newName,
+		Cves:        orig.Cves,
+		Cwes:        orig.Cwes,
+		Cvss:        orig.Cvss,
+		CorpScore:   orig.CorpScore,
+		CvssLink:    orig.CvssLink,
+		Finder:      orig.Finder,
+		Initiator:   initiator,
+		Summary:     orig.Summary,
+		Test:        orig.Test,
+		Mitigation:  orig.Mitigation,
+		References:  orig.References,
+		Exploit:     orig.Exploit,
+		Exploitable: orig.Exploitable,
+	}
+	clone.Dates.Published = orig.Dates.Published
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = addVulnerability(tx, &clone)
+	if !vars.IsNilErr(err) {
+		return 0, err
+	}
+
+	// Add the affected systems as not mitigated
+	for _, aff := range orig.AffSystems {
+		err = vars.InsertAffected(tx, clone.ID, aff.Sys.ID, false)
+		if !vars.IsNilErr(err) {
+			return 0, err
+		}
+	}
+
+	// Add the tasks as not done
+	for _, task := range orig.Tasks {
+		t := *task
+		t.VulnID = clone.ID
+		t.Done = false
+		_, err = vars.InsertTask(tx, &t)
+		if !vars.IsNilErr(err) {
+			return 0, err
+		}
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return 0, e
+	}
+	return clone.ID, nil
+}
+
+// CloseDB is a way to close connections to the database safely
+func CloseDB(db *sql.DB) {
+	vars.CloseDB(db)
+}
 
 // ConnectDB gets the VARS config object and calls the VARS function to set up the database connection.
 func ConnectDB() (*sql.DB, error) {
@@ -431,6 +864,14 @@ func DeleteAffected(db *sql.DB, vid, sid int64) error {
 	}()
 
 	// Delete the row (vid, sid) from affected.
+	err = vars.DeleteScanMitigation(tx, vid, sid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteAffectedPkgs(tx, vid, sid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
 	err = vars.DeleteAffected(tx, vid, sid)
 	if !vars.IsNilErr(err) {
 		return err
@@ -444,6 +885,43 @@ func DeleteAffected(db *sql.DB, vid, sid int64) error {
 	return nil
 }
 
+// DeleteAttachment deletes the attachment with the given attachid and its contents.
+func DeleteAttachment(db *sql.DB, aid int64) error {
+	att, err := vars.GetAttachment(aid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	store, err := vars.GetBlobStore()
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	keys, err := deleteAttachments(tx, store, []*vars.Attachment{att})
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	deleteBlobs(store, keys)
+	return nil
+}
+
 // DeleteCve will delete the row (vulnid, cve).
 func DeleteCve(db *sql.DB, vid int64, cve string) error {
 	//Start transaction and set rollback function
@@ -471,8 +949,11 @@ func DeleteCve(db *sql.DB, vid int64, cve string) error {
 	return nil
 }
 
-// DeleteEmployee will change the username of the row with empid to 'removed'.
-func DeleteEmployee(db *sql.DB, eid int64) error {
+// DeactivateEmployee marks the employee with the given empid as inactive so they can no longer log in.
+// The employee's username and history are kept so they can be reactivated later. If reassign is
+// valid, the open vulnerability assessments initiated by the employee are reassigned to the employee
+// with that empid.
+func DeactivateEmployee(db *sql.DB, eid int64, reassign vars.VarsNullInt64) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -485,7 +966,24 @@ func DeleteEmployee(db *sql.DB, eid int64) error {
 		}
 	}()
 
-	err = vars.UpdateEmpUname(tx, eid, "VARSremoved")
+	if reassign.Valid {
+		if reassign.Int64 == eid {
+			return errors.New("Varsapi: DeactivateEmployee: Cannot reassign vulnerability assessments to the employee being deactivated")
+		}
+		emp, err := vars.GetEmployee(reassign.Int64)
+		if err != nil {
+			return err
+		}
+		if !emp.Active {
+			return errors.New("Varsapi: DeactivateEmployee: Cannot reassign vulnerability assessments to an inactive employee")
+		}
+		err = vars.UpdateInitiatorOpen(tx, eid, reassign.Int64)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+	}
+
+	err = vars.UpdateEmpActive(tx, eid, false, GetVarsNullTime(time.Now()))
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -498,9 +996,10 @@ func DeleteEmployee(db *sql.DB, eid int64) error {
 	return nil
 }
 
-// DeleteNote deletes the note with the given noteid.
-func DeleteNote(db *sql.DB, nid int64) error {
-	//Start transaction and set rollback function
+// ReactivateEmployee marks the employee with the given empid as active so they can log in again. An
+// error is returned if another active employee has the same username.
+func ReactivateEmployee(db *sql.DB, eid int64) error {
+	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
 		return err
@@ -512,8 +1011,21 @@ func DeleteNote(db *sql.DB, nid int64) error {
 		}
 	}()
 
-	// Delete the note (nid)
-	err = vars.DeleteNote(tx, nid)
+	emp, err := vars.GetEmployee(eid)
+	if err != nil {
+		return err
+	}
+	id, err := vars.GetEmpIDtx(tx, emp.UserName)
+	if err != nil {
+		return err
+	}
+	if id != eid {
+		if other, err := vars.GetEmployee(id); err == nil && other.Active {
+			return errors.New("Varsapi: ReactivateEmployee: The username is in use by another active employee")
+		}
+	}
+
+	err = vars.UpdateEmpActive(tx, eid, true, vars.VarsNullTime{})
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -526,8 +1038,9 @@ func DeleteNote(db *sql.DB, nid int64) error {
 	return nil
 }
 
-// DeleteRef will delete the row in the ref table associated with (vid, ref).
-func DeleteRef(db *sql.DB, vid int64, ref string) error {
+// DeleteNote marks the note with the given noteid as deleted. The note and its revisions are kept so
+// that replies to it stay in their thread.
+func DeleteNote(db *sql.DB, nid int64) error {
 	//Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -540,7 +1053,8 @@ func DeleteRef(db *sql.DB, vid int64, ref string) error {
 		}
 	}()
 
-	err = vars.DeleteRef(tx, vid, ref)
+	// Mark the note (nid) as deleted
+	err = vars.UpdateNoteDeleted(tx, nid, GetVarsNullTime(time.Now()))
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -553,8 +1067,8 @@ func DeleteRef(db *sql.DB, vid int64, ref string) error {
 	return nil
 }
 
-// DeleteSystem will delete the row in the sys table associated with sid.
-func DeleteSystem(db *sql.DB, sid int64) error {
+// DeleteRef will delete the row in the ref table associated with (vid, ref).
+func DeleteRef(db *sql.DB, vid int64, ref string) error {
 	//Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -567,12 +1081,34 @@ func DeleteSystem(db *sql.DB, sid int64) error {
 		}
 	}()
 
-	err = vars.DeleteSystemFromAffected(tx, sid)
+	err = vars.DeleteRef(tx, vid, ref)
 	if !vars.IsNilErr(err) {
 		return err
 	}
 
-	err = vars.DeleteSystem(tx, sid)
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// DeleteRelated removes the link between the two vulnerabilities.
+func DeleteRelated(db *sql.DB, vid, rvid int64) error {
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.DeleteRelated(tx, vid, rvid)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -585,8 +1121,9 @@ func DeleteSystem(db *sql.DB, sid int64) error {
 	return nil
 }
 
-// DeleteTicket will delete the row (vulnid, ticket).
-func DeleteTicket(db *sql.DB, vid int64, ticket string) error {
+// DeleteRole removes the role from the database, leaving the default or configured role of the same name if there
+// is one. A no rows error is returned if the role is not defined in the database.
+func DeleteRole(db *sql.DB, role string) error {
 	//Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -599,7 +1136,7 @@ func DeleteTicket(db *sql.DB, vid int64, ticket string) error {
 		}
 	}()
 
-	err = vars.DeleteTicket(tx, vid, ticket)
+	err = vars.DeleteRolePermissions(tx, role)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -612,8 +1149,8 @@ func DeleteTicket(db *sql.DB, vid int64, ticket string) error {
 	return nil
 }
 
-// DeleteVulnerability will delete the vulnerability with the given vulnid from VARS.
-func DeleteVulnerability(db *sql.DB, vid int64) error {
+// DeleteSystem will delete the row in the sys table associated with sid.
+func DeleteSystem(db *sql.DB, sid int64) error {
 	//Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -626,86 +1163,53 @@ func DeleteVulnerability(db *sql.DB, vid int64) error {
 		}
 	}()
 
-	// Delete from CVEs table
-	cves, err := vars.GetCves(vid)
+	// Delete the attachments related to the system
+	atts, err := vars.GetAttachmentsBySystem(sid)
 	if !vars.IsNilErr(err) {
 		return err
 	}
-	for _, cve := range *cves {
-		err = vars.DeleteCve(tx, vid, cve)
-		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
-			return err
-		}
-	}
-
-	// Delete from Tickets table
-	tickets, err := vars.GetTickets(vid)
+	store, err := vars.GetBlobStore()
 	if !vars.IsNilErr(err) {
 		return err
 	}
-	for _, ticket := range *tickets {
-		err = vars.DeleteTicket(tx, vid, ticket)
-		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
-			return err
-		}
-	}
-
-	// Delete from Affected table
-	affected, err := vars.GetAffected(vid)
+	keys, err := deleteAttachments(tx, store, atts)
 	if !vars.IsNilErr(err) {
 		return err
 	}
-	for _, aff := range affected {
-		err = vars.DeleteAffected(tx, vid, aff.Sys.ID)
-		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
-			return err
-		}
-	}
 
-	// Delete from Notes table
-	notes, err := vars.GetNotes(vid)
-	if !vars.IsNilErr(err) {
+	err = vars.DeleteSysScanMitigations(tx, sid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
 		return err
 	}
-	for _, note := range notes {
-		err = vars.DeleteNote(tx, note.ID)
-		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
-			return err
-		}
+	err = vars.DeleteSysAffectedPkgs(tx, sid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
 	}
-
-	// Delete from Ref table
-	refs, err := vars.GetReferences(vid)
+	err = vars.DeleteSystemFromAffected(tx, sid)
 	if !vars.IsNilErr(err) {
 		return err
 	}
-	for _, ref := range *refs {
-		err = vars.DeleteRef(tx, vid, ref)
-		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
-			return err
-		}
-	}
 
-	// Delete from Exploits table
-	err = vars.DeleteExploit(tx, vid)
+	// Remove the system scope from the tasks
+	err = vars.UpdateTasksSysNull(tx, sid)
 	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
 		return err
 	}
 
-	// Delete from Dates table
-	err = vars.DeleteDates(tx, vid)
+	err = vars.DeleteSysAddrs(tx, sid)
 	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
 		return err
 	}
-
-	// Delete from Impact table
-	err = vars.DeleteImpact(tx, vid)
+	err = vars.DeleteSysPorts(tx, sid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteSysComponents(tx, sid)
 	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
 		return err
 	}
 
-	// Delete from vuln table
-	err = vars.DeleteVulnerability(tx, vid)
+	err = vars.DeleteSystem(tx, sid)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -715,299 +1219,2711 @@ func DeleteVulnerability(db *sql.DB, vid int64) error {
 	if e := tx.Commit(); e != nil {
 		return e
 	}
+	deleteBlobs(store, keys)
 	return nil
 }
 
-// GetEmployeeByID returns an Employee object with the given empid.
-func GetEmployeeByID(eid int64) (*vars.Employee, error) {
-	return vars.GetEmployee(eid)
-}
+// DeleteTask deletes the remediation task.
+func DeleteTask(db *sql.DB, tid int64) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
 
-// GetEmployeeByUsername returns an Employee object with the given username.
-func GetEmployeeByUsername(username string) (*vars.Employee, error) {
-	id, err := vars.GetEmpID(username)
+	err = vars.DeleteTask(tx, tid)
 	if !vars.IsNilErr(err) {
-		var emp vars.Employee
-		return &emp, err
+		return err
 	}
-	return vars.GetEmployee(id)
-}
 
-// GetEmployees returns a slice of pointers to Employee objects.
-func GetEmployees() ([]*vars.Employee, error) {
-	return vars.GetEmployees()
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
 }
 
-// GetClosedVulnerabilities builds/returns a slice of pointers to Vulnerabilities that
-// have a non-NULL 'mitigated' date.
-func GetClosedVulnerabilities() ([]*vars.Vulnerability, error) {
-	var vulns []*vars.Vulnerability
+// DeleteTemplate deletes the vulnerability template and its references.
+func DeleteTemplate(db *sql.DB, tid int64) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
 
-	// Get a slice of IDs associated with open vulnerabilities
-	ids, err := vars.GetClosedVulnIDs()
+	err = vars.DeleteTemplateRefs(tx, tid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteTemplate(tx, tid)
 	if !vars.IsNilErr(err) {
-		return vulns, err
+		return err
 	}
 
-	// For each ID get the associated vulnerability object
-	for _, id := range *ids {
-		vuln, err := GetVulnerability(id)
-		if !vars.IsNilErr(err) {
-			return vulns, err
-		}
-		vulns = append(vulns, vuln)
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
 	}
-	return vulns, nil
+	return nil
 }
 
-// GetConfig retrieves/returns the Config object that was created in VARS.
+// DeleteTicket will delete the row (vulnid, ticket).
+func DeleteTicket(db *sql.DB, vid int64, ticket string) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.DeleteTicket(tx, vid, ticket)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// DeleteVulnerability will delete the vulnerability with the given vulnid from VARS.
+func DeleteVulnerability(db *sql.DB, vid int64) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	// Delete from CVEs table
+	cves, err := vars.GetCves(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	for _, cve := range *cves {
+		err = vars.DeleteCve(tx, vid, cve)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+	}
+
+	// Delete from CWEs table
+	cwes, err := vars.GetCwes(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	for _, cwe := range *cwes {
+		err = vars.DeleteCwe(tx, vid, cwe)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+	}
+
+	// Delete from KEV matches table
+	err = vars.DeleteKevMatches(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+
+	// Delete from scanner references table
+	err = vars.DeleteScanRefs(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+
+	// Delete from Tickets table
+	tickets, err := vars.GetTickets(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	for _, ticket := range *tickets {
+		err = vars.DeleteTicket(tx, vid, ticket)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+	}
+
+	// Delete from Affected table
+	err = vars.DeleteVulnScanMitigations(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteVulnAffectedPkgs(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	affected, err := vars.GetAffected(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	for _, aff := range affected {
+		err = vars.DeleteAffected(tx, vid, aff.Sys.ID)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+	}
+
+	// Delete from Attachments table
+	atts, err := vars.GetAttachments(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	store, err := vars.GetBlobStore()
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	keys, err := deleteAttachments(tx, store, atts)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Delete from Notes table. Replies are newer than their parents so the notes are deleted newest first.
+	notes, err := vars.GetNotes(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	for i := len(notes) - 1; i >= 0; i-- {
+		err = vars.DeleteNoteMentions(tx, notes[i].ID)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+		err = vars.DeleteNoteRevisions(tx, notes[i].ID)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+		err = vars.DeleteNote(tx, notes[i].ID)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+	}
+
+	// Delete from Ref table
+	refs, err := vars.GetReferences(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	for _, ref := range *refs {
+		err = vars.DeleteRef(tx, vid, ref)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+	}
+
+	// Delete from Related table
+	err = vars.DeleteRelatedAll(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+
+	// Delete from Merges table
+	err = vars.DeleteMerges(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+
+	// Delete from Tasks table
+	err = vars.DeleteTasks(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+
+	// Delete from Exploits table
+	err = vars.DeleteExploit(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+
+	// Delete from Dates table
+	err = vars.DeleteDates(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+
+	// Delete from Impact table
+	err = vars.DeleteImpact(tx, vid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+
+	// Delete from vuln table
+	err = vars.DeleteVulnerability(tx, vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	deleteBlobs(store, keys)
+	return nil
+}
+
+// Export writes an archive of all the data of VARS to w, for moving an instance or taking a snapshot before an
+// upgrade. The archive is a gzipped tarball holding the manifest (manifest.json), the rows of each table of
+// vars.ArchiveTables as JSON lines (tables/NAME.jsonl) and the contents of the attachments
+// (attachments/ATTACHID). The data is read in a single read only transaction, so the archive is consistent.
+func Export(db *sql.DB, w io.Writer) (*vars.ArchiveManifest, error) {
+	store, err := vars.GetBlobStore()
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	tx, err := db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	man := vars.ArchiveManifest{Format: vars.ArchiveFormat, Version: vars.ArchiveVersion, Created: time.Now(), Tables: make(map[string]int)}
+	tables := make(map[string]*bytes.Buffer)
+	var keys map[int64]string
+	for _, t := range vars.ArchiveTables {
+		rows, err := vars.GetArchiveRows(tx, t)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return nil, err
+			}
+		}
+		tables[t.Name] = &buf
+		man.Tables[t.Name] = len(rows)
+		if t.Name == "attachments" {
+			keys = make(map[int64]string)
+			for _, row := range rows {
+				id, _ := archiveInt(row["attachid"])
+				keys[id], _ = row["storekey"].(string)
+			}
+		}
+	}
+	man.Attachments = len(keys)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	data, err := json.MarshalIndent(man, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeArchiveMember(tw, "manifest.json", data); err != nil {
+		return nil, err
+	}
+	for _, t := range vars.ArchiveTables {
+		if err := writeArchiveMember(tw, "tables/"+t.Name+".jsonl", tables[t.Name].Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	var ids []int64
+	for id := range keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		data, err := store.Get(keys[id])
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		if err := writeArchiveMember(tw, "attachments/"+strconv.FormatInt(id, 10), data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return &man, nil
+}
+
+// ExportCsaf writes the CSAF 2.0 document of the vulnerability to w. The document uses the security advisory
+// profile, or the VEX profile if vex is true. Only the notes marked public are included.
+func ExportCsaf(w io.Writer, vid int64, vex bool) error {
+	vuln, err := GetVulnerability(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	notes, err := vars.GetNotes(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	opts := csaf.Options{
+		Vex:       vex,
+		Publisher: csaf.Publisher{Category: "other", Name: publisher(), Namespace: vars.Conf.CsafNamespace},
+		Catalog:   make(map[string]*vars.CatalogEntry),
+		Date:      time.Now(),
+	}
+	if opts.Publisher.Namespace == "" {
+		opts.Publisher.Namespace = "https://github.com/cbelk/vars"
+	}
+	for _, note := range notes {
+		if note.Public && !note.Deleted.Valid {
+			opts.Notes = append(opts.Notes, note)
+		}
+	}
+	for _, cve := range vuln.Cves {
+		entry, err := vars.GetCatalogEntry(strings.ToUpper(cve))
+		if err != nil {
+			if vars.IsNoRowsError(err) {
+				continue
+			}
+			return err
+		}
+		opts.Catalog[entry.Cve] = entry
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(csaf.FromVulnerability(vuln, &opts))
+}
+
+// ExportDossierPdf writes the dossier to w as a PDF document. The Markdown of the notes is stripped to plain text.
+func ExportDossierPdf(w io.Writer, d *vars.Dossier) error {
+	const date = "2006-01-02"
+	v := d.Vuln
+	doc := pdf.New(fmt.Sprintf("%s - Vulnerability Dossier", v.Name))
+	doc.Paragraph("Generated " + d.Generated.Format("2006-01-02 15:04 MST"))
+
+	doc.Heading("Summary")
+	doc.Paragraph(v.Summary)
+
+	doc.Heading("Scores")
+	epss, kev, exploitable := "None", "No", "Unknown"
+	if v.Epss != nil {
+		epss = fmt.Sprintf("%.2f%% (%.0fth percentile, %s)", v.Epss.Score*100, v.Epss.Percentile*100, v.Epss.ModelDate.Format(date))
+	}
+	if len(v.Kev) > 0 {
+		kev = fmt.Sprintf("Yes, added %s", v.Kev[0].DateAdded.Format(date))
+	}
+	if v.Exploitable.Valid {
+		exploitable = "No"
+		if v.Exploitable.Bool {
+			exploitable = "Yes"
+		}
+	}
+	doc.Fields([][2]string{
+		{"CVSS", fmt.Sprintf("%.1f", v.Cvss)},
+		{"CVSS link", v.CvssLink.String},
+		{"Corporate score", fmt.Sprintf("%.1f", v.CorpScore)},
+		{"EPSS", epss},
+		{"Known exploited", kev},
+		{"Exploitable", exploitable},
+		{"Exploit", v.Exploit.String},
+	})
+
+	doc.Heading("CVEs")
+	doc.Fields([][2]string{
+		{"CVEs", strings.Join(v.Cves, ", ")},
+		{"CWEs", strings.Join(v.Cwes, ", ")},
+		{"Tickets", strings.Join(v.Tickets, ", ")},
+		{"References", strings.Join(v.References, "\n")},
+		{"Finder", d.Finder},
+		{"Initiator", d.Initiator},
+		{"Tasks done", fmt.Sprintf("%d%%", v.Progress)},
+	})
+
+	doc.Heading("Test")
+	doc.Paragraph(v.Test)
+	doc.Heading("Mitigation")
+	doc.Paragraph(v.Mitigation)
+
+	doc.Heading("Affected Systems")
+	var rows [][]string
+	for _, a := range v.AffSystems {
+		status, evidence := "Not mitigated", ""
+		if a.Mitigated {
+			status = "Mitigated"
+		}
+		if a.Evidence != nil {
+			evidence = fmt.Sprintf("%s scan %s, %s", a.Evidence.Scanner, a.Evidence.Scan, a.Evidence.Mitigated.Format(date))
+		}
+		var pkgs []string
+		for _, p := range a.Packages {
+			pkgs = append(pkgs, fmt.Sprintf("%s %s", p.Package, p.Installed))
+		}
+		rows = append(rows, []string{a.Sys.Name, a.Sys.Location, a.Sys.OpSys, status, evidence, strings.Join(pkgs, "\n")})
+	}
+	doc.Table([]float64{0.2, 0.12, 0.14, 0.14, 0.2, 0.2}, []string{"System", "Location", "OS", "Status", "Evidence", "Packages"}, rows)
+
+	doc.Heading("Timeline")
+	rows = nil
+	for _, e := range d.Timeline {
+		rows = append(rows, []string{e.Date.Format(date), e.Event})
+	}
+	doc.Table([]float64{0.2, 0.8}, []string{"Date", "Event"}, rows)
+
+	doc.Heading("Notes")
+	rows = nil
+	for _, n := range d.Notes {
+		author := n.Author
+		if n.Reply {
+			author = "Reply from " + author
+		}
+		rows = append(rows, []string{fmt.Sprintf("%s\n%s", author, n.Added.Format("2006-01-02 15:04")), plainText(n.Note)})
+	}
+	doc.Table([]float64{0.25, 0.75}, []string{"Author", "Note"}, rows)
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// ExportOSV returns the OSV record of the vulnerability. The CVSS vectors are those of the CVEs in the catalog and
+// of the CVSS link, and the ecosystems of the affected packages are taken from the package URLs of the components
+// of the affected systems, when an SBOM listed them.
+func ExportOSV(vid int64) (*osv.Entry, error) {
+	vuln, err := GetVulnerability(vid)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	var vectors []string
+	for _, cve := range vuln.Cves {
+		entry, err := vars.GetCatalogEntry(strings.ToUpper(cve))
+		if err != nil {
+			if vars.IsNoRowsError(err) {
+				continue
+			}
+			return nil, err
+		}
+		if entry.CvssVector.Valid && !stringInSlice(entry.CvssVector.String, &vectors) {
+			vectors = append(vectors, entry.CvssVector.String)
+		}
+	}
+	if i := strings.Index(vuln.CvssLink.String, "#CVSS:"); i >= 0 && !stringInSlice(vuln.CvssLink.String[i+1:], &vectors) {
+		vectors = append(vectors, vuln.CvssLink.String[i+1:])
+	}
+	purls := make(map[string]string)
+	for _, a := range vuln.AffSystems {
+		if len(a.Packages) == 0 {
+			continue
+		}
+		comps, err := vars.GetSysComponents(a.Sys.ID)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		for _, c := range comps {
+			if c.Purl != "" {
+				purls[c.Name] = c.Purl
+			}
+		}
+	}
+	return osv.FromVulnerability(vuln, vectors, purls, time.Now()), nil
+}
+
+// ExportSarif writes the vulnerabilities to w as a SARIF 2.1.0 log with a rule per vulnerability and a result per
+// affected system that is not mitigated. See sarif.FromVulnerabilities.
+func ExportSarif(w io.Writer, vulns []*vars.Vulnerability) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarif.FromVulnerabilities(vulns))
+}
+
+// ExportStix writes the STIX 2.1 bundle of the vulnerabilities to w, marked with the TLP level (see stix.Policy).
+// The configured StixTlp is used if tlp is empty. The notes marked public are included as note objects.
+func ExportStix(w io.Writer, vulns []*vars.Vulnerability, tlp string) error {
+	opts := stix.Options{Tlp: tlp, Producer: publisher(), Notes: make(map[int64][]*vars.Note), Date: time.Now()}
+	if opts.Tlp == "" {
+		opts.Tlp = vars.Conf.StixTlp
+	}
+	if opts.Tlp == "" {
+		opts.Tlp = "amber"
+	}
+	for _, vuln := range vulns {
+		notes, err := vars.GetNotes(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		for _, note := range notes {
+			if note.Public && !note.Deleted.Valid {
+				opts.Notes[vuln.ID] = append(opts.Notes[vuln.ID], note)
+			}
+		}
+	}
+	bundle, err := stix.FromVulnerabilities(vulns, &opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bundle)
+}
+
+// ExportSystemsCsv writes all the systems to w as CSV with the SystemsCsvColumns header. Cells that start like a
+// formula are quoted (see csvCell). The output can be read back by ImportSystemsCsv.
+func ExportSystemsCsv(w io.Writer) error {
+	syss, err := vars.GetSystems()
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(SystemsCsvColumns); err != nil {
+		return err
+	}
+	for _, sys := range syss {
+		addrs, err := vars.GetSysAddrs(sys.ID)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		rec := []string{sys.Name, sys.Type, sys.OpSys, sys.Location, sys.Description, sys.State, strings.Join(*addrs, ";")}
+		for i := range rec {
+			rec[i] = csvCell(rec[i])
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportVulnerabilitiesCsv writes the vulnerabilities to w as CSV with the VulnerabilityColumns header. Cells that
+// start like a formula are quoted (see csvCell).
+func ExportVulnerabilitiesCsv(w io.Writer, vulns []*vars.Vulnerability) error {
+	rows, err := vulnerabilityRows(vulns)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(VulnerabilityColumns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		rec := make([]string, len(row))
+		for i, c := range row {
+			switch v := c.(type) {
+			case nil:
+			case float32:
+				rec[i] = strconv.FormatFloat(float64(v), 'f', -1, 32)
+			default:
+				rec[i] = csvCell(fmt.Sprint(v))
+			}
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportVulnerabilitiesXlsx writes the vulnerabilities to w as an XLSX workbook with the VulnerabilityColumns
+// header. The scores and counts are written as numbers.
+func ExportVulnerabilitiesXlsx(w io.Writer, sheet string, vulns []*vars.Vulnerability) error {
+	rows, err := vulnerabilityRows(vulns)
+	if err != nil {
+		return err
+	}
+	return xlsx.Write(w, sheet, VulnerabilityColumns, rows)
+}
+
+// FilterVulnerabilitiesByEpss returns the vulnerabilities whose highest EPSS score is at least min.
+func FilterVulnerabilitiesByEpss(vulns []*vars.Vulnerability, min float32) []*vars.Vulnerability {
+	res := []*vars.Vulnerability{}
+	for _, v := range vulns {
+		if v.Epss != nil && v.Epss.Score >= min {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// FindCveDuplicates returns the vulnerabilities other than vid that list the given CVE.
+func FindCveDuplicates(vid int64, cve string) ([]*vars.Vulnerability, error) {
+	var dups []*vars.Vulnerability
+	vulns, err := FindVulnerabilitiesByCve(cve)
+	if err != nil {
+		return dups, err
+	}
+	for _, v := range vulns {
+		if v.ID != vid {
+			dups = append(dups, v)
+		}
+	}
+	return dups, nil
+}
+
+// FindVulnerabilitiesByCve returns the vulnerabilities that list the given CVE.
+func FindVulnerabilitiesByCve(cve string) ([]*vars.Vulnerability, error) {
+	var vulns []*vars.Vulnerability
+	ids, err := vars.GetVulnIDsByCve(strings.TrimSpace(cve))
+	if !vars.IsNilErr(err) {
+		return vulns, err
+	}
+	for _, id := range *ids {
+		vuln, err := GetVulnerability(id)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns, nil
+}
+
+// GetEmployeeByID returns an Employee object with the given empid.
+func GetEmployeeByID(eid int64) (*vars.Employee, error) {
+	return vars.GetEmployee(eid)
+}
+
+// GetEmployeeByUsername returns an Employee object with the given username.
+func GetEmployeeByUsername(username string) (*vars.Employee, error) {
+	id, err := vars.GetEmpID(username)
+	if !vars.IsNilErr(err) {
+		var emp vars.Employee
+		return &emp, err
+	}
+	return vars.GetEmployee(id)
+}
+
+// GetEmployees returns a slice of pointers to Employee objects.
+func GetEmployees() ([]*vars.Employee, error) {
+	return vars.GetEmployees()
+}
+
+// GetClosedVulnerabilities builds/returns a slice of pointers to Vulnerabilities that
+// have a non-NULL 'mitigated' date.
+func GetClosedVulnerabilities() ([]*vars.Vulnerability, error) {
+	var vulns []*vars.Vulnerability
+
+	// Get a slice of IDs associated with open vulnerabilities
+	ids, err := vars.GetClosedVulnIDs()
+	if !vars.IsNilErr(err) {
+		return vulns, err
+	}
+
+	// For each ID get the associated vulnerability object
+	for _, id := range *ids {
+		vuln, err := GetVulnerability(id)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns, nil
+}
+
+// GetAttachMaxSize returns the configured maximum attachment size in bytes.
+func GetAttachMaxSize() int64 {
+	if vars.Conf.AttachMaxSize > 0 {
+		return vars.Conf.AttachMaxSize
+	}
+	return vars.DefaultAttachMaxSize
+}
+
+// GetAttachment retrieves/returns the attachment metadata with the given id.
+func GetAttachment(aid int64) (*vars.Attachment, error) {
+	return vars.GetAttachment(aid)
+}
+
+// GetAttachmentContents retrieves/returns the contents of the attachment from the blob store. The
+// SHA-256 checksum of the contents is verified against the one recorded when the file was attached.
+func GetAttachmentContents(att *vars.Attachment) ([]byte, error) {
+	store, err := vars.GetBlobStore()
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	data, err := store.Get(att.StoreKey)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != att.Sha256 {
+		return nil, errors.New("Varsapi: GetAttachmentContents: Checksum of the stored contents does not match")
+	}
+	return data, nil
+}
+
+// GetAttachments retrieves/returns a slice of pointers to the attachments for the given vulnid.
+func GetAttachments(vid int64) ([]*vars.Attachment, error) {
+	return vars.GetAttachments(vid)
+}
+
+// GetCatalogEntry returns the NVD catalog entry of the CVE.
+func GetCatalogEntry(cve string) (*vars.CatalogEntry, error) {
+	return vars.GetCatalogEntry(cve)
+}
+
+// GetConfig retrieves/returns the Config object that was created in VARS.
 func GetConfig() vars.Config {
 	return vars.Conf
 }
 
-// GetCves returns a pointer to a slice of cves associated with the vulnid.
-func GetCves(vid int64) (*[]string, error) {
-	return vars.GetCves(vid)
+// GetCves returns a pointer to a slice of cves associated with the vulnid.
+func GetCves(vid int64) (*[]string, error) {
+	return vars.GetCves(vid)
+}
+
+// GetDossier returns the dossier of the vulnerability: the vulnerability, the names of its finder and initiator,
+// the timeline of its dates and its notes that are not deleted.
+func GetDossier(vid int64) (*vars.Dossier, error) {
+	vuln, err := GetVulnerability(vid)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	d := vars.Dossier{Vuln: vuln, Generated: time.Now()}
+	names := make(map[int64]string)
+	name := func(eid int64) (string, error) {
+		if n, ok := names[eid]; ok {
+			return n, nil
+		}
+		emp, err := vars.GetEmployee(eid)
+		if err != nil {
+			if vars.IsNoRowsError(err) {
+				return "", nil
+			}
+			return "", err
+		}
+		names[eid] = strings.TrimSpace(emp.FirstName + " " + emp.LastName)
+		return names[eid], nil
+	}
+	if d.Finder, err = name(vuln.Finder); err != nil {
+		return nil, err
+	}
+	if d.Initiator, err = name(vuln.Initiator); err != nil {
+		return nil, err
+	}
+
+	event := func(date time.Time, format string, a ...interface{}) {
+		d.Timeline = append(d.Timeline, &vars.DossierEvent{Date: date, Event: fmt.Sprintf(format, a...)})
+	}
+	event(vuln.Dates.Initiated, "Assessment started")
+	if vuln.Dates.Published.Valid {
+		event(vuln.Dates.Published.Time, "Vulnerability published")
+	}
+	for _, k := range vuln.Kev {
+		event(k.DateAdded, "%s added to the CISA KEV catalog", k.Cve)
+		if k.DueDate.Valid {
+			event(k.DueDate.Time, "CISA KEV due date for %s", k.Cve)
+		}
+	}
+	for _, a := range vuln.AffSystems {
+		if a.Evidence != nil {
+			event(a.Evidence.Mitigated, "%s mitigated according to %s scan %s", a.Sys.Name, a.Evidence.Scanner, a.Evidence.Scan)
+		}
+	}
+	for _, t := range vuln.Tasks {
+		if t.Due.Valid {
+			status := "open"
+			if t.Done {
+				status = "done"
+			}
+			event(t.Due.Time, "Task due: %s (%s)", t.Title, status)
+		}
+	}
+	if vuln.Dates.Mitigated.Valid {
+		event(vuln.Dates.Mitigated.Time, "Mitigated on all systems")
+	}
+	sort.SliceStable(d.Timeline, func(i, j int) bool { return d.Timeline[i].Date.Before(d.Timeline[j].Date) })
+
+	// Order the notes into threads: each note is followed by its replies, oldest first. The replies to a deleted
+	// note stay in its place.
+	notes, err := vars.GetNotes(vid)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Added.Before(notes[j].Added) })
+	ids := make(map[int64]bool)
+	for _, note := range notes {
+		ids[note.ID] = true
+	}
+	replies := make(map[int64][]*vars.Note)
+	var roots []*vars.Note
+	for _, note := range notes {
+		if note.Parent.Valid && ids[note.Parent.Int64] {
+			replies[note.Parent.Int64] = append(replies[note.Parent.Int64], note)
+		} else {
+			roots = append(roots, note)
+		}
+	}
+	var thread func(ns []*vars.Note) error
+	thread = func(ns []*vars.Note) error {
+		for _, note := range ns {
+			if !note.Deleted.Valid {
+				author, err := name(note.EmpID)
+				if err != nil {
+					return err
+				}
+				d.Notes = append(d.Notes, &vars.DossierNote{Author: author, Added: note.Added, Note: note.Note, Reply: note.Parent.Valid})
+			}
+			if err := thread(replies[note.ID]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := thread(roots); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetKevMatches returns the vulnerabilities that were matched to the KEV catalog since the given time.
+func GetKevMatches(since time.Time) ([]*vars.KevMatch, error) {
+	return vars.GetKevMatches(since)
+}
+
+// GetNoteAuthor returns the empid of the author of the note.
+func GetNoteAuthor(noteid int64) (int64, error) {
+	return vars.GetNoteAuthor(noteid)
+}
+
+// GetNote retrieves/returns the note with the given noteid.
+func GetNote(noteid int64) (*vars.Note, error) {
+	return vars.GetNote(noteid)
+}
+
+// GetNoteRevisions retrieves/returns the previous versions of the note with the given noteid, oldest first.
+func GetNoteRevisions(noteid int64) ([]*vars.NoteRevision, error) {
+	return vars.GetNoteRevisions(noteid)
+}
+
+// GetNotes retrieves/returns a slice of pointers to all note objects (including deleted notes) for the given vulnid.
+func GetNotes(vid int64) ([]*vars.Note, error) {
+	notes, err := vars.GetNotes(vid)
+	if !vars.IsNilErr(err) {
+		return notes, err
+	}
+	for _, note := range notes {
+		ments, err := vars.GetNoteMentions(note.ID)
+		if !vars.IsNilErr(err) {
+			return notes, err
+		}
+		note.Mentions = *ments
+	}
+	return notes, nil
+}
+
+// ParseMentions returns the usernames mentioned (@username) in the note.
+func ParseMentions(note string) []string {
+	var names []string
+	for _, m := range MentionRegexp.FindAllStringSubmatch(note, -1) {
+		if !stringInSlice(m[1], &names) {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// GetOpenVulnerabilities builds/returns a slice of pointers to Vulnerabilities that
+// have a NULL 'mitigated' date.
+func GetOpenVulnerabilities() ([]*vars.Vulnerability, error) {
+	var vulns []*vars.Vulnerability
+
+	// Get a slice of IDs associated with open vulnerabilities
+	ids, err := vars.GetOpenVulnIDs()
+	if !vars.IsNilErr(err) {
+		return vulns, err
+	}
+
+	// For each ID get the associated vulnerability object
+	for _, id := range *ids {
+		vuln, err := GetVulnerability(id)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vulns = append(vulns, vuln)
+	}
+	return vulns, nil
+}
+
+// GetRoles returns the roles known to VARS. The default roles are replaced by the roles of the
+// same name defined in the database, which are replaced by the roles defined in the configuration.
+func GetRoles() (vars.Roles, error) {
+	dbRoles, err := vars.GetRolePermissions()
+	if err != nil {
+		return nil, err
+	}
+	return vars.BuildRoles(vars.DefaultRoles, dbRoles, vars.Conf.Roles), nil
+}
+
+// GetSbomCandidates returns the open vulnerabilities that may affect the system through the components imported from
+// its SBOM, and that the system is not yet affected by. See ImportSbom.
+func GetSbomCandidates(sid int64) ([]*vars.SbomCandidate, error) {
+	sys, err := vars.GetSystem(sid)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	comps, err := vars.GetSysComponents(sid)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	return sbomCandidates(sys, comps)
+}
+
+// GetSystem retrieves/returns the system with the given id.
+func GetSystem(sid int64) (*vars.System, error) {
+	sys, err := vars.GetSystem(sid)
+	if !vars.IsNilErr(err) {
+		return sys, err
+	}
+	addrs, err := vars.GetSysAddrs(sid)
+	if !vars.IsNilErr(err) {
+		return sys, err
+	}
+	sys.Addresses = *addrs
+	ports, err := vars.GetSysPorts(sid)
+	if !vars.IsNilErr(err) {
+		return sys, err
+	}
+	sys.Ports = ports
+	return sys, nil
+}
+
+// GetSystemByName retrieves/returns the system with the given name.
+func GetSystemByName(name string) (*vars.System, error) {
+	id, err := vars.GetSystemID(name)
+	if !vars.IsNilErr(err) {
+		var s vars.System
+		return &s, err
+	}
+	return GetSystem(id)
+}
+
+// GetSystemComponents retrieves/returns the components of the system imported from its SBOM.
+func GetSystemComponents(sid int64) ([]*vars.Component, error) {
+	return vars.GetSysComponents(sid)
+}
+
+// GetSystemsByState retrieves/returns the systems with the given state.
+func GetSystemsByState(state string) ([]*vars.System, error) {
+	return vars.GetSystemsByState(state)
+}
+
+// GetSystems retrieves/returns a slice of pointers to all System objects.
+func GetSystems() ([]*vars.System, error) {
+	return vars.GetSystems()
+}
+
+// GetTask returns the remediation task with the given ID.
+func GetTask(tid int64) (*vars.Task, error) {
+	return vars.GetTask(tid)
+}
+
+// GetTasks returns the remediation tasks of the vulnerability.
+func GetTasks(vid int64) ([]*vars.Task, error) {
+	return vars.GetTasks(vid)
+}
+
+// GetTemplate returns the vulnerability template with the given ID, including its references.
+func GetTemplate(tid int64) (*vars.Template, error) {
+	t, err := vars.GetTemplate(tid)
+	if !vars.IsNilErr(err) {
+		return t, err
+	}
+	refs, err := vars.GetTemplateRefs(tid)
+	if !vars.IsNilErr(err) {
+		return t, err
+	}
+	t.References = *refs
+	return t, nil
+}
+
+// GetTemplates returns all of the vulnerability templates, including their references.
+func GetTemplates() ([]*vars.Template, error) {
+	tmpls, err := vars.GetTemplates()
+	if !vars.IsNilErr(err) {
+		return tmpls, err
+	}
+	for _, t := range tmpls {
+		refs, err := vars.GetTemplateRefs(t.ID)
+		if !vars.IsNilErr(err) {
+			return tmpls, err
+		}
+		t.References = *refs
+	}
+	return tmpls, nil
+}
+
+// GetVarsNullBool creates/returns a VarsNullBool object using the given boolean paramter.
+func GetVarsNullBool(b bool) vars.VarsNullBool {
+	return vars.VarsNullBool{sql.NullBool{Bool: b, Valid: true}}
+}
+
+// GetVarsNullInt64 creates/returns a VarsNullInt64 object using the given integer paramter.
+func GetVarsNullInt64(i int64) vars.VarsNullInt64 {
+	return vars.VarsNullInt64{sql.NullInt64{Int64: i, Valid: true}}
+}
+
+// GetVarsNullString creates/returns a VarsNullString object using the given string paramter.
+func GetVarsNullString(str string) vars.VarsNullString {
+	return vars.VarsNullString{sql.NullString{String: str, Valid: true}}
+}
+
+// GetVarsNullTime creates/returns a VarsNullTime object using the given time paramter.
+func GetVarsNullTime(t time.Time) vars.VarsNullTime {
+	return vars.VarsNullTime{pq.NullTime{Time: t, Valid: true}}
+}
+
+// GetMergedInto returns the ID of the vulnerability that the given vulnerability was merged into.
+func GetMergedInto(vid int64) (int64, error) {
+	return vars.GetMergedInto(vid)
+}
+
+// GetVulnID returns the vulnid associated with the vname.
+func GetVulnID(vname string) (int64, error) {
+	return vars.GetVulnID(vname)
+}
+
+// GetVulnerabilities retrieves/returns all vulnerabilities.
+func GetVulnerabilities() ([]*vars.Vulnerability, error) {
+	// Get vulnerabilities (vuln fields)
+	vulns, err := vars.GetVulnerabilities()
+	if !vars.IsNilErr(err) {
+		return vulns, err
+	}
+
+	for _, vuln := range vulns {
+		//Get impact
+		cvss, cvssLink, cscore, err := vars.GetImpact(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Cvss = cvss
+		vuln.CvssLink = cvssLink
+		vuln.CorpScore = cscore
+
+		// Get dates
+		vd, err := vars.GetVulnDates(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Dates = *vd
+
+		// Get cves
+		cves, err := vars.GetCves(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Cves = *cves
+
+		// Get cwes
+		cwes, err := vars.GetCwes(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Cwes = *cwes
+
+		// Get tickets
+		ticks, err := vars.GetTickets(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Tickets = *ticks
+
+		// Get references
+		refs, err := vars.GetReferences(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.References = *refs
+
+		// Get exploit
+		exploit, exploitable, err := vars.GetExploit(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Exploit = exploit
+		vuln.Exploitable = exploitable
+
+		// Get KEV entries
+		kev, err := vars.GetKevByVuln(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Kev = kev
+
+		// Get EPSS score
+		epss, err := vars.GetEpssByVuln(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Epss = epss
+
+		// Get affected
+		affs, err := vars.GetAffected(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.AffSystems = affs
+
+		// Get tasks
+		tasks, err := vars.GetTasks(vuln.ID)
+		if !vars.IsNilErr(err) {
+			return vulns, err
+		}
+		vuln.Tasks = tasks
+		vuln.Progress = TaskProgress(tasks)
+	}
+	return vulns, nil
+}
+
+// GetVulnerability retrieves/returns the vulnerability with the given id.
+func GetVulnerability(vid int64) (*vars.Vulnerability, error) {
+	var v vars.Vulnerability
+
+	// Get vulnerability fields
+	vuln, err := vars.GetVulnerability(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+
+	//Get impact
+	cvss, cvssLink, cscore, err := vars.GetImpact(vuln.ID)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Cvss = cvss
+	vuln.CvssLink = cvssLink
+	vuln.CorpScore = cscore
+
+	// Get dates
+	vd, err := vars.GetVulnDates(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Dates = *vd
+
+	// Get Cves
+	cves, err := vars.GetCves(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Cves = *cves
+
+	// Get Cwes
+	cwes, err := vars.GetCwes(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Cwes = *cwes
+
+	// Get tickets
+	ticks, err := vars.GetTickets(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Tickets = *ticks
+
+	// Get references
+	refs, err := vars.GetReferences(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.References = *refs
+
+	// Get exploit
+	exploit, exploitable, err := vars.GetExploit(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Exploit = exploit
+	vuln.Exploitable = exploitable
+
+	// Get KEV entries
+	kev, err := vars.GetKevByVuln(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Kev = kev
+
+	// Get EPSS score
+	epss, err := vars.GetEpssByVuln(vid)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Epss = epss
+
+	// Get affected
+	affs, err := vars.GetAffected(vuln.ID)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.AffSystems = affs
+
+	// Get related
+	rels, err := vars.GetRelated(vuln.ID)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Related = rels
+
+	// Get tasks
+	tasks, err := vars.GetTasks(vuln.ID)
+	if !vars.IsNilErr(err) {
+		return &v, err
+	}
+	vuln.Tasks = tasks
+	vuln.Progress = TaskProgress(tasks)
+
+	return vuln, nil
+}
+
+// GetVulnerabilityByName retrieves/returns the vulnerability with the given name.
+func GetVulnerabilityByName(name string) (*vars.Vulnerability, error) {
+	id, err := vars.GetVulnID(name)
+	if !vars.IsNilErr(err) {
+		var v vars.Vulnerability
+		return &v, err
+	}
+	return GetVulnerability(id)
+}
+
+// Import restores an archive written by Export in a single transaction, merging it into the data of VARS or
+// replacing it (see vars.ArchiveOptions). The archive is validated before anything is changed: its version must be
+// supported, its tables and columns known and every reference of a row must point to a row of the archive. The
+// serial IDs of the rows are remapped to the IDs they get in VARS and the references are updated to match. If
+// opts.DryRun is set the transaction is rolled back.
+func Import(db *sql.DB, r io.Reader, opts *vars.ArchiveOptions) (*vars.ArchiveReport, error) {
+	// The attachment contents are spooled to a temporary directory, and only read one at a time when stored
+	dir, err := ioutil.TempDir("", "vars-import")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	man, tables, atts, err := readArchive(r, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateArchive(man, tables, atts); err != nil {
+		return nil, err
+	}
+	rep := vars.ArchiveReport{DryRun: opts.DryRun, Replace: opts.Replace, Version: man.Version,
+		Inserted: make(map[string]int), Matched: make(map[string]int), Skipped: make(map[string]int)}
+	store, err := vars.GetBlobStore()
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	var oldKeys, newKeys []string
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+			// Contents outside of the database are not removed by the rollback
+			if !store.Transactional() {
+				for _, key := range newKeys {
+					store.Delete(nil, key)
+				}
+			}
+		}
+	}()
+
+	// Delete the current data, and the attachment contents with it
+	if opts.Replace {
+		rows, err := vars.GetArchiveRows(tx, archiveTable("attachments"))
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		for _, row := range rows {
+			key, _ := row["storekey"].(string)
+			oldKeys = append(oldKeys, key)
+		}
+		err = vars.TruncateArchiveTables(tx)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		if store.Transactional() {
+			for _, key := range oldKeys {
+				if err := store.Delete(tx, key); !vars.IsNilErr(err) {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// remap maps the serial IDs of the archive to those in VARS, and matched holds those of rows merged into
+	// existing ones, by table
+	remap := make(map[string]map[int64]int64)
+	matched := make(map[string]map[int64]bool)
+	for _, t := range vars.ArchiveTables {
+		remap[t.Name] = make(map[int64]int64)
+		matched[t.Name] = make(map[int64]bool)
+	}
+	for _, t := range vars.ArchiveTables {
+		for _, row := range tables[t.Name] {
+			if t.ReplaceOnly && !opts.Replace {
+				rep.Skipped[t.Name]++
+				continue
+			}
+
+			// Rows owned by a row that was merged or skipped are skipped
+			if t.Owner != "" && !opts.Replace {
+				ref := archiveRef(t, t.Owner)
+				old, _ := archiveInt(row[t.Owner])
+				if _, ok := remap[ref.Table][old]; !ok || matched[ref.Table][old] {
+					rep.Skipped[t.Name]++
+					continue
+				}
+			}
+			var old int64
+			if t.Serial != "" {
+				old, _ = archiveInt(row[t.Serial])
+			}
+			if t.Match != "" && !opts.Replace {
+				id, err := vars.GetArchiveMatch(tx, t, row[t.Match])
+				if vars.IsNilErr(err) {
+					remap[t.Name][old] = id
+					matched[t.Name][old] = true
+					rep.Matched[t.Name]++
+					continue
+				}
+				if !vars.IsNoRowsError(err) {
+					return nil, err
+				}
+			}
+
+			// Remap the references and the serial ID
+			mapped := true
+			for _, ref := range t.Refs {
+				if len(ref.Columns) != 1 || archiveTable(ref.Table).Serial != ref.Keys[0] || row[ref.Columns[0]] == nil {
+					continue
+				}
+				id, _ := archiveInt(row[ref.Columns[0]])
+				if row[ref.Columns[0]], mapped = remap[ref.Table][id]; !mapped {
+					break
+				}
+			}
+			if !mapped {
+				rep.Skipped[t.Name]++
+				continue
+			}
+			if t.Serial != "" {
+				id := old
+				if !opts.Replace {
+					id, err = vars.NextArchiveID(tx, t)
+					if !vars.IsNilErr(err) {
+						return nil, err
+					}
+				}
+				row[t.Serial] = id
+			}
+			if t.Name == "attachments" {
+				data, err := ioutil.ReadFile(atts[old])
+				if err != nil {
+					return nil, err
+				}
+				key, err := store.Put(tx, data)
+				if !vars.IsNilErr(err) {
+					return nil, err
+				}
+				newKeys = append(newKeys, key)
+				row["storekey"] = key
+			}
+
+			ok, err := vars.InsertArchiveRow(tx, t, row, opts.Replace)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			if !ok {
+				// The contents stored for a skipped attachment are not referenced by any row
+				if t.Name == "attachments" {
+					key := newKeys[len(newKeys)-1]
+					newKeys = newKeys[:len(newKeys)-1]
+					if err := store.Delete(tx, key); !vars.IsNilErr(err) {
+						return nil, err
+					}
+				}
+				rep.Skipped[t.Name]++
+				continue
+			}
+			if t.Serial != "" {
+				remap[t.Name][old], _ = archiveInt(row[t.Serial])
+			}
+			rep.Inserted[t.Name]++
+		}
+	}
+	if opts.Replace {
+		err = vars.ResetArchiveSequences(tx)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+	}
+
+	if opts.DryRun {
+		return &rep, nil
+	}
+
+	// Commit the transaction. The new contents are removed by the deferred rollback if the commit fails.
+	if e := tx.Commit(); e != nil {
+		return nil, e
+	}
+	rollback = false
+	if !store.Transactional() {
+		deleteBlobs(store, oldKeys)
+	}
+	return &rep, nil
+}
+
+// ImportCatalog adds the entries to the NVD catalog in a single transaction. Entries that are already in the catalog
+// are only replaced if they were modified more recently, so a modified feed can be re-imported on top of the full
+// feeds. It returns the number of entries that were imported and the number that were already up to date.
+func ImportCatalog(db *sql.DB, entries []*vars.CatalogEntry) (int, int, error) {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	imported, unchanged, err := importCatalog(tx, entries, false)
+	if !vars.IsNilErr(err) {
+		return 0, 0, err
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return 0, 0, e
+	}
+	return imported, unchanged, nil
+}
+
+// ImportEpss adds the EPSS scores in a single transaction. Scores that are already stored are only replaced by
+// scores of a newer model date. It returns the number of scores that were imported and the number that were
+// already up to date.
+func ImportEpss(db *sql.DB, scores []*vars.EpssScore) (int, int, error) {
+	var imported, unchanged int
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	for _, s := range scores {
+		s.Cve = strings.ToUpper(s.Cve)
+		err = vars.UpsertEpssScore(tx, s)
+		if vars.IsNoRowsError(err) {
+			unchanged++
+			continue
+		}
+		if !vars.IsNilErr(err) {
+			return 0, 0, err
+		}
+		imported++
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return 0, 0, e
+	}
+	return imported, unchanged, nil
+}
+
+// ImportGrype imports the Grype JSON output read from r. The scanned image is recorded as a system of type
+// container-image. See ImportScan.
+func ImportGrype(db *sql.DB, r io.Reader, opts *vars.ScanOptions) (*vars.ScanDiff, error) {
+	rep, err := grype.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return importScan(db, rep, opts, true)
+}
+
+// ImportInventory records the hosts of the scan report in the systems inventory in a single transaction. The hosts
+// are resolved to systems like ImportScan does, and the hosts that are unknown are added as systems. The OS guess is
+// filled into systems without an OS, and the new addresses and the open ports of the hosts are recorded. The active
+// systems with an address in the targets of the scan that were not seen are reported as candidates to be set
+// inactive. If opts.DryRun is set the transaction is rolled back and the returned diff holds the changes that would
+// have been made.
+func ImportInventory(db *sql.DB, rep *vars.ScanReport, opts *vars.ScanOptions) (*vars.InventoryDiff, error) {
+	diff := vars.InventoryDiff{Scanner: rep.Scanner, DryRun: opts.DryRun}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	var sdiff vars.ScanDiff
+	imp := newScanImport(tx, rep.Scanner, opts, &sdiff, true)
+	seen := make(map[int64]bool)
+	for _, host := range rep.Hosts {
+		added := len(sdiff.NewSystems)
+		sys, err := imp.system(host)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		if sys == nil || seen[sys.ID] {
+			continue
+		}
+		seen[sys.ID] = true
+
+		// Record the open ports of the systems that were added
+		if len(sdiff.NewSystems) > added {
+			for _, p := range host.Ports {
+				err = vars.InsertSysPort(tx, sys.ID, p)
+				if !vars.IsNilErr(err) {
+					return nil, err
+				}
+			}
+			sys.Ports = host.Ports
+			diff.NewSystems = append(diff.NewSystems, sys)
+			continue
+		}
+
+		change := vars.SystemChange{System: sys}
+		if host.OpSys != "" && (sys.OpSys == "" || strings.EqualFold(sys.OpSys, "unknown")) {
+			err = vars.UpdateSysOS(tx, sys.ID, host.OpSys)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			change.OpSys = host.OpSys
+		}
+
+		// Record the addresses that aren't known yet
+		addrs, err := vars.GetSysAddrs(sys.ID)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		for _, a := range []string{host.Address, host.Name} {
+			a = strings.ToLower(a)
+			if a == "" || stringInSlice(a, addrs) || stringInSlice(a, &change.NewAddresses) {
+				continue
+			}
+			_, err = vars.GetSysIDByAddrtx(tx, a)
+			if vars.IsNilErr(err) {
+				// The address belongs to another system
+				continue
+			}
+			if !vars.IsNoRowsError(err) {
+				return nil, err
+			}
+			err = vars.InsertSysAddr(tx, sys.ID, a)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			change.NewAddresses = append(change.NewAddresses, a)
+		}
+
+		// Replace the open ports if they changed
+		ports, err := vars.GetSysPorts(sys.ID)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		change.OpenedPorts = portsNotIn(host.Ports, ports)
+		change.ClosedPorts = portsNotIn(ports, host.Ports)
+		if len(change.OpenedPorts) > 0 || len(change.ClosedPorts) > 0 {
+			err = vars.DeleteSysPorts(tx, sys.ID)
+			if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+				return nil, err
+			}
+			for _, p := range host.Ports {
+				err = vars.InsertSysPort(tx, sys.ID, p)
+				if !vars.IsNilErr(err) {
+					return nil, err
+				}
+			}
+		}
+
+		if change.OpSys == "" && len(change.NewAddresses) == 0 && len(change.OpenedPorts) == 0 && len(change.ClosedPorts) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, &change)
+	}
+
+	// Find the active systems in the scanned networks that were not seen
+	targets := scanTargets(rep.Targets)
+	systems, err := vars.GetSystemsByState("active")
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	for _, sys := range systems {
+		if seen[sys.ID] {
+			continue
+		}
+		addrs, err := vars.GetSysAddrs(sys.ID)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		sys.Addresses = *addrs
+		for _, a := range sys.Addresses {
+			if ip := net.ParseIP(a); ip != nil && ipInNets(ip, targets) {
+				diff.InactiveCandidates = append(diff.InactiveCandidates, sys)
+				break
+			}
+		}
+	}
+
+	if opts.DryRun {
+		return &diff, nil
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return nil, e
+	}
+	return &diff, nil
+}
+
+// ImportKev adds the entries to the KEV catalog in a single transaction, replacing the entries that are already
+// in it. The vulnerabilities with a CVE that is in the catalog and was not matched by an earlier import are flagged
+// as exploitable and returned so that they can be rescored.
+func ImportKev(db *sql.DB, entries []*vars.KevEntry) ([]*vars.KevMatch, error) {
+	var matches []*vars.KevMatch
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return matches, err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	for _, e := range entries {
+		e.Cve = strings.ToUpper(e.Cve)
+		err = vars.UpsertKevEntry(tx, e)
+		if !vars.IsNilErr(err) {
+			return matches, err
+		}
+	}
+
+	// Record the new matches and flag their vulnerabilities
+	matches, err = matchKev(tx)
+	if !vars.IsNilErr(err) {
+		return matches, err
+	}
+	for _, m := range matches {
+		vuln, err := vars.GetVulnerability(m.VulnID)
+		if !vars.IsNilErr(err) {
+			return matches, err
+		}
+		m.VulnName = vuln.Name
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return matches, e
+	}
+	return matches, nil
+}
+
+// ImportNessus imports the Nessus v2 report read from r. See ImportScan.
+func ImportNessus(db *sql.DB, r io.Reader, opts *vars.ScanOptions) (*vars.ScanDiff, error) {
+	rep, err := nessus.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return ImportScan(db, rep, opts)
+}
+
+// ImportNmap imports the Nmap XML output read from r into the systems inventory. See ImportInventory.
+func ImportNmap(db *sql.DB, r io.Reader, opts *vars.ScanOptions) (*vars.InventoryDiff, error) {
+	rep, err := nmap.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return ImportInventory(db, rep, opts)
+}
+
+// ImportOSV reads the OSV records of the directory (see osv.ParseDir) in a single transaction. By default the
+// records are added to the CVE catalog, one entry per CVE. Unlike the NVD feeds they only fill in what the catalog
+// is missing, so they never replace the NVD summary and CVSS vector of a CVE. With opts.Vulns a vulnerability is
+// started for each record instead, unless one of its CVEs or its name is already tracked; the vulnerability is
+// named after the ID and the summary of the record. Withdrawn records are skipped. If opts.DryRun is set the
+// transaction is rolled back.
+func ImportOSV(db *sql.DB, dir string, opts *vars.OsvOptions) (*vars.OsvReport, error) {
+	records, err := osv.ParseDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	rep := vars.OsvReport{DryRun: opts.DryRun, Records: len(records)}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	var entries []*vars.CatalogEntry
+	tracked := make(map[string]bool)
+	for _, rec := range records {
+		if rec.Withdrawn != "" {
+			rep.Skipped = append(rep.Skipped, rec.ID)
+			continue
+		}
+		if opts.Vulns {
+			err = importOsvVuln(tx, rec, opts, tracked, &rep)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			continue
+		}
+		es, err := osv.ToCatalogEntries(rec)
+		if err != nil {
+			return nil, errors.New("Varsapi: ImportOSV: " + rec.ID + ": " + err.Error())
+		}
+		if len(es) == 0 {
+			rep.Skipped = append(rep.Skipped, rec.ID)
+		}
+		entries = append(entries, es...)
+	}
+	rep.Imported, rep.Unchanged, err = importCatalog(tx, entries, true)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return &rep, nil
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return nil, e
+	}
+	return &rep, nil
+}
+
+// ImportOpenVas imports the OpenVAS / GVM XML report read from r. See ImportScan.
+func ImportOpenVas(db *sql.DB, r io.Reader, opts *vars.ScanOptions) (*vars.ScanDiff, error) {
+	rep, err := openvas.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return ImportScan(db, rep, opts)
+}
+
+// ImportSbom replaces the components of the system with the components of the CycloneDX or SPDX document read from r,
+// in a single transaction. The returned diff lists the components that were added and removed, and the candidate
+// affected rows: the open vulnerabilities that the system is not yet affected by and that list one of its components.
+// A component matches a vulnerability when the SBOM reports one of the vulnerability's CVEs for it, or when a scan
+// import recorded a vulnerable package of the same name whose installed version is the component version, or whose
+// installed version is older and fixed version newer than the component version. Older component versions are not
+// matched: the scan only shows that the versions from the installed one up to the fixed one are vulnerable. The
+// candidates are not added as affected. If opts.DryRun is set the transaction is rolled back.
+func ImportSbom(db *sql.DB, sid int64, r io.Reader, opts *vars.ScanOptions) (*vars.SbomDiff, error) {
+	bom, err := sbom.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	sys, err := vars.GetSystem(sid)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	old, err := vars.GetSysComponents(sid)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	diff := vars.SbomDiff{Format: bom.Format, DryRun: opts.DryRun, System: sys.Name, Components: len(bom.Components)}
+	diff.Added = componentsNotIn(bom.Components, old)
+	diff.Removed = componentsNotIn(old, bom.Components)
+	diff.Candidates, err = sbomCandidates(sys, bom.Components)
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	if opts.DryRun {
+		return &diff, nil
+	}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.DeleteSysComponents(tx, sid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return nil, err
+	}
+	for _, c := range bom.Components {
+		err = vars.InsertSysComponent(tx, sid, c)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return nil, e
+	}
+	return &diff, nil
+}
+
+// ImportScan records the findings of the scan report in a single transaction. The hosts are resolved to systems by
+// name and then by address; unknown hosts are added as systems if ScanCreateSystems is configured. The findings are
+// resolved to vulnerabilities by the scanner plugins mapped to them, then by CVE, and new vulnerabilities are started
+// for the rest. The systems that are not yet affected by the vulnerabilities are added as affected, and the vulnerable
+// packages reported for a finding replace the packages recorded on the affected system. If opts.DryRun is set the
+// transaction is rolled back and the returned diff holds the changes that would have been made.
+//
+// If opts.Reconcile is set the affected systems of the scanned hosts are made to match the scan. A system is
+// mitigated, with the scan recorded as evidence, when an open vulnerability mapped to a plugin of the scanner is no
+// longer reported on it, and a system mitigated by an earlier scan is reopened when the vulnerability is reported
+// again. Systems that were mitigated or reopened by hand, and systems of closed vulnerabilities, are left alone and
+// reported as conflicts.
+func ImportScan(db *sql.DB, rep *vars.ScanReport, opts *vars.ScanOptions) (*vars.ScanDiff, error) {
+	return importScan(db, rep, opts, vars.Conf.ScanCreateSystems)
+}
+
+// ImportSystemsCsv adds the systems of the CSV read from r in a single transaction. The header row maps the columns
+// to the SystemsCsvColumns by name, case insensitively; only the name column is required and the other columns are
+// ignored. Empty type, os and location values are recorded as unknown, and an empty state as active.
+//
+// Each row is validated: the name must be given, must not be in use (see NameIsAvailable) and must not be repeated
+// in the file, the state must be active or inactive, and the addresses must not belong to another system. Rejected
+// rows are listed in the report with their problems. Unless opts.BestEffort is set, a rejected row means that no
+// system is added. If opts.DryRun is set the transaction is rolled back.
+func ImportSystemsCsv(db *sql.DB, r io.Reader, opts *vars.CsvOptions) (*vars.SystemsCsvReport, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("Varsapi: ImportSystemsCsv: The file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	rep := vars.SystemsCsvReport{DryRun: opts.DryRun}
+	cols := make(map[string]int)
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if a, ok := systemsCsvAliases[name]; ok {
+			name = a
+		}
+		if !stringInSlice(name, &SystemsCsvColumns) {
+			rep.Ignored = append(rep.Ignored, h)
+			continue
+		}
+		if _, ok := cols[name]; ok {
+			return nil, errors.New("Varsapi: ImportSystemsCsv: The " + name + " column is repeated")
+		}
+		cols[name] = i
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, errors.New("Varsapi: ImportSystemsCsv: The header has no name column")
+	}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	names := make(map[string]bool)
+	addrs := make(map[string]bool)
+	for row := 1; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(col string) string {
+			if i, ok := cols[col]; ok && i < len(rec) {
+				return csvValue(strings.TrimSpace(rec[i]))
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+		rep.Rows++
+
+		sys := CreateSystem(field("name"), field("type"), field("os"), field("location"), field("description"), strings.ToLower(field("state")))
+		for _, f := range []*string{&sys.Type, &sys.OpSys, &sys.Location} {
+			if *f == "" {
+				*f = "unknown"
+			}
+		}
+		if sys.State == "" {
+			sys.State = "active"
+		}
+		sys.Addresses = strings.FieldsFunc(strings.ToLower(field("addresses")), func(r rune) bool {
+			return r == ';' || r == ',' || r == ' '
+		})
+
+		// Validate the row
+		var problems []string
+		if sys.Name == "" {
+			problems = append(problems, "The name is missing")
+		} else if names[sys.Name] {
+			problems = append(problems, "The name is used by an earlier row")
+		} else {
+			a, err := vars.NameIsAvailable("sys", sys.Name)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			if !a {
+				problems = append(problems, "The name is already in use")
+			}
+		}
+		if sys.State != "active" && sys.State != "inactive" {
+			problems = append(problems, "The state must be active or inactive")
+		}
+		seen := make(map[string]bool)
+		for _, addr := range sys.Addresses {
+			if seen[addr] {
+				problems = append(problems, "The address "+addr+" is repeated")
+				continue
+			}
+			seen[addr] = true
+			if addrs[addr] {
+				problems = append(problems, "The address "+addr+" is used by an earlier row")
+				continue
+			}
+			_, err := vars.GetSysIDByAddrtx(tx, addr)
+			if vars.IsNilErr(err) {
+				problems = append(problems, "The address "+addr+" belongs to another system")
+			} else if !vars.IsNoRowsError(err) {
+				return nil, err
+			}
+		}
+		if len(problems) > 0 {
+			rep.Rejected = append(rep.Rejected, &vars.CsvRowError{Row: row, Name: sys.Name, Errors: problems})
+			continue
+		}
+		names[sys.Name] = true
+		for _, addr := range sys.Addresses {
+			addrs[addr] = true
+		}
+
+		// Add the system
+		err = vars.InsertSystem(tx, sys)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		sys.ID, err = vars.GetSystemIDtx(tx, sys.Name)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		if sys.State != "active" {
+			err = vars.UpdateSysState(tx, sys.ID, sys.State)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+		}
+		for _, addr := range sys.Addresses {
+			err = vars.InsertSysAddr(tx, sys.ID, addr)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+		}
+		rep.Added = append(rep.Added, sys)
+	}
+
+	if opts.DryRun || (len(rep.Rejected) > 0 && !opts.BestEffort) {
+		return &rep, nil
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return nil, e
+	}
+	rep.Committed = true
+	return &rep, nil
+}
+
+// ImportTrivy imports the Trivy JSON output read from r. The scanned image is recorded as a system of type
+// container-image. See ImportScan.
+func ImportTrivy(db *sql.DB, r io.Reader, opts *vars.ScanOptions) (*vars.ScanDiff, error) {
+	rep, err := trivy.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return importScan(db, rep, opts, true)
+}
+
+// IsNilErr returns true if the error is nil, false otherwise.
+func IsNilErr(err error) bool {
+	return vars.IsNilErr(err)
+}
+
+// IsAttachTooLargeError returns true if the error is caused by an attachment exceeding the maximum size
+func IsAttachTooLargeError(err error) bool {
+	return vars.IsAttachTooLargeError(err)
+}
+
+// IsAttachTypeNotAllowedError returns true if the error is caused by an attachment type that is not allowed
+func IsAttachTypeNotAllowedError(err error) bool {
+	return vars.IsAttachTypeNotAllowedError(err)
+}
+
+// IsInvalidValueError returns true if the error is caused by a value provided by the user that is not valid
+func IsInvalidValueError(err error) bool {
+	return vars.IsInvalidValueError(err)
+}
+
+// IsNameNotAvailableError returns true if the error is caused by name not being available
+func IsNameNotAvailableError(err error) bool {
+	return vars.IsNameNotAvailableError(err)
+}
+
+// IsNoRowsError returns true if the error is caused by no rows being effected
+func IsNoRowsError(err error) bool {
+	return vars.IsNoRowsError(err)
 }
 
-// GetNoteAuthor returns the empid of the author of the note.
-func GetNoteAuthor(noteid int64) (int64, error) {
-	return vars.GetNoteAuthor(noteid)
-}
+// MergeVulnerabilities merges the vulnerability dropID into keepID in a single transaction. The CVEs, CWEs, tickets,
+// references and affected systems with their vulnerable packages and scan evidence are unioned (an affected system
+// mitigated in either is mitigated), the notes, attachments, tasks and links are moved, keepID is exploitable if
+// either is, and the earliest initiated date is kept. The dropped vulnerability is then deleted and the merge is
+// recorded so that its ID can be redirected to keepID.
+func MergeVulnerabilities(db *sql.DB, keepID, dropID int64) error {
+	if keepID == dropID {
+		return errors.New("Varsapi: MergeVulnerabilities: Cannot merge a vulnerability into itself")
+	}
+	keep, err := GetVulnerability(keepID)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	drop, err := GetVulnerability(dropID)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	// Union CVEs
+	for _, cve := range drop.Cves {
+		err = vars.DeleteCve(tx, dropID, cve)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		dup := false
+		for _, c := range keep.Cves {
+			if strings.EqualFold(c, cve) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			err = vars.InsertCve(tx, keepID, cve)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	}
+
+	// Union CWEs
+	for _, cwe := range drop.Cwes {
+		err = vars.DeleteCwe(tx, dropID, cwe)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		if !stringInSlice(cwe, &keep.Cwes) {
+			err = vars.InsertCwe(tx, keepID, cwe)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	}
+
+	// Union tickets
+	for _, ticket := range drop.Tickets {
+		err = vars.DeleteTicket(tx, dropID, ticket)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		if !stringInSlice(ticket, &keep.Tickets) {
+			err = vars.InsertTicket(tx, keepID, ticket)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	}
+
+	// Union references
+	for _, ref := range drop.References {
+		err = vars.DeleteRef(tx, dropID, ref)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		if !stringInSlice(ref, &keep.References) {
+			err = vars.InsertRef(tx, keepID, ref)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	}
+
+	// Union affected systems. A system mitigated in either vulnerability is mitigated in the result, and keeps the
+	// scan evidence of dropID if it is only mitigated there.
+	err = vars.DeleteVulnScanMitigations(tx, dropID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteVulnAffectedPkgs(tx, dropID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	keepAff := make(map[int64]bool)
+	keepPkgs := make(map[int64]map[string]bool)
+	for _, aff := range keep.AffSystems {
+		keepAff[aff.Sys.ID] = aff.Mitigated
+		keepPkgs[aff.Sys.ID] = make(map[string]bool)
+		for _, p := range aff.Packages {
+			keepPkgs[aff.Sys.ID][p.Package+"\x00"+p.Installed] = true
+		}
+	}
+	for _, aff := range drop.AffSystems {
+		err = vars.DeleteAffected(tx, dropID, aff.Sys.ID)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		mit, ok := keepAff[aff.Sys.ID]
+		if !ok {
+			err = vars.InsertAffected(tx, keepID, aff.Sys.ID, aff.Mitigated)
+		} else if aff.Mitigated && !mit {
+			err = vars.UpdateAffected(tx, keepID, aff.Sys.ID, true)
+		}
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		if aff.Evidence != nil && !mit {
+			err = vars.UpsertScanMitigation(tx, keepID, aff.Sys.ID, aff.Evidence)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+		for _, p := range aff.Packages {
+			if keepPkgs[aff.Sys.ID][p.Package+"\x00"+p.Installed] {
+				continue
+			}
+			err = vars.InsertAffectedPkg(tx, keepID, aff.Sys.ID, p)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	}
+
+	// Re-parent notes, attachments and tasks
+	err = vars.UpdateNotesVuln(tx, dropID, keepID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.UpdateTasksVuln(tx, dropID, keepID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.UpdateAttachmentsVuln(tx, dropID, keepID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
 
-// GetNotes retrieves/returns a slice of pointers to all note objects for the given vulnid.
-func GetNotes(vid int64) ([]*vars.Note, error) {
-	return vars.GetNotes(vid)
-}
+	// Map the scanner plugins of dropID to keepID
+	err = vars.UpdateScanRefsVuln(tx, dropID, keepID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
 
-// GetOpenVulnerabilities builds/returns a slice of pointers to Vulnerabilities that
-// have a NULL 'mitigated' date.
-func GetOpenVulnerabilities() ([]*vars.Vulnerability, error) {
-	var vulns []*vars.Vulnerability
+	// Move links that keepID doesn't already have
+	linked := map[int64]bool{keepID: true}
+	for _, rel := range keep.Related {
+		linked[rel.VulnID] = true
+	}
+	err = vars.DeleteRelatedAll(tx, dropID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	for _, rel := range drop.Related {
+		if linked[rel.VulnID] {
+			continue
+		}
+		switch rel.Type {
+		case vars.RelDuplicatedBy:
+			err = vars.InsertRelated(tx, rel.VulnID, keepID, vars.RelDuplicateOf)
+		case vars.RelSupersededBy:
+			err = vars.InsertRelated(tx, rel.VulnID, keepID, vars.RelSupersedes)
+		default:
+			err = vars.InsertRelated(tx, keepID, rel.VulnID, rel.Type)
+		}
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		linked[rel.VulnID] = true
+	}
 
-	// Get a slice of IDs associated with open vulnerabilities
-	ids, err := vars.GetOpenVulnIDs()
-	if !vars.IsNilErr(err) {
-		return vulns, err
+	// Merge the exploit: keepID is exploitable if either is, and the exploit of dropID is added to that of keepID
+	if drop.Exploitable.Valid || drop.Exploit.Valid {
+		exploitable := keep.Exploitable.Bool || drop.Exploitable.Bool
+		exploit := keep.Exploit.String
+		if d := drop.Exploit.String; d != "" && d != exploit {
+			if exploit != "" {
+				exploit += "\n\n"
+			}
+			exploit += d
+		}
+		err = vars.UpdateExploitable(tx, keepID, exploitable)
+		if vars.IsNilErr(err) && exploit != keep.Exploit.String {
+			err = vars.UpdateExploit(tx, keepID, exploit)
+			if vars.IsNilErr(err) {
+				err = vars.UpdateExploitable(tx, keepID, exploitable)
+			}
+		}
+		if vars.IsNoRowsError(err) {
+			err = vars.InsertExploit(tx, keepID, exploitable, exploit)
+		}
+		if !vars.IsNilErr(err) {
+			return err
+		}
 	}
 
-	// For each ID get the associated vulnerability object
-	for _, id := range *ids {
-		vuln, err := GetVulnerability(id)
+	// Keep the earliest initiated date
+	if drop.Dates.Initiated.Before(keep.Dates.Initiated) {
+		err = vars.UpdateInitDate(tx, keepID, drop.Dates.Initiated)
 		if !vars.IsNilErr(err) {
-			return vulns, err
+			return err
 		}
-		vulns = append(vulns, vuln)
 	}
-	return vulns, nil
-}
 
-// GetSystem retrieves/returns the system with the given id.
-func GetSystem(sid int64) (*vars.System, error) {
-	return vars.GetSystem(sid)
-}
+	// Record the merge and redirect earlier merges into dropID
+	err = vars.UpdateMerges(tx, dropID, keepID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.InsertMerge(tx, dropID, drop.Name, keepID, time.Now())
+	if !vars.IsNilErr(err) {
+		return err
+	}
 
-// GetSystemByName retrieves/returns the system with the given name.
-func GetSystemByName(name string) (*vars.System, error) {
-	id, err := vars.GetSystemID(name)
+	// Delete the rest of dropID. The CVEs moved to keepID are matched against KEV again below.
+	err = vars.DeleteKevMatches(tx, dropID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteExploit(tx, dropID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteDates(tx, dropID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteImpact(tx, dropID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	err = vars.DeleteVulnerability(tx, dropID)
 	if !vars.IsNilErr(err) {
-		var s vars.System
-		return &s, err
+		return err
 	}
-	return vars.GetSystem(id)
+	_, err = matchKev(tx)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
 }
 
-// GetSystemsByState retrieves/returns the systems with the given state.
-func GetSystemsByState(state string) ([]*vars.System, error) {
-	return vars.GetSystemsByState(state)
+// ReadConfig passes the config string to vars.ReadConfig to create the Config object.
+func ReadConfig(config string) error {
+	return vars.ReadConfig(config)
 }
 
-// GetSystems retrieves/returns a slice of pointers to all System objects.
-func GetSystems() ([]*vars.System, error) {
-	return vars.GetSystems()
+// SetRolePermissions replaces the permissions of the role in the database with perms. An invalid value error is
+// returned if perms is empty or holds an unknown permission; use DeleteRole to remove the role from the database.
+func SetRolePermissions(db *sql.DB, role string, perms []string) error {
+	if strings.TrimSpace(role) == "" {
+		return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", "SetRolePermissions", "The role name is empty")
+	}
+	if len(perms) == 0 {
+		return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", "SetRolePermissions", "The role has no permissions")
+	}
+	for _, p := range perms {
+		if !stringInSlice(p, &vars.Permissions) {
+			return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", "SetRolePermissions", "Unknown permission "+p)
+		}
+	}
+
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.DeleteRolePermissions(tx, role)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	done := make(map[string]bool)
+	for _, p := range perms {
+		if done[p] {
+			continue
+		}
+		done[p] = true
+		err = vars.InsertRolePermission(tx, role, p)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
 }
 
-// GetVarsNullBool creates/returns a VarsNullBool object using the given boolean paramter.
-func GetVarsNullBool(b bool) vars.VarsNullBool {
-	return vars.VarsNullBool{sql.NullBool{Bool: b, Valid: true}}
+// SortVulnerabilities sorts the vulnerabilities by key: "name", "cvss", "corpscore", "epss" or "initiated". Scores
+// are sorted highest first and the initiated date newest first. Vulnerabilities without an EPSS score are sorted
+// last by "epss".
+func SortVulnerabilities(vulns []*vars.Vulnerability, key string) error {
+	var less func(a, b *vars.Vulnerability) bool
+	switch key {
+	case "name":
+		less = func(a, b *vars.Vulnerability) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "cvss":
+		less = func(a, b *vars.Vulnerability) bool { return a.Cvss > b.Cvss }
+	case "corpscore":
+		less = func(a, b *vars.Vulnerability) bool { return a.CorpScore > b.CorpScore }
+	case "epss":
+		less = func(a, b *vars.Vulnerability) bool {
+			if a.Epss == nil || b.Epss == nil {
+				return a.Epss != nil
+			}
+			return a.Epss.Score > b.Epss.Score
+		}
+	case "initiated":
+		less = func(a, b *vars.Vulnerability) bool { return a.Dates.Initiated.After(b.Dates.Initiated) }
+	default:
+		return errors.New("Varsapi: SortVulnerabilities: Unknown sort key " + key)
+	}
+	sort.SliceStable(vulns, func(i, j int) bool { return less(vulns[i], vulns[j]) })
+	return nil
 }
 
-// GetVarsNullString creates/returns a VarsNullString object using the given string paramter.
-func GetVarsNullString(str string) vars.VarsNullString {
-	return vars.VarsNullString{sql.NullString{String: str, Valid: true}}
+// TaskProgress returns the percentage of the tasks that are done, rounded down. It returns 0 when there are no tasks.
+func TaskProgress(tasks []*vars.Task) int {
+	if len(tasks) == 0 {
+		return 0
+	}
+	done := 0
+	for _, t := range tasks {
+		if t.Done {
+			done++
+		}
+	}
+	return done * 100 / len(tasks)
 }
 
-// GetVarsNullTime creates/returns a VarsNullTime object using the given time paramter.
-func GetVarsNullTime(t time.Time) vars.VarsNullTime {
-	return vars.VarsNullTime{pq.NullTime{Time: t, Valid: true}}
+// UpdateAffected will update the mitigated status of the row (vid, sid).
+func UpdateAffected(db *sql.DB, vid, sid int64, mit bool) error {
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.UpdateAffected(tx, vid, sid, mit)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// A system mitigated by hand is no longer mitigated by the scan. The evidence is kept when the system is
+	// reopened so the next scan import reports the conflict instead of mitigating it again.
+	if mit {
+		err = vars.DeleteScanMitigation(tx, vid, sid)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return err
+		}
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
 }
 
-// GetVulnID returns the vulnid associated with the vname.
-func GetVulnID(vname string) (int64, error) {
-	return vars.GetVulnID(vname)
+// UpdateCvss will update the cvss score and link if they have been changed.
+func UpdateCvss(db *sql.DB, vid int64, cvss float32, link string) error {
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	c, cl, _, err := vars.GetImpact(vid)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	if cvss != c {
+		err = vars.UpdateCvss(tx, vid, cvss)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if (cl.Valid && link != cl.String) || !cl.Valid {
+		vnl := GetVarsNullString(link)
+		err = vars.UpdateCvssLink(tx, vid, vnl)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
 }
 
-// GetVulnerabilities retrieves/returns all vulnerabilities.
-func GetVulnerabilities() ([]*vars.Vulnerability, error) {
-	// Get vulnerabilities (vuln fields)
-	vulns, err := vars.GetVulnerabilities()
+// UpdateEmployee will update the row in the emp table with the new employee information.
+func UpdateEmployee(db *sql.DB, emp *vars.Employee) error {
+	// Get the old employee
+	old, err := GetEmployeeByID(emp.ID)
 	if !vars.IsNilErr(err) {
-		return vulns, err
+		return err
 	}
 
-	for _, vuln := range vulns {
-		//Get impact
-		cvss, cvssLink, cscore, err := vars.GetImpact(vuln.ID)
-		if !vars.IsNilErr(err) {
-			return vulns, err
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
 		}
-		vuln.Cvss = cvss
-		vuln.CvssLink = cvssLink
-		vuln.CorpScore = cscore
+	}()
 
-		// Get dates
-		vd, err := vars.GetVulnDates(vuln.ID)
+	// Compare old employee object to new employee object and update appropriate parts
+	if old.FirstName != emp.FirstName {
+		err = vars.UpdateEmpFname(tx, emp.ID, emp.FirstName)
 		if !vars.IsNilErr(err) {
-			return vulns, err
+			return err
 		}
-		vuln.Dates = *vd
-
-		// Get cves
-		cves, err := vars.GetCves(vuln.ID)
+	}
+	if old.LastName != emp.LastName {
+		err = vars.UpdateEmpLname(tx, emp.ID, emp.LastName)
 		if !vars.IsNilErr(err) {
-			return vulns, err
+			return err
 		}
-		vuln.Cves = *cves
-
-		// Get tickets
-		ticks, err := vars.GetTickets(vuln.ID)
+	}
+	if old.Email != emp.Email {
+		err = vars.UpdateEmpEmail(tx, emp.ID, emp.Email)
 		if !vars.IsNilErr(err) {
-			return vulns, err
+			return err
 		}
-		vuln.Tickets = *ticks
-
-		// Get references
-		refs, err := vars.GetReferences(vuln.ID)
+	}
+	if old.UserName != emp.UserName {
+		err = vars.UpdateEmpUname(tx, emp.ID, emp.UserName)
 		if !vars.IsNilErr(err) {
-			return vulns, err
+			return err
 		}
-		vuln.References = *refs
-
-		// Get exploit
-		exploit, exploitable, err := vars.GetExploit(vuln.ID)
+	}
+	if old.Role != emp.Role {
+		err = vars.UpdateEmpRole(tx, emp.ID, emp.Role)
 		if !vars.IsNilErr(err) {
-			return vulns, err
+			return err
 		}
-		vuln.Exploit = exploit
-		vuln.Exploitable = exploitable
+	}
 
-		// Get affected
-		affs, err := vars.GetAffected(vuln.ID)
-		if !vars.IsNilErr(err) {
-			return vulns, err
-		}
-		vuln.AffSystems = affs
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
 	}
-	return vulns, nil
+	return nil
 }
 
-// GetVulnerability retrieves/returns the vulnerability with the given id.
-func GetVulnerability(vid int64) (*vars.Vulnerability, error) {
-	var v vars.Vulnerability
-
-	// Get vulnerability fields
-	vuln, err := vars.GetVulnerability(vid)
-	if !vars.IsNilErr(err) {
-		return &v, err
+// UpdateEmployeeEmail will update the email associated with the given empid.
+func UpdateEmployeeEmail(db *sql.DB, eid int64, email string) error {
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
 	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
 
-	//Get impact
-	cvss, cvssLink, cscore, err := vars.GetImpact(vuln.ID)
+	err = vars.UpdateEmpEmail(tx, eid, email)
 	if !vars.IsNilErr(err) {
-		return &v, err
+		return err
 	}
-	vuln.Cvss = cvss
-	vuln.CvssLink = cvssLink
-	vuln.CorpScore = cscore
 
-	// Get dates
-	vd, err := vars.GetVulnDates(vid)
-	if !vars.IsNilErr(err) {
-		return &v, err
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
 	}
-	vuln.Dates = *vd
+	return nil
+}
 
-	// Get Cves
-	cves, err := vars.GetCves(vid)
-	if !vars.IsNilErr(err) {
-		return &v, err
+// UpdateEmployeeName will update the first/last name associated with the given empid.
+func UpdateEmployeeName(db *sql.DB, eid int64, fname, lname string) error {
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
 	}
-	vuln.Cves = *cves
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
 
-	// Get tickets
-	ticks, err := vars.GetTickets(vid)
+	err = vars.UpdateEmpFname(tx, eid, fname)
 	if !vars.IsNilErr(err) {
-		return &v, err
+		return err
 	}
-	vuln.Tickets = *ticks
-
-	// Get references
-	refs, err := vars.GetReferences(vid)
+	err = vars.UpdateEmpLname(tx, eid, lname)
 	if !vars.IsNilErr(err) {
-		return &v, err
+		return err
 	}
-	vuln.References = *refs
 
-	// Get exploit
-	exploit, exploitable, err := vars.GetExploit(vid)
-	if !vars.IsNilErr(err) {
-		return &v, err
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
 	}
-	vuln.Exploit = exploit
-	vuln.Exploitable = exploitable
+	return nil
+}
 
-	// Get affected
-	affs, err := vars.GetAffected(vuln.ID)
-	if !vars.IsNilErr(err) {
-		return &v, err
+// UpdateEmployeeRole will update the role associated with the given empid.
+func UpdateEmployeeRole(db *sql.DB, eid int64, role string) error {
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
 	}
-	vuln.AffSystems = affs
-
-	return vuln, nil
-}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
 
-// GetVulnerabilityByName retrieves/returns the vulnerability with the given name.
-func GetVulnerabilityByName(name string) (*vars.Vulnerability, error) {
-	id, err := vars.GetVulnID(name)
+	if err = checkRole(role); err != nil {
+		return err
+	}
+	err = vars.UpdateEmpRole(tx, eid, role)
 	if !vars.IsNilErr(err) {
-		var v vars.Vulnerability
-		return &v, err
+		return err
 	}
-	return GetVulnerability(id)
-}
-
-// IsNilErr returns true if the error is nil, false otherwise.
-func IsNilErr(err error) bool {
-	return vars.IsNilErr(err)
-}
 
-// IsNameNotAvailableError returns true if the error is caused by name not being available
-func IsNameNotAvailableError(err error) bool {
-	return vars.IsNameNotAvailableError(err)
-}
-
-// IsNoRowsError returns true if the error is caused by no rows being effected
-func IsNoRowsError(err error) bool {
-	return vars.IsNoRowsError(err)
-}
-
-// ReadConfig passes the config string to vars.ReadConfig to create the Config object.
-func ReadConfig(config string) error {
-	return vars.ReadConfig(config)
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
 }
 
-// UpdateAffected will update the mitigated status of the row (vid, sid).
-func UpdateAffected(db *sql.DB, vid, sid int64, mit bool) error {
+// UpdateEmployeeUsername will update the username associated with the given empid.
+func UpdateEmployeeUsername(db *sql.DB, eid int64, username string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1020,7 +3936,7 @@ func UpdateAffected(db *sql.DB, vid, sid int64, mit bool) error {
 		}
 	}()
 
-	err = vars.UpdateAffected(tx, vid, sid, mit)
+	err = vars.UpdateEmpUname(tx, eid, username)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -1033,8 +3949,8 @@ func UpdateAffected(db *sql.DB, vid, sid int64, mit bool) error {
 	return nil
 }
 
-// UpdateCvss will update the cvss score and link if they have been changed.
-func UpdateCvss(db *sql.DB, vid int64, cvss float32, link string) error {
+// UpdateExploit will update the exploit associated with vulnid.
+func UpdateExploit(db *sql.DB, vid int64, exploit string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1047,20 +3963,14 @@ func UpdateCvss(db *sql.DB, vid int64, cvss float32, link string) error {
 		}
 	}()
 
-	c, cl, _, err := vars.GetImpact(vid)
+	err = vars.UpdateExploit(tx, vid, exploit)
 	if !vars.IsNilErr(err) {
-		return err
-	}
-	if cvss != c {
-		err = vars.UpdateCvss(tx, vid, cvss)
-		if !vars.IsNilErr(err) {
-			return err
-		}
-	}
-	if (cl.Valid && link != cl.String) || !cl.Valid {
-		vnl := GetVarsNullString(link)
-		err = vars.UpdateCvssLink(tx, vid, vnl)
-		if !vars.IsNilErr(err) {
+		if vars.IsNoRowsError(err) {
+			err = vars.InsertExploit(tx, vid, true, exploit)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		} else {
 			return err
 		}
 	}
@@ -1073,14 +3983,42 @@ func UpdateCvss(db *sql.DB, vid int64, cvss float32, link string) error {
 	return nil
 }
 
-// UpdateEmployee will update the row in the emp table with the new employee information.
-func UpdateEmployee(db *sql.DB, emp *vars.Employee) error {
-	// Get the old employee
-	old, err := GetEmployeeByID(emp.ID)
-	if !vars.IsNilErr(err) {
+// UpdateExploitable will update the exploitable boolean associated with vulnid.
+func UpdateExploitable(db *sql.DB, vid int64, exploitable bool) error {
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
 		return err
 	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.UpdateExploitable(tx, vid, exploitable)
+	if !vars.IsNilErr(err) {
+		if vars.IsNoRowsError(err) {
+			err = vars.InsertExploit(tx, vid, exploitable, "")
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		} else {
+			return err
+		}
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
 
+// UpdateNote will update the note with the given noteid. The previous text of the note is kept as a revision.
+func UpdateNote(db *sql.DB, noteid int64, note string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1093,36 +4031,19 @@ func UpdateEmployee(db *sql.DB, emp *vars.Employee) error {
 		}
 	}()
 
-	// Compare old employee object to new employee object and update appropriate parts
-	if old.FirstName != emp.FirstName {
-		err = vars.UpdateEmpFname(tx, emp.ID, emp.FirstName)
-		if !vars.IsNilErr(err) {
-			return err
-		}
-	}
-	if old.LastName != emp.LastName {
-		err = vars.UpdateEmpLname(tx, emp.ID, emp.LastName)
-		if !vars.IsNilErr(err) {
-			return err
-		}
-	}
-	if old.Email != emp.Email {
-		err = vars.UpdateEmpEmail(tx, emp.ID, emp.Email)
-		if !vars.IsNilErr(err) {
-			return err
-		}
+	err = vars.InsertNoteRevision(tx, noteid)
+	if !vars.IsNilErr(err) {
+		return err
 	}
-	if old.UserName != emp.UserName {
-		err = vars.UpdateEmpUname(tx, emp.ID, emp.UserName)
-		if !vars.IsNilErr(err) {
-			return err
-		}
+
+	err = vars.UpdateNote(tx, noteid, note)
+	if !vars.IsNilErr(err) {
+		return err
 	}
-	if old.Level != emp.Level {
-		err = vars.UpdateEmpLevel(tx, emp.ID, emp.Level)
-		if !vars.IsNilErr(err) {
-			return err
-		}
+
+	err = setNoteMentions(tx, noteid, note)
+	if !vars.IsNilErr(err) {
+		return err
 	}
 
 	// Commit the transaction
@@ -1133,8 +4054,8 @@ func UpdateEmployee(db *sql.DB, emp *vars.Employee) error {
 	return nil
 }
 
-// UpdateEmployeeEmail will update the email associated with the given empid.
-func UpdateEmployeeEmail(db *sql.DB, eid int64, email string) error {
+// UpdateNotePublic marks the note as public, so that it is included in published advisories, or as internal.
+func UpdateNotePublic(db *sql.DB, noteid int64, public bool) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1147,7 +4068,7 @@ func UpdateEmployeeEmail(db *sql.DB, eid int64, email string) error {
 		}
 	}()
 
-	err = vars.UpdateEmpEmail(tx, eid, email)
+	err = vars.UpdateNotePublic(tx, noteid, public)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -1160,8 +4081,8 @@ func UpdateEmployeeEmail(db *sql.DB, eid int64, email string) error {
 	return nil
 }
 
-// UpdateEmployeeLevel will update the level associated with the given empid.
-func UpdateEmployeeLevel(db *sql.DB, eid int64, level int) error {
+// UpdateVulnerabilityMitigation will update the mitigation associated with the given vulnid.
+func UpdateVulnerabilityMitigation(db *sql.DB, vid int64, mitigation string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1174,12 +4095,11 @@ func UpdateEmployeeLevel(db *sql.DB, eid int64, level int) error {
 		}
 	}()
 
-	err = vars.UpdateEmpLevel(tx, eid, level)
+	err = vars.UpdateMitigation(tx, vid, mitigation)
 	if !vars.IsNilErr(err) {
 		return err
 	}
 
-	// Commit the transaction
 	rollback = false
 	if e := tx.Commit(); e != nil {
 		return e
@@ -1187,8 +4107,8 @@ func UpdateEmployeeLevel(db *sql.DB, eid int64, level int) error {
 	return nil
 }
 
-// UpdateEmployeeName will update the first/last name associated with the given empid.
-func UpdateEmployeeName(db *sql.DB, eid int64, fname, lname string) error {
+// UpdateVulnerabilityName updates the name associated with the given vulnid.
+func UpdateVulnerabilityName(db *sql.DB, vid int64, name string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1201,16 +4121,18 @@ func UpdateEmployeeName(db *sql.DB, eid int64, fname, lname string) error {
 		}
 	}()
 
-	err = vars.UpdateEmpFname(tx, eid, fname)
+	a, err := vars.NameIsAvailable("vuln", name)
 	if !vars.IsNilErr(err) {
 		return err
 	}
-	err = vars.UpdateEmpLname(tx, eid, lname)
+	if !a {
+		return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateVulnerabilityName")
+	}
+	err = vars.UpdateVulnName(tx, vid, name)
 	if !vars.IsNilErr(err) {
 		return err
 	}
 
-	// Commit the transaction
 	rollback = false
 	if e := tx.Commit(); e != nil {
 		return e
@@ -1218,8 +4140,8 @@ func UpdateEmployeeName(db *sql.DB, eid int64, fname, lname string) error {
 	return nil
 }
 
-// UpdateEmployeeUsername will update the username associated with the given empid.
-func UpdateEmployeeUsername(db *sql.DB, eid int64, username string) error {
+// UpdateVulnerabilitySummary updates the summary associated with the given vulnid.
+func UpdateVulnerabilitySummary(db *sql.DB, vid int64, summary string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1232,12 +4154,11 @@ func UpdateEmployeeUsername(db *sql.DB, eid int64, username string) error {
 		}
 	}()
 
-	err = vars.UpdateEmpUname(tx, eid, username)
+	err = vars.UpdateSummary(tx, vid, summary)
 	if !vars.IsNilErr(err) {
 		return err
 	}
 
-	// Commit the transaction
 	rollback = false
 	if e := tx.Commit(); e != nil {
 		return e
@@ -1245,8 +4166,8 @@ func UpdateEmployeeUsername(db *sql.DB, eid int64, username string) error {
 	return nil
 }
 
-// UpdateExploit will update the exploit associated with vulnid.
-func UpdateExploit(db *sql.DB, vid int64, exploit string) error {
+// UpdateVulnerabilityTest will update the test associated with the given vulnid.
+func UpdateVulnerabilityTest(db *sql.DB, vid int64, test string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1259,19 +4180,11 @@ func UpdateExploit(db *sql.DB, vid int64, exploit string) error {
 		}
 	}()
 
-	err = vars.UpdateExploit(tx, vid, exploit)
+	err = vars.UpdateTest(tx, vid, test)
 	if !vars.IsNilErr(err) {
-		if vars.IsNoRowsError(err) {
-			err = vars.InsertExploit(tx, vid, true, exploit)
-			if !vars.IsNilErr(err) {
-				return err
-			}
-		} else {
-			return err
-		}
+		return err
 	}
 
-	// Commit the transaction
 	rollback = false
 	if e := tx.Commit(); e != nil {
 		return e
@@ -1279,8 +4192,24 @@ func UpdateExploit(db *sql.DB, vid int64, exploit string) error {
 	return nil
 }
 
-// UpdateExploitable will update the exploitable boolean associated with vulnid.
-func UpdateExploitable(db *sql.DB, vid int64, exploitable bool) error {
+// UpdateVulnerability updates the edited parts of the vulnerability
+func UpdateVulnerability(db *sql.DB, vuln *vars.Vulnerability) error {
+	// Get the old vulnerability
+	old, err := vars.GetVulnerability(vuln.ID)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	tickets, err := vars.GetTickets(vuln.ID)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	old.Tickets = *tickets
+	refs, err := vars.GetReferences(vuln.ID)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	old.References = *refs
+
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1293,46 +4222,165 @@ func UpdateExploitable(db *sql.DB, vid int64, exploitable bool) error {
 		}
 	}()
 
-	err = vars.UpdateExploitable(tx, vid, exploitable)
-	if !vars.IsNilErr(err) {
-		if vars.IsNoRowsError(err) {
-			err = vars.InsertExploit(tx, vid, exploitable, "")
+	// Compare old vulnerability object to new vulnerability object and update appropriate parts
+	if old.Name != vuln.Name {
+		// Check new name
+		a, err := vars.NameIsAvailable("vuln", vuln.Name)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		if !a {
+			return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateVulnerability")
+		}
+
+		// Update name
+		err = vars.UpdateVulnName(tx, vuln.ID, vuln.Name)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if old.Cvss != vuln.Cvss {
+		err = vars.UpdateCvss(tx, vuln.ID, vuln.Cvss)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if old.CorpScore != vuln.CorpScore {
+		err = vars.UpdateCorpScore(tx, vuln.ID, vuln.CorpScore)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if old.CvssLink != vuln.CvssLink {
+		err = vars.UpdateCvssLink(tx, vuln.ID, vuln.CvssLink)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if old.Finder != vuln.Finder {
+		err = vars.UpdateFinder(tx, vuln.ID, vuln.Finder)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if old.Initiator != vuln.Initiator {
+		err = vars.UpdateInitiator(tx, vuln.ID, vuln.Initiator)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if old.Summary != vuln.Summary {
+		err = vars.UpdateSummary(tx, vuln.ID, vuln.Summary)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if old.Test != vuln.Test {
+		err = vars.UpdateTest(tx, vuln.ID, vuln.Test)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if old.Mitigation != vuln.Mitigation {
+		err = vars.UpdateMitigation(tx, vuln.ID, vuln.Mitigation)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	// Check (and update if needed) the published time
+	opt, err := old.Dates.Published.Value()
+	if err != nil {
+		return err
+	}
+	npt, err := vuln.Dates.Published.Value()
+	if err != nil {
+		return err
+	}
+	if opt != nil && npt != nil {
+		opd, ok := opt.(time.Time)
+		if !ok {
+			return errors.New("Varsapi: UpdateVulnerability: Failed to assert type for old published date.")
+		}
+		npd, ok := npt.(time.Time)
+		if !ok {
+			return errors.New("Varsapi: UpdateVulnerability: Failed to assert type for new published date")
+		}
+		if !opd.Equal(npd) {
+			err = vars.UpdatePubDate(tx, vuln.ID, vuln.Dates.Published)
 			if !vars.IsNilErr(err) {
 				return err
 			}
-		} else {
+		}
+	} else if opt == nil && npt != nil {
+		err = vars.UpdatePubDate(tx, vuln.ID, vuln.Dates.Published)
+		if !vars.IsNilErr(err) {
 			return err
 		}
 	}
-
-	// Commit the transaction
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+	// Check (and update if needed) the initiated time
+	if !old.Dates.Initiated.Equal(vuln.Dates.Initiated) {
+		err = vars.UpdateInitDate(tx, vuln.ID, vuln.Dates.Initiated)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	// Check (and update if needed) the mitigated time
+	omt, err := old.Dates.Mitigated.Value()
+	if err != nil {
+		return err
+	}
+	nmt, err := vuln.Dates.Mitigated.Value()
+	if err != nil {
+		return err
+	}
+	if omt != nil && nmt != nil {
+		omd, ok := omt.(time.Time)
+		if !ok {
+			return errors.New("Varsapi: UpdateVulnerability: Failed to assert type for old mitigated date")
+		}
+		nmd, ok := nmt.(time.Time)
+		if !ok {
+			return errors.New("Varsapi: UpdateVulnerability: Failed to assert type for new mitigated date")
+		}
+		if !omd.Equal(nmd) {
+			err = vars.UpdateMitDate(tx, vuln.ID, vuln.Dates.Mitigated)
+			if !vars.IsNilErr(err) {
+				return err
+			}
+		}
+	} else if omt == nil && nmt != nil {
+		err = vars.UpdateMitDate(tx, vuln.ID, vuln.Dates.Mitigated)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if vuln.Exploit.Valid {
+		if old.Exploit.Valid {
+			if old.Exploit.String != vuln.Exploit.String {
+				err = vars.UpdateExploit(tx, vuln.ID, vuln.Exploit.String)
+				if !vars.IsNilErr(err) {
+					return err
+				}
+			}
+		}
+		err = vars.UpdateExploit(tx, vuln.ID, vuln.Exploit.String)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	err = UpdateCves(tx, old, vuln)
+	if !vars.IsNilErr(err) {
+		return err
 	}
-	return nil
-}
-
-// UpdateNote will update the note with the given noteid.
-func UpdateNote(db *sql.DB, noteid int64, note string) error {
-	// Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
+	err = UpdateTickets(tx, old, vuln)
+	if !vars.IsNilErr(err) {
 		return err
 	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
-		}
-	}()
-
-	err = vars.UpdateNote(tx, noteid, note)
+	err = UpdateReferences(tx, old, vuln)
 	if !vars.IsNilErr(err) {
 		return err
 	}
 
-	// Commit the transaction
 	rollback = false
 	if e := tx.Commit(); e != nil {
 		return e
@@ -1340,8 +4388,8 @@ func UpdateNote(db *sql.DB, noteid int64, note string) error {
 	return nil
 }
 
-// UpdateVulnerabilityMitigation will update the mitigation associated with the given vulnid.
-func UpdateVulnerabilityMitigation(db *sql.DB, vid int64, mitigation string) error {
+// UpdateCorpscore will update the corpscore associated with the given vulnid.
+func UpdateCorpScore(db *sql.DB, vid int64, corpscore float32) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1354,7 +4402,7 @@ func UpdateVulnerabilityMitigation(db *sql.DB, vid int64, mitigation string) err
 		}
 	}()
 
-	err = vars.UpdateMitigation(tx, vid, mitigation)
+	err = vars.UpdateCorpScore(tx, vid, corpscore)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -1366,8 +4414,9 @@ func UpdateVulnerabilityMitigation(db *sql.DB, vid int64, mitigation string) err
 	return nil
 }
 
-// UpdateVulnerabilityName updates the name associated with the given vulnid.
-func UpdateVulnerabilityName(db *sql.DB, vid int64, name string) error {
+// UpdateCve will update the CVE associated with the row (vid, cve). If the new CVE is in the KEV catalog the
+// vulnerability is flagged as exploitable.
+func UpdateCve(db *sql.DB, vid int64, oldcve, newcve string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1380,14 +4429,11 @@ func UpdateVulnerabilityName(db *sql.DB, vid int64, name string) error {
 		}
 	}()
 
-	a, err := vars.NameIsAvailable("vuln", name)
+	err = vars.UpdateCve(tx, vid, oldcve, newcve)
 	if !vars.IsNilErr(err) {
 		return err
 	}
-	if !a {
-		return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateVulnerabilityName")
-	}
-	err = vars.UpdateVulnName(tx, vid, name)
+	_, err = matchKev(tx)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -1399,8 +4445,34 @@ func UpdateVulnerabilityName(db *sql.DB, vid int64, name string) error {
 	return nil
 }
 
-// UpdateVulnerabilitySummary updates the summary associated with the given vulnid.
-func UpdateVulnerabilitySummary(db *sql.DB, vid int64, summary string) error {
+// UpdateCves determines the rows that need to be deleted/added and calls the appropriate VARS function. The added
+// CVEs are matched against the KEV catalog.
+func UpdateCves(tx *sql.Tx, old, vuln *vars.Vulnerability) error {
+	del := toBeDeleted(&old.Cves, &vuln.Cves)
+	for _, cve := range *del {
+		err := vars.DeleteCve(tx, vuln.ID, cve)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	add := toBeAdded(&old.Cves, &vuln.Cves)
+	for _, cve := range *add {
+		err := vars.InsertCve(tx, vuln.ID, cve)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if len(*add) > 0 {
+		_, err := matchKev(tx)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateFinder will update the finder's empid associated with the vulnid
+func UpdateFinder(db *sql.DB, vid, eid int64) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1413,7 +4485,7 @@ func UpdateVulnerabilitySummary(db *sql.DB, vid int64, summary string) error {
 		}
 	}()
 
-	err = vars.UpdateSummary(tx, vid, summary)
+	err = vars.UpdateFinder(tx, vid, eid)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -1425,8 +4497,8 @@ func UpdateVulnerabilitySummary(db *sql.DB, vid int64, summary string) error {
 	return nil
 }
 
-// UpdateVulnerabilityTest will update the test associated with the given vulnid.
-func UpdateVulnerabilityTest(db *sql.DB, vid int64, test string) error {
+// UpdateReference will update the reference associated with row (vid, oldRef) to newRef.
+func UpdateReference(db *sql.DB, vid int64, oldRef, newRef string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1439,7 +4511,7 @@ func UpdateVulnerabilityTest(db *sql.DB, vid int64, test string) error {
 		}
 	}()
 
-	err = vars.UpdateTest(tx, vid, test)
+	err = vars.UpdateRefers(tx, vid, oldRef, newRef)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -1451,23 +4523,32 @@ func UpdateVulnerabilityTest(db *sql.DB, vid int64, test string) error {
 	return nil
 }
 
-// UpdateVulnerability updates the edited parts of the vulnerability
-func UpdateVulnerability(db *sql.DB, vuln *vars.Vulnerability) error {
-	// Get the old vulnerability
-	old, err := vars.GetVulnerability(vuln.ID)
-	if !vars.IsNilErr(err) {
-		return err
+// UpdateReferences determines the rows that need to be deleted/added and calls the appropriate VARS function.
+func UpdateReferences(tx *sql.Tx, old, vuln *vars.Vulnerability) error {
+	del := toBeDeleted(&old.References, &vuln.References)
+	for _, ref := range *del {
+		err := vars.DeleteRef(tx, vuln.ID, ref)
+		if !vars.IsNilErr(err) {
+			return err
+		}
 	}
-	tickets, err := vars.GetTickets(vuln.ID)
-	if !vars.IsNilErr(err) {
-		return err
+	add := toBeAdded(&old.References, &vuln.References)
+	for _, ref := range *add {
+		err := vars.InsertRef(tx, vuln.ID, ref)
+		if !vars.IsNilErr(err) {
+			return err
+		}
 	}
-	old.Tickets = *tickets
-	refs, err := vars.GetReferences(vuln.ID)
+	return nil
+}
+
+// UpdateSystem updates the edited parts of the system
+func UpdateSystem(db *sql.DB, sys *vars.System) error {
+	// Get the old system
+	old, err := vars.GetSystem(sys.ID)
 	if !vars.IsNilErr(err) {
 		return err
 	}
-	old.References = *refs
 
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
@@ -1481,165 +4562,258 @@ func UpdateVulnerability(db *sql.DB, vuln *vars.Vulnerability) error {
 		}
 	}()
 
-	// Compare old vulnerability object to new vulnerability object and update appropriate parts
-	if old.Name != vuln.Name {
+	// Compare old system object to new system object and update appropriate parts
+	if old.Name != sys.Name {
 		// Check new name
-		a, err := vars.NameIsAvailable("vuln", vuln.Name)
+		a, err := vars.NameIsAvailable("sys", sys.Name)
 		if !vars.IsNilErr(err) {
 			return err
 		}
 		if !a {
-			return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateVulnerability")
+			return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateSystem")
 		}
 
 		// Update name
-		err = vars.UpdateVulnName(tx, vuln.ID, vuln.Name)
+		err = vars.UpdateSysName(tx, sys.ID, sys.Name)
 		if !vars.IsNilErr(err) {
 			return err
 		}
 	}
-	if old.Cvss != vuln.Cvss {
-		err = vars.UpdateCvss(tx, vuln.ID, vuln.Cvss)
+	if old.Type != sys.Type {
+		err = vars.UpdateSysType(tx, sys.ID, sys.Type)
 		if !vars.IsNilErr(err) {
 			return err
 		}
 	}
-	if old.CorpScore != vuln.CorpScore {
-		err = vars.UpdateCorpScore(tx, vuln.ID, vuln.CorpScore)
+	if old.OpSys != sys.OpSys {
+		err = vars.UpdateSysOS(tx, sys.ID, sys.OpSys)
 		if !vars.IsNilErr(err) {
 			return err
 		}
 	}
-	if old.CvssLink != vuln.CvssLink {
-		err = vars.UpdateCvssLink(tx, vuln.ID, vuln.CvssLink)
+	if old.Location != sys.Location {
+		err = vars.UpdateSysLoc(tx, sys.ID, sys.Location)
 		if !vars.IsNilErr(err) {
 			return err
 		}
 	}
-	if old.Finder != vuln.Finder {
-		err = vars.UpdateFinder(tx, vuln.ID, vuln.Finder)
+	if old.Description != sys.Description {
+		err = vars.UpdateSysDesc(tx, sys.ID, sys.Description)
 		if !vars.IsNilErr(err) {
 			return err
 		}
 	}
-	if old.Initiator != vuln.Initiator {
-		err = vars.UpdateInitiator(tx, vuln.ID, vuln.Initiator)
+	if old.State != sys.State {
+		err = vars.UpdateSysState(tx, sys.ID, sys.State)
 		if !vars.IsNilErr(err) {
 			return err
 		}
 	}
-	if old.Summary != vuln.Summary {
-		err = vars.UpdateSummary(tx, vuln.ID, vuln.Summary)
-		if !vars.IsNilErr(err) {
-			return err
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// UpdateSystemDescription updates the description of the given system.
+func UpdateSystemDescription(db *sql.DB, sid int64, desc string) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
 		}
+	}()
+
+	err = vars.UpdateSysDesc(tx, sid, desc)
+	if !vars.IsNilErr(err) {
+		return err
 	}
-	if old.Test != vuln.Test {
-		err = vars.UpdateTest(tx, vuln.ID, vuln.Test)
-		if !vars.IsNilErr(err) {
-			return err
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// UpdateSystemLocation updates the location of the given system.
+func UpdateSystemLocation(db *sql.DB, sid int64, loc string) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
 		}
+	}()
+
+	err = vars.UpdateSysLoc(tx, sid, loc)
+	if !vars.IsNilErr(err) {
+		return err
 	}
-	if old.Mitigation != vuln.Mitigation {
-		err = vars.UpdateMitigation(tx, vuln.ID, vuln.Mitigation)
-		if !vars.IsNilErr(err) {
-			return err
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// UpdateSystemName updates the name associated with the sysid.
+func UpdateSystemName(db *sql.DB, sid int64, name string) error {
+	// Check if name is available
+	a, err := vars.NameIsAvailable("sys", name)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	if !a {
+		return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateSystemName")
+	}
+
+	// Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
 		}
+	}()
+
+	err = vars.UpdateSysName(tx, sid, name)
+	if !vars.IsNilErr(err) {
+		return err
 	}
-	// Check (and update if needed) the published time
-	opt, err := old.Dates.Published.Value()
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// UpdateSystemOS updates the operating system of the given system.
+func UpdateSystemOS(db *sql.DB, sid int64, opsys string) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	npt, err := vuln.Dates.Published.Value()
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.UpdateSysOS(tx, sid, opsys)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// UpdateSystemState sets the state of the given system to 'state'.
+func UpdateSystemState(db *sql.DB, sid int64, state string) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	if opt != nil && npt != nil {
-		opd, ok := opt.(time.Time)
-		if !ok {
-			return errors.New("Varsapi: UpdateVulnerability: Failed to assert type for old published date.")
-		}
-		npd, ok := npt.(time.Time)
-		if !ok {
-			return errors.New("Varsapi: UpdateVulnerability: Failed to assert type for new published date")
-		}
-		if !opd.Equal(npd) {
-			err = vars.UpdatePubDate(tx, vuln.ID, vuln.Dates.Published)
-			if !vars.IsNilErr(err) {
-				return err
-			}
-		}
-	} else if opt == nil && npt != nil {
-		err = vars.UpdatePubDate(tx, vuln.ID, vuln.Dates.Published)
-		if !vars.IsNilErr(err) {
-			return err
-		}
-	}
-	// Check (and update if needed) the initiated time
-	if !old.Dates.Initiated.Equal(vuln.Dates.Initiated) {
-		err = vars.UpdateInitDate(tx, vuln.ID, vuln.Dates.Initiated)
-		if !vars.IsNilErr(err) {
-			return err
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
 		}
-	}
-	// Check (and update if needed) the mitigated time
-	omt, err := old.Dates.Mitigated.Value()
-	if err != nil {
+	}()
+
+	err = vars.UpdateSysState(tx, sid, state)
+	if !vars.IsNilErr(err) {
 		return err
 	}
-	nmt, err := vuln.Dates.Mitigated.Value()
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// UpdateSystemType updates the type of system.
+func UpdateSystemType(db *sql.DB, sid int64, tp string) error {
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	if omt != nil && nmt != nil {
-		omd, ok := omt.(time.Time)
-		if !ok {
-			return errors.New("Varsapi: UpdateVulnerability: Failed to assert type for old mitigated date")
-		}
-		nmd, ok := nmt.(time.Time)
-		if !ok {
-			return errors.New("Varsapi: UpdateVulnerability: Failed to assert type for new mitigated date")
-		}
-		if !omd.Equal(nmd) {
-			err = vars.UpdateMitDate(tx, vuln.ID, vuln.Dates.Mitigated)
-			if !vars.IsNilErr(err) {
-				return err
-			}
-		}
-	} else if omt == nil && nmt != nil {
-		err = vars.UpdateMitDate(tx, vuln.ID, vuln.Dates.Mitigated)
-		if !vars.IsNilErr(err) {
-			return err
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
 		}
+	}()
+
+	err = vars.UpdateSysType(tx, sid, tp)
+	if !vars.IsNilErr(err) {
+		return err
 	}
-	if vuln.Exploit.Valid {
-		if old.Exploit.Valid {
-			if old.Exploit.String != vuln.Exploit.String {
-				err = vars.UpdateExploit(tx, vuln.ID, vuln.Exploit.String)
-				if !vars.IsNilErr(err) {
-					return err
-				}
-			}
-		}
-		err = vars.UpdateExploit(tx, vuln.ID, vuln.Exploit.String)
-		if !vars.IsNilErr(err) {
-			return err
-		}
+
+	// Commit the transaction
+	rollback = false
+	if e := tx.Commit(); e != nil {
+		return e
 	}
-	err = UpdateCves(tx, old, vuln)
-	if !vars.IsNilErr(err) {
+	return nil
+}
+
+// UpdateTask updates the title, assignee, due date, done flag and system of the task with the ID of t. An
+// InvalidValue error is returned if the task has no title or its vulnerability, assignee or system doesn't exist.
+func UpdateTask(db *sql.DB, t *vars.Task) error {
+	if err := validateTask("UpdateTask", t); err != nil {
 		return err
 	}
-	err = UpdateTickets(tx, old, vuln)
-	if !vars.IsNilErr(err) {
+
+	//Start transaction and set rollback function
+	tx, err := db.Begin()
+	if err != nil {
 		return err
 	}
-	err = UpdateReferences(tx, old, vuln)
+	rollback := true
+	defer func() {
+		if rollback {
+			tx.Rollback()
+		}
+	}()
+
+	err = vars.UpdateTask(tx, t)
 	if !vars.IsNilErr(err) {
 		return err
 	}
 
+	// Commit the transaction
 	rollback = false
 	if e := tx.Commit(); e != nil {
 		return e
@@ -1647,9 +4821,14 @@ func UpdateVulnerability(db *sql.DB, vuln *vars.Vulnerability) error {
 	return nil
 }
 
-// UpdateCorpscore will update the corpscore associated with the given vulnid.
-func UpdateCorpScore(db *sql.DB, vid int64, corpscore float32) error {
-	// Start transaction and set rollback function
+// UpdateTemplate updates the vulnerability template with the ID of t, replacing its references.
+func UpdateTemplate(db *sql.DB, t *vars.Template) error {
+	old, err := vars.GetTemplate(t.ID)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	//Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
 		return err
@@ -1661,11 +4840,33 @@ func UpdateCorpScore(db *sql.DB, vid int64, corpscore float32) error {
 		}
 	}()
 
-	err = vars.UpdateCorpScore(tx, vid, corpscore)
+	// Check if the new template name is available
+	if old.Name != t.Name {
+		a, err := vars.NameIsAvailable("template", t.Name)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+		if !a {
+			return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateTemplate")
+		}
+	}
+
+	err = vars.UpdateTemplate(tx, t)
 	if !vars.IsNilErr(err) {
 		return err
 	}
+	err = vars.DeleteTemplateRefs(tx, t.ID)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	for _, ref := range t.References {
+		err = vars.InsertTemplateRef(tx, t.ID, ref)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
 
+	// Commit the transaction
 	rollback = false
 	if e := tx.Commit(); e != nil {
 		return e
@@ -1673,8 +4874,8 @@ func UpdateCorpScore(db *sql.DB, vid int64, corpscore float32) error {
 	return nil
 }
 
-// UpdateCve will update the CVE associated with the row (vid, cve).
-func UpdateCve(db *sql.DB, vid int64, oldcve, newcve string) error {
+// UpdateTicket will update the ticket associated with the row (vid, ticket).
+func UpdateTicket(db *sql.DB, vid int64, oldticket, newticket string) error {
 	// Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
@@ -1687,7 +4888,7 @@ func UpdateCve(db *sql.DB, vid int64, oldcve, newcve string) error {
 		}
 	}()
 
-	err = vars.UpdateCve(tx, vid, oldcve, newcve)
+	err = vars.UpdateTicket(tx, vid, oldticket, newticket)
 	if !vars.IsNilErr(err) {
 		return err
 	}
@@ -1699,18 +4900,97 @@ func UpdateCve(db *sql.DB, vid int64, oldcve, newcve string) error {
 	return nil
 }
 
-// UpdateCves determines the rows that need to be deleted/added and calls the appropriate VARS function.
-func UpdateCves(tx *sql.Tx, old, vuln *vars.Vulnerability) error {
-	del := toBeDeleted(&old.Cves, &vuln.Cves)
-	for _, cve := range *del {
-		err := vars.DeleteCve(tx, vuln.ID, cve)
+// UpdateTickets determines the rows that need to be deleted/added and calls the appropriate VARS function.
+func UpdateTickets(tx *sql.Tx, old, vuln *vars.Vulnerability) error {
+	del := toBeDeleted(&old.Tickets, &vuln.Tickets)
+	for _, tick := range *del {
+		err := vars.DeleteTicket(tx, vuln.ID, tick)
 		if !vars.IsNilErr(err) {
 			return err
 		}
 	}
-	add := toBeAdded(&old.Cves, &vuln.Cves)
-	for _, cve := range *add {
-		err := vars.InsertCve(tx, vuln.ID, cve)
+	add := toBeAdded(&old.Tickets, &vuln.Tickets)
+	for _, tick := range *add {
+		err := vars.InsertTicket(tx, vuln.ID, tick)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// addVulnerability inserts the vulnerability and its impact, dates, CVEs, tickets, references and exploit using
+// the given transaction and sets the ID of vuln. The CVEs are matched against the KEV catalog.
+func addVulnerability(tx *sql.Tx, vuln *vars.Vulnerability) error {
+	// Check if vulnerability name is available
+	a, err := vars.NameIsAvailable("vuln", vuln.Name)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	if !a {
+		return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "addVulnerability")
+	}
+
+	// Insert the vulnerability into the database
+	err = vars.InsertVulnerability(tx, vuln.Name, vuln.Finder, vuln.Initiator, vuln.Summary, vuln.Test, vuln.Mitigation)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Update the vulnid
+	vid, err := vars.GetVulnIDtx(tx, vuln.Name)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	vuln.ID = vid
+
+	// Insert the values in the impact table
+	err = vars.InsertImpact(tx, vuln.ID, vuln.Cvss, vuln.CorpScore, vuln.CvssLink)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Insert the values in the dates table
+	err = vars.InsertDates(tx, vuln.ID, time.Now(), vuln.Dates.Published, vuln.Dates.Mitigated)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Insert the values in the cves table
+	err = vars.SetCves(tx, vuln)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Insert the values in the cwes table
+	for _, cwe := range vuln.Cwes {
+		err = vars.InsertCwe(tx, vuln.ID, cwe)
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+
+	// Insert the values in the ticket table
+	err = vars.SetTickets(tx, vuln)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Insert the values in the reference table
+	err = vars.SetReferences(tx, vuln)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Insert the values in the exploits table
+	err = vars.SetExploit(tx, vuln)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+
+	// Flag the vulnerability as exploitable if one of its CVEs is in the KEV catalog
+	if len(vuln.Cves) > 0 {
+		_, err = matchKev(tx)
 		if !vars.IsNilErr(err) {
 			return err
 		}
@@ -1718,159 +4998,371 @@ func UpdateCves(tx *sql.Tx, old, vuln *vars.Vulnerability) error {
 	return nil
 }
 
-// UpdateFinder will update the finder's empid associated with the vulnid
-func UpdateFinder(db *sql.DB, vid, eid int64) error {
-	// Start transaction and set rollback function
-	tx, err := db.Begin()
+// archiveInt returns the integer value of a column of an archive row.
+func archiveInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// archiveKey returns the key of the columns of the row, or false if one of them is null.
+func archiveKey(row map[string]interface{}, cols []string) (string, bool) {
+	var key []string
+	for _, col := range cols {
+		if row[col] == nil {
+			return "", false
+		}
+		key = append(key, fmt.Sprint(row[col]))
+	}
+	return strings.Join(key, "\x00"), true
+}
+
+// archiveRef returns the reference of the table by the column.
+func archiveRef(t *vars.ArchiveTable, col string) *vars.ArchiveRef {
+	for i := range t.Refs {
+		if len(t.Refs[i].Columns) == 1 && t.Refs[i].Columns[0] == col {
+			return &t.Refs[i]
+		}
+	}
+	return nil
+}
+
+// archiveTable returns the archive table with the name, or nil if there is none.
+func archiveTable(name string) *vars.ArchiveTable {
+	for _, t := range vars.ArchiveTables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// attachTypeAllowed returns true if the media type of mtype is in the configured list of attachment types.
+func attachTypeAllowed(mtype string) bool {
+	types := vars.Conf.AttachTypes
+	if len(types) == 0 {
+		types = vars.DefaultAttachTypes
+	}
+	mtype = strings.TrimSpace(strings.Split(mtype, ";")[0])
+	return stringInSlice(mtype, &types)
+}
+
+// checkRole returns an error if the role is not one of the roles known to VARS.
+func checkRole(role string) error {
+	roles, err := GetRoles()
 	if err != nil {
 		return err
 	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
-		}
-	}()
+	if _, ok := roles[role]; !ok {
+		return errors.New("Varsapi: Unknown role " + role)
+	}
+	return nil
+}
 
-	err = vars.UpdateFinder(tx, vid, eid)
-	if !vars.IsNilErr(err) {
-		return err
+// compareVersions compares the version strings a and b, returning -1, 0 or 1. The versions are compared token by
+// token, where a token is a run of digits or of letters. Numbers compare numerically and are newer than letters, so
+// 1.0rc1 is older than 1.0 and 1.0.1 is newer.
+func compareVersions(a, b string) int {
+	ta := versionTokens(a)
+	tb := versionTokens(b)
+	for i := 0; i < len(ta) || i < len(tb); i++ {
+		switch {
+		case i >= len(ta):
+			if isDigit(tb[i][0]) {
+				return -1
+			}
+			return 1
+		case i >= len(tb):
+			if isDigit(ta[i][0]) {
+				return 1
+			}
+			return -1
+		}
+		x, y := ta[i], tb[i]
+		dx, dy := isDigit(x[0]), isDigit(y[0])
+		switch {
+		case dx && !dy:
+			return 1
+		case !dx && dy:
+			return -1
+		case dx && dy:
+			x = strings.TrimLeft(x, "0")
+			y = strings.TrimLeft(y, "0")
+			if len(x) != len(y) {
+				if len(x) < len(y) {
+					return -1
+				}
+				return 1
+			}
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
 	}
+	return 0
+}
 
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+// componentNames returns the lowercased names a component may be recorded under as a vulnerable package: its name,
+// and the name and namespace-qualified name of its package URL.
+func componentNames(c *vars.Component) []string {
+	names := []string{strings.ToLower(c.Name)}
+	purl := strings.TrimPrefix(c.Purl, "pkg:")
+	if purl == c.Purl {
+		return names
 	}
-	return nil
+	if i := strings.IndexAny(purl, "@?#"); i >= 0 {
+		purl = purl[:i]
+	}
+	parts := strings.Split(purl, "/")
+	if len(parts) < 2 {
+		return names
+	}
+	name, _ := url.PathUnescape(parts[len(parts)-1])
+	names = append(names, strings.ToLower(name))
+	if len(parts) > 2 {
+		ns, _ := url.PathUnescape(strings.Join(parts[1:len(parts)-1], "/"))
+		names = append(names, strings.ToLower(ns+"/"+name), strings.ToLower(ns+":"+name))
+	}
+	return names
 }
 
-// UpdateReference will update the reference associated with row (vid, oldRef) to newRef.
-func UpdateReference(db *sql.DB, vid int64, oldRef, newRef string) error {
-	// Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
-		return err
+// componentsNotIn returns the components of a that are not in b.
+func componentsNotIn(a, b []*vars.Component) []*vars.Component {
+	in := make(map[string]bool)
+	for _, c := range b {
+		in[c.Name+"@"+c.Version] = true
 	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
+	var res []*vars.Component
+	for _, c := range a {
+		if !in[c.Name+"@"+c.Version] {
+			res = append(res, c)
 		}
-	}()
+	}
+	return res
+}
 
-	err = vars.UpdateRefers(tx, vid, oldRef, newRef)
-	if !vars.IsNilErr(err) {
-		return err
+// csvCell returns the text of a CSV cell prefixed with a quote if it starts like a formula (=, +, -, @, a tab or a
+// carriage return), so spreadsheets show the text instead of running it.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
 	}
+	return s
+}
 
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+// csvValue returns the text of the CSV cell without the quote added by csvCell.
+func csvValue(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+		return s[1:]
 	}
-	return nil
+	return s
 }
 
-// UpdateReferences determines the rows that need to be deleted/added and calls the appropriate VARS function.
-func UpdateReferences(tx *sql.Tx, old, vuln *vars.Vulnerability) error {
-	del := toBeDeleted(&old.References, &vuln.References)
-	for _, ref := range *del {
-		err := vars.DeleteRef(tx, vuln.ID, ref)
-		if !vars.IsNilErr(err) {
-			return err
+// deleteAttachments deletes the attachment rows. The contents are deleted with them if the blob store is
+// transactional, otherwise the keys of the contents are returned so they can be deleted with deleteBlobs once the
+// transaction is committed.
+func deleteAttachments(tx *sql.Tx, store vars.BlobStore, atts []*vars.Attachment) ([]string, error) {
+	var keys []string
+	for _, att := range atts {
+		if err := vars.DeleteAttachment(tx, att.ID); !vars.IsNilErr(err) {
+			return nil, err
 		}
-	}
-	add := toBeAdded(&old.References, &vuln.References)
-	for _, ref := range *add {
-		err := vars.InsertRef(tx, vuln.ID, ref)
-		if !vars.IsNilErr(err) {
-			return err
+		if !store.Transactional() {
+			keys = append(keys, att.StoreKey)
+			continue
+		}
+		if err := store.Delete(tx, att.StoreKey); !vars.IsNilErr(err) {
+			return nil, err
 		}
 	}
-	return nil
+	return keys, nil
 }
 
-// UpdateSystem updates the edited parts of the system
-func UpdateSystem(db *sql.DB, sys *vars.System) error {
-	// Get the old system
-	old, err := vars.GetSystem(sys.ID)
-	if !vars.IsNilErr(err) {
-		return err
+// deleteBlobs deletes the contents left by deleteAttachments from a blob store that is not transactional. The
+// rows are already gone, so a file that can't be removed is only left behind.
+func deleteBlobs(store vars.BlobStore, keys []string) {
+	for _, key := range keys {
+		store.Delete(nil, key)
 	}
+}
 
-	// Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
+// importCatalog adds the entries to the CVE catalog within tx. See ImportCatalog. With merge, the entries only fill in
+// the fields that are empty in the catalog and add the CWEs and references that are missing, so records from other
+// sources such as OSV don't replace the NVD data (see vars.MergeCatalogEntry). It returns the number of entries
+// that were imported and the number that were already up to date.
+func importCatalog(tx *sql.Tx, entries []*vars.CatalogEntry, merge bool) (int, int, error) {
+	var imported, unchanged int
+	for _, e := range entries {
+		e.Cve = strings.ToUpper(e.Cve)
+		if merge {
+			err := vars.MergeCatalogEntry(tx, e)
+			if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+				return 0, 0, err
+			}
+			changed := vars.IsNilErr(err)
+			for _, cwe := range e.Cwes {
+				err = vars.InsertCatalogCwe(tx, e.Cve, cwe)
+				if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+					return 0, 0, err
+				}
+				changed = changed || vars.IsNilErr(err)
+			}
+			for _, ref := range e.References {
+				err = vars.InsertCatalogRef(tx, e.Cve, ref)
+				if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+					return 0, 0, err
+				}
+				changed = changed || vars.IsNilErr(err)
+			}
+			if changed {
+				imported++
+			} else {
+				unchanged++
+			}
+			continue
 		}
-	}()
 
-	// Compare old system object to new system object and update appropriate parts
-	if old.Name != sys.Name {
-		// Check new name
-		a, err := vars.NameIsAvailable("sys", sys.Name)
-		if !vars.IsNilErr(err) {
-			return err
+		err := vars.UpsertCatalogEntry(tx, e)
+		if vars.IsNoRowsError(err) {
+			unchanged++
+			continue
 		}
-		if !a {
-			return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateSystem")
+		if !vars.IsNilErr(err) {
+			return 0, 0, err
 		}
 
-		// Update name
-		err = vars.UpdateSysName(tx, sys.ID, sys.Name)
-		if !vars.IsNilErr(err) {
-			return err
+		// Replace the CWEs and references
+		err = vars.DeleteCatalogCwes(tx, e.Cve)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return 0, 0, err
+		}
+		for _, cwe := range e.Cwes {
+			err = vars.InsertCatalogCwe(tx, e.Cve, cwe)
+			if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+				return 0, 0, err
+			}
+		}
+		err = vars.DeleteCatalogRefs(tx, e.Cve)
+		if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+			return 0, 0, err
+		}
+		for _, ref := range e.References {
+			err = vars.InsertCatalogRef(tx, e.Cve, ref)
+			if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+				return 0, 0, err
+			}
 		}
+		imported++
 	}
-	if old.Type != sys.Type {
-		err = vars.UpdateSysType(tx, sys.ID, sys.Type)
+	return imported, unchanged, nil
+}
+
+// importOsvVuln starts a vulnerability for the OSV record unless it is already tracked. tracked holds the CVEs and
+// names of the vulnerabilities started by the import, which the lookups outside of tx can't see. See ImportOSV.
+func importOsvVuln(tx *sql.Tx, rec *osv.Entry, opts *vars.OsvOptions, tracked map[string]bool, rep *vars.OsvReport) error {
+	name := rec.ID
+	if rec.Summary != "" {
+		name = rec.ID + ": " + rec.Summary
+	}
+	cves := rec.Cves()
+	isTracked := tracked[name]
+	for _, cve := range cves {
+		if isTracked {
+			break
+		}
+		ids, err := vars.GetVulnIDsByCve(cve)
 		if !vars.IsNilErr(err) {
 			return err
 		}
+		isTracked = tracked[cve] || len(*ids) > 0
 	}
-	if old.OpSys != sys.OpSys {
-		err = vars.UpdateSysOS(tx, sys.ID, sys.OpSys)
+	if !isTracked {
+		a, err := vars.NameIsAvailable("vuln", name)
 		if !vars.IsNilErr(err) {
 			return err
 		}
+		isTracked = !a
 	}
-	if old.Location != sys.Location {
-		err = vars.UpdateSysLoc(tx, sys.ID, sys.Location)
-		if !vars.IsNilErr(err) {
-			return err
+	if isTracked {
+		rep.Tracked = append(rep.Tracked, rec.ID)
+		return nil
+	}
+
+	vuln := vars.Vulnerability{
+		Name:      name,
+		Cves:      cves,
+		Finder:    opts.Employee,
+		Initiator: opts.Employee,
+		Summary:   rec.Details,
+		Test:      "Listed by OSV record " + rec.ID,
+	}
+	if vuln.Summary == "" {
+		vuln.Summary = rec.Summary
+	}
+	if vector, score := rec.Vector(); vector != "" {
+		vuln.Cvss = score
+		vuln.CvssLink = vars.ToVarsNullString(nvd.CalculatorLink(vector))
+	}
+	if rec.Published != "" {
+		if t, err := time.Parse(time.RFC3339, rec.Published); err == nil {
+			vuln.Dates.Published = GetVarsNullTime(t.UTC())
 		}
 	}
-	if old.Description != sys.Description {
-		err = vars.UpdateSysDesc(tx, sys.ID, sys.Description)
-		if !vars.IsNilErr(err) {
-			return err
+	if rec.DatabaseSpecific != nil {
+		vuln.Cwes = rec.DatabaseSpecific.CweIDs
+	}
+	for _, ref := range rec.References {
+		if ref.URL != "" && !stringInSlice(ref.URL, &vuln.References) {
+			vuln.References = append(vuln.References, ref.URL)
 		}
 	}
-	if old.State != sys.State {
-		err = vars.UpdateSysState(tx, sys.ID, sys.State)
-		if !vars.IsNilErr(err) {
-			return err
+
+	// The fixed versions of the affected packages are the mitigation
+	var fixes []string
+	for _, aff := range rec.Affected {
+		if aff.Package == nil {
+			continue
+		}
+		var fixed []string
+		for _, r := range aff.Ranges {
+			for _, ev := range r.Events {
+				if ev.Fixed != "" && !stringInSlice(ev.Fixed, &fixed) {
+					fixed = append(fixed, ev.Fixed)
+				}
+			}
+		}
+		if len(fixed) > 0 {
+			fixes = append(fixes, "Upgrade "+aff.Package.Name+" to "+strings.Join(fixed, " or ")+".")
 		}
 	}
+	vuln.Mitigation = strings.Join(fixes, "\n")
 
-	// Commit the transaction
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+	err := addVulnerability(tx, &vuln)
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	tracked[name] = true
+	for _, cve := range cves {
+		tracked[cve] = true
 	}
+	rep.NewVulns = append(rep.NewVulns, &vuln)
 	return nil
 }
 
-// UpdateSystemDescription updates the description of the given system.
-func UpdateSystemDescription(db *sql.DB, sid int64, desc string) error {
+// importScan records the findings of the scan report like ImportScan. The hosts that are unknown are added as
+// systems if create is set.
+func importScan(db *sql.DB, rep *vars.ScanReport, opts *vars.ScanOptions, create bool) (*vars.ScanDiff, error) {
+	diff := vars.ScanDiff{Scanner: rep.Scanner, DryRun: opts.DryRun}
+
 	//Start transaction and set rollback function
 	tx, err := db.Begin()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	rollback := true
 	defer func() {
@@ -1879,201 +5371,641 @@ func UpdateSystemDescription(db *sql.DB, sid int64, desc string) error {
 		}
 	}()
 
-	err = vars.UpdateSysDesc(tx, sid, desc)
-	if !vars.IsNilErr(err) {
-		return err
+	imp := newScanImport(tx, rep.Scanner, opts, &diff, create)
+	evidence := vars.ScanMitigation{Scanner: rep.Scanner, Scan: rep.Name, Mitigated: time.Now()}
+	for _, host := range rep.Hosts {
+		sys, err := imp.system(host)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		if sys == nil {
+			continue
+		}
+		reported := make(map[int64]bool)
+		pkgs := make(map[int64][]*vars.AffectedPkg)
+		for _, f := range host.Findings {
+			vuln, err := imp.vuln(f)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			pkgs[vuln.ID] = append(pkgs[vuln.ID], f.Packages...)
+			if reported[vuln.ID] {
+				continue
+			}
+			reported[vuln.ID] = true
+			aff := vars.ScanAffected{VulnID: vuln.ID, Vuln: vuln.Name, SysID: sys.ID, System: sys.Name}
+			mit, err := vars.GetAffectedMitigatedtx(tx, vuln.ID, sys.ID)
+			if vars.IsNoRowsError(err) {
+				err = vars.InsertAffected(tx, vuln.ID, sys.ID, false)
+				if !vars.IsNilErr(err) {
+					return nil, err
+				}
+				diff.NewAffected = append(diff.NewAffected, &aff)
+				continue
+			}
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			if !mit || !opts.Reconcile {
+				diff.Unchanged++
+				continue
+			}
+			err = reopenScanAffected(tx, &aff, &diff)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+		}
+
+		// Replace the vulnerable packages of the affected systems
+		for vid, ps := range pkgs {
+			if len(ps) == 0 {
+				continue
+			}
+			err = setAffectedPkgs(tx, vid, sys.ID, ps)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+		}
+
+		// Mitigate the affected systems the scanner no longer reports
+		if !opts.Reconcile {
+			continue
+		}
+		affs, err := vars.GetScanAffectedtx(tx, sys.ID, rep.Scanner)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		for _, aff := range affs {
+			if reported[aff.VulnID] {
+				continue
+			}
+			aff.System = sys.Name
+			_, err = vars.GetScanMitigationtx(tx, aff.VulnID, sys.ID)
+			if vars.IsNilErr(err) {
+				diff.Conflicts = append(diff.Conflicts, &vars.ScanConflict{ScanAffected: *aff, Reason: "Reopened by hand after a scan showed it mitigated"})
+				continue
+			}
+			if !vars.IsNoRowsError(err) {
+				return nil, err
+			}
+			err = vars.UpdateAffected(tx, aff.VulnID, sys.ID, true)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			err = vars.UpsertScanMitigation(tx, aff.VulnID, sys.ID, &evidence)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			diff.Mitigated = append(diff.Mitigated, aff)
+		}
+	}
+
+	if opts.DryRun {
+		return &diff, nil
 	}
 
 	// Commit the transaction
 	rollback = false
 	if e := tx.Commit(); e != nil {
-		return e
+		return nil, e
 	}
-	return nil
+	return &diff, nil
 }
 
-// UpdateSystemLocation updates the location of the given system.
-func UpdateSystemLocation(db *sql.DB, sid int64, loc string) error {
-	//Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
+// isAlnum returns true if the byte is an ASCII letter or digit.
+func isAlnum(b byte) bool {
+	return isDigit(b) || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// isDigit returns true if the byte is an ASCII digit.
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// ipInNets returns true if the IP address is in one of the networks.
+func ipInNets(ip net.IP, nets []*net.IPNet) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
 		}
-	}()
+	}
+	return false
+}
 
-	err = vars.UpdateSysLoc(tx, sid, loc)
+// matchKev records the matches between the CVEs of the vulnerabilities and the KEV catalog that were not recorded
+// yet and flags their vulnerabilities as exploitable, using the given transaction. It returns the new matches.
+func matchKev(tx *sql.Tx) ([]*vars.KevMatch, error) {
+	matches, err := vars.GetKevUnmatchedtx(tx)
 	if !vars.IsNilErr(err) {
-		return err
+		return matches, err
+	}
+	now := time.Now()
+	flagged := make(map[int64]bool)
+	for _, m := range matches {
+		m.Matched = now
+		err = vars.InsertKevMatch(tx, m)
+		if !vars.IsNilErr(err) {
+			return matches, err
+		}
+		if flagged[m.VulnID] {
+			continue
+		}
+		err = vars.UpdateExploitable(tx, m.VulnID, true)
+		if vars.IsNoRowsError(err) {
+			err = vars.InsertExploit(tx, m.VulnID, true, "")
+		}
+		if !vars.IsNilErr(err) {
+			return matches, err
+		}
+		flagged[m.VulnID] = true
 	}
+	return matches, nil
+}
 
-	// Commit the transaction
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+// plainText strips the Markdown of a note, leaving its text: heading and quote marks, code fences and emphasis are
+// removed and links are written as their text followed by the URL.
+func plainText(note string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.Replace(note, "\r\n", "\n", -1), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		line = mdBlockMark.ReplaceAllString(line, "")
+		line = mdLinkMark.ReplaceAllString(line, "$1 ($2)")
+		line = mdSpanMark.ReplaceAllString(line, "$1")
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// portsNotIn returns the ports of a that are not in b.
+func portsNotIn(a, b []*vars.SysPort) []*vars.SysPort {
+	var ports []*vars.SysPort
+	for _, p := range a {
+		found := false
+		for _, q := range b {
+			if p.Port == q.Port && p.Protocol == q.Protocol {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+// publisher returns the configured name of the organization publishing the CSAF documents and STIX bundles.
+func publisher() string {
+	if vars.Conf.CsafPublisher != "" {
+		return vars.Conf.CsafPublisher
+	}
+	return "VARS"
+}
+
+// readArchive reads the manifest and the rows of the tables of an archive written by Export, and writes the
+// attachment contents to files in dir. The paths of the files are returned by attachid. Plain tarballs are accepted
+// as well.
+func readArchive(r io.Reader, dir string) (*vars.ArchiveManifest, map[string][]map[string]interface{}, map[int64]string, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var man *vars.ArchiveManifest
+	tables := make(map[string][]map[string]interface{})
+	atts := make(map[int64]string)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		switch {
+		case hdr.Name == "manifest.json":
+			man = &vars.ArchiveManifest{}
+			if err := json.NewDecoder(tr).Decode(man); err != nil {
+				return nil, nil, nil, errors.New("Varsapi: readArchive: manifest.json: " + err.Error())
+			}
+		case strings.HasPrefix(hdr.Name, "tables/") && strings.HasSuffix(hdr.Name, ".jsonl"):
+			name := strings.TrimSuffix(strings.TrimPrefix(hdr.Name, "tables/"), ".jsonl")
+			dec := json.NewDecoder(tr)
+			dec.UseNumber()
+			rows := []map[string]interface{}{}
+			for {
+				var row map[string]interface{}
+				if err := dec.Decode(&row); err == io.EOF {
+					break
+				} else if err != nil {
+					return nil, nil, nil, errors.New("Varsapi: readArchive: " + hdr.Name + ": " + err.Error())
+				}
+				rows = append(rows, row)
+			}
+			tables[name] = rows
+		case strings.HasPrefix(hdr.Name, "attachments/"):
+			id, err := strconv.ParseInt(strings.TrimPrefix(hdr.Name, "attachments/"), 10, 64)
+			if err != nil {
+				return nil, nil, nil, errors.New("Varsapi: readArchive: Unexpected member " + hdr.Name)
+			}
+			path := filepath.Join(dir, strconv.FormatInt(id, 10))
+			f, err := os.Create(path)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			_, err = io.Copy(f, tr)
+			if e := f.Close(); err == nil {
+				err = e
+			}
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			atts[id] = path
+		default:
+			return nil, nil, nil, errors.New("Varsapi: readArchive: Unexpected member " + hdr.Name)
+		}
 	}
-	return nil
+	if man == nil {
+		return nil, nil, nil, errors.New("Varsapi: readArchive: The archive has no manifest")
+	}
+	return man, tables, atts, nil
 }
 
-// UpdateSystemName updates the name associated with the sysid.
-func UpdateSystemName(db *sql.DB, sid int64, name string) error {
-	// Check if name is available
-	a, err := vars.NameIsAvailable("sys", name)
+// reopenScanAffected reopens the affected system if it was mitigated by a scan import. Otherwise it is reported as
+// a conflict.
+func reopenScanAffected(tx *sql.Tx, aff *vars.ScanAffected, diff *vars.ScanDiff) error {
+	_, err := vars.GetScanMitigationtx(tx, aff.VulnID, aff.SysID)
+	if vars.IsNoRowsError(err) {
+		diff.Conflicts = append(diff.Conflicts, &vars.ScanConflict{ScanAffected: *aff, Reason: "Mitigated by hand but still reported by the scan"})
+		return nil
+	}
 	if !vars.IsNilErr(err) {
 		return err
 	}
-	if !a {
-		return vars.NewErr(vars.NameNotAvailable, "VARS", "varsapi", "UpdateSystemName")
+	dates, err := vars.GetVulnDates(aff.VulnID)
+	if !vars.IsNilErr(err) {
+		return err
 	}
-
-	// Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
+	if dates.Mitigated.Valid {
+		diff.Conflicts = append(diff.Conflicts, &vars.ScanConflict{ScanAffected: *aff, Reason: "Reported by the scan but the vulnerability is closed"})
+		return nil
+	}
+	err = vars.UpdateAffected(tx, aff.VulnID, aff.SysID, false)
+	if !vars.IsNilErr(err) {
 		return err
 	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
-		}
-	}()
-
-	err = vars.UpdateSysName(tx, sid, name)
+	err = vars.DeleteScanMitigation(tx, aff.VulnID, aff.SysID)
 	if !vars.IsNilErr(err) {
 		return err
 	}
+	diff.Reopened = append(diff.Reopened, aff)
+	return nil
+}
 
-	// Commit the transaction
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+// scanImport resolves the hosts and findings of a scan report within the transaction of the import. The systems and
+// vulnerabilities added by the import are cached, since some of the lookups can't see the uncommitted rows.
+type scanImport struct {
+	tx      *sql.Tx
+	scanner string
+	opts    *vars.ScanOptions
+	diff    *vars.ScanDiff
+	create  bool                           // Add the hosts that are unknown as systems
+	systems map[string]*vars.System        // Systems by lower case name and address
+	plugins map[string]*vars.Vulnerability // Vulnerabilities by plugin ID
+	cves    map[string]*vars.Vulnerability // Vulnerabilities added by the import by CVE
+	names   map[string]bool                // Names of the vulnerabilities added by the import
+}
+
+// newScanImport returns a scanImport recording its changes in diff. The hosts that are unknown are added as systems
+// if create is set.
+func newScanImport(tx *sql.Tx, scanner string, opts *vars.ScanOptions, diff *vars.ScanDiff, create bool) *scanImport {
+	return &scanImport{
+		tx:      tx,
+		scanner: scanner,
+		opts:    opts,
+		diff:    diff,
+		create:  create,
+		systems: make(map[string]*vars.System),
+		plugins: make(map[string]*vars.Vulnerability),
+		cves:    make(map[string]*vars.Vulnerability),
+		names:   make(map[string]bool),
 	}
-	return nil
 }
 
-// UpdateSystemOS updates the operating system of the given system.
-func UpdateSystemOS(db *sql.DB, sid int64, opsys string) error {
-	//Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
-		return err
+// system returns the system of the host, adding it if imp.create is set. A nil system is returned
+// if the host is unknown and was not added.
+func (imp *scanImport) system(host *vars.ScanHost) (*vars.System, error) {
+	name, addr := strings.ToLower(host.Name), strings.ToLower(host.Address)
+	for _, key := range []string{name, addr} {
+		if sys, ok := imp.systems[key]; ok && key != "" {
+			return sys, nil
+		}
 	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
+
+	// Look the host up by name and then by address
+	var id int64
+	var err error
+	found := false
+	for _, key := range []string{host.Name, host.Address} {
+		if key == "" {
+			continue
 		}
-	}()
+		id, err = vars.GetSystemIDtx(imp.tx, key)
+		if vars.IsNoRowsError(err) {
+			id, err = vars.GetSysIDByAddrtx(imp.tx, key)
+		}
+		if vars.IsNilErr(err) {
+			found = true
+			break
+		}
+		if !vars.IsNoRowsError(err) {
+			return nil, err
+		}
+	}
 
-	err = vars.UpdateSysOS(tx, sid, opsys)
-	if !vars.IsNilErr(err) {
-		return err
+	var sys *vars.System
+	if found {
+		sys, err = vars.GetSystem(id)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+	} else {
+		label := host.Name
+		if label == "" {
+			label = host.Address
+		}
+		if !imp.create || label == "" {
+			imp.diff.UnknownHosts = append(imp.diff.UnknownHosts, label)
+			return nil, nil
+		}
+		sys = CreateSystem(label, host.Type, host.OpSys, "unknown", "Added by the "+imp.scanner+" import", "active")
+		if sys.Type == "" {
+			sys.Type = "unknown"
+		}
+		if sys.OpSys == "" {
+			sys.OpSys = "unknown"
+		}
+		err = vars.InsertSystem(imp.tx, sys)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		sys.ID, err = vars.GetSystemIDtx(imp.tx, sys.Name)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		for _, a := range []string{host.Address, host.Name} {
+			if a == "" || stringInSlice(strings.ToLower(a), &sys.Addresses) {
+				continue
+			}
+			err = vars.InsertSysAddr(imp.tx, sys.ID, a)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			sys.Addresses = append(sys.Addresses, strings.ToLower(a))
+		}
+		imp.diff.NewSystems = append(imp.diff.NewSystems, sys)
 	}
 
-	// Commit the transaction
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+	for _, key := range []string{name, addr} {
+		if key != "" {
+			imp.systems[key] = sys
+		}
 	}
-	return nil
+	return sys, nil
 }
 
-// UpdateSystemState sets the state of the given system to 'state'.
-func UpdateSystemState(db *sql.DB, sid int64, state string) error {
-	//Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
-		return err
+// vuln returns the vulnerability of the finding, starting a new vulnerability if the plugin isn't mapped to one
+// and none of its CVEs are in VARS. The plugin is mapped to the vulnerability that is returned.
+func (imp *scanImport) vuln(f *vars.ScanFinding) (*vars.Vulnerability, error) {
+	if vuln, ok := imp.plugins[f.PluginID]; ok {
+		return vuln, nil
+	}
+
+	// Look the finding up by plugin and then by CVE
+	var vuln *vars.Vulnerability
+	mapped := false
+	vid, err := vars.GetScanRefVulntx(imp.tx, imp.scanner, f.PluginID)
+	if vars.IsNilErr(err) {
+		mapped = true
+	} else if !vars.IsNoRowsError(err) {
+		return nil, err
+	} else {
+		for _, cve := range f.Cves {
+			if v, ok := imp.cves[cve]; ok {
+				vuln = v
+				break
+			}
+			ids, err := vars.GetVulnIDsByCve(cve)
+			if !vars.IsNilErr(err) {
+				return nil, err
+			}
+			if len(*ids) > 0 {
+				vid = (*ids)[0]
+				break
+			}
+		}
 	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
+	if vuln == nil && vid != 0 {
+		vuln, err = vars.GetVulnerability(vid)
+		if !vars.IsNilErr(err) {
+			return nil, err
 		}
-	}()
+	}
 
-	err = vars.UpdateSysState(tx, sid, state)
-	if !vars.IsNilErr(err) {
-		return err
+	// Start a new vulnerability for the finding
+	if vuln == nil {
+		vuln = &vars.Vulnerability{
+			Name:       f.Name,
+			Cves:       f.Cves,
+			Cvss:       f.Cvss,
+			Finder:     imp.opts.Employee,
+			Initiator:  imp.opts.Employee,
+			Summary:    f.Summary,
+			Test:       "Reported by " + imp.scanner + " plugin " + f.PluginID,
+			Mitigation: f.Solution,
+			References: f.References,
+		}
+		a, err := vars.NameIsAvailable("vuln", vuln.Name)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		if !a || vuln.Name == "" || imp.names[vuln.Name] {
+			vuln.Name = strings.TrimSpace(vuln.Name + " (" + imp.scanner + " " + f.PluginID + ")")
+		}
+		err = addVulnerability(imp.tx, vuln)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
+		imp.names[vuln.Name] = true
+		for _, cve := range vuln.Cves {
+			imp.cves[cve] = vuln
+		}
+		imp.diff.NewVulns = append(imp.diff.NewVulns, vuln)
 	}
 
-	// Commit the transaction
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+	if !mapped {
+		err = vars.InsertScanRef(imp.tx, imp.scanner, f.PluginID, vuln.ID)
+		if !vars.IsNilErr(err) {
+			return nil, err
+		}
 	}
-	return nil
+	imp.plugins[f.PluginID] = vuln
+	return vuln, nil
 }
 
-// UpdateSystemType updates the type of system.
-func UpdateSystemType(db *sql.DB, sid int64, tp string) error {
-	//Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
-		return err
+// sbomCandidates returns the open vulnerabilities that the system is not yet affected by and that list one of the
+// components. See ImportSbom.
+func sbomCandidates(sys *vars.System, comps []*vars.Component) ([]*vars.SbomCandidate, error) {
+	cands := []*vars.SbomCandidate{}
+	open, err := vars.GetOpenVulnIDs()
+	if !vars.IsNilErr(err) {
+		return cands, err
 	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
-		}
-	}()
-
-	err = vars.UpdateSysType(tx, sid, tp)
+	affected, err := vars.GetSysVulnIDs(sys.ID)
 	if !vars.IsNilErr(err) {
-		return err
+		return cands, err
+	}
+	skip := make(map[int64]bool)
+	for _, vid := range *open {
+		skip[vid] = false
+	}
+	for _, vid := range *affected {
+		skip[vid] = true
+	}
+	pkgs, err := vars.GetOpenVulnPkgs()
+	if !vars.IsNilErr(err) {
+		return cands, err
+	}
+	byName := make(map[string][]*vars.VulnPkg)
+	for _, p := range pkgs {
+		name := strings.ToLower(p.Package)
+		byName[name] = append(byName[name], p)
 	}
 
-	// Commit the transaction
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+	type candKey struct {
+		vid  int64
+		comp *vars.Component
 	}
-	return nil
+	names := make(map[int64]string)
+	seen := make(map[candKey]bool)
+	add := func(vid int64, vname string, c *vars.Component, reason string) {
+		key := candKey{vid, c}
+		if s, ok := skip[vid]; !ok || s || seen[key] {
+			return
+		}
+		seen[key] = true
+		cands = append(cands, &vars.SbomCandidate{
+			ScanAffected: vars.ScanAffected{VulnID: vid, Vuln: vname, SysID: sys.ID, System: sys.Name},
+			Component:    c.Name,
+			Version:      c.Version,
+			Reason:       reason,
+		})
+	}
+	for _, c := range comps {
+		for _, cve := range c.Cves {
+			ids, err := vars.GetVulnIDsByCve(cve)
+			if !vars.IsNilErr(err) {
+				return cands, err
+			}
+			for _, vid := range *ids {
+				if _, ok := names[vid]; !ok {
+					vuln, err := vars.GetVulnerability(vid)
+					if !vars.IsNilErr(err) {
+						return cands, err
+					}
+					names[vid] = vuln.Name
+				}
+				add(vid, names[vid], c, "The SBOM reports "+cve+" for the component")
+			}
+		}
+		if c.Version == "" {
+			continue
+		}
+		checked := make(map[string]bool)
+		for _, name := range componentNames(c) {
+			if checked[name] {
+				continue
+			}
+			checked[name] = true
+			for _, p := range byName[name] {
+				switch {
+				case c.Version == p.Installed:
+					add(p.VulnID, p.Vuln, c, "Version "+p.Installed+" of "+p.Package+" is vulnerable")
+				case p.Installed != "" && p.Fixed != "" && compareVersions(c.Version, p.Installed) > 0 &&
+					compareVersions(c.Version, p.Fixed) < 0:
+					add(p.VulnID, p.Vuln, c, p.Package+" is fixed in version "+p.Fixed)
+				}
+			}
+		}
+	}
+	return cands, nil
 }
 
-// UpdateTicket will update the ticket associated with the row (vid, ticket).
-func UpdateTicket(db *sql.DB, vid int64, oldticket, newticket string) error {
-	// Start transaction and set rollback function
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	rollback := true
-	defer func() {
-		if rollback {
-			tx.Rollback()
+// scanTargets parses the targets of a scan report into networks. Addresses are converted into single host networks
+// and targets that are neither are skipped.
+func scanTargets(targets []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, t := range targets {
+		if _, n, err := net.ParseCIDR(t); err == nil {
+			nets = append(nets, n)
+		} else if ip := net.ParseIP(t); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
 		}
-	}()
+	}
+	return nets
+}
 
-	err = vars.UpdateTicket(tx, vid, oldticket, newticket)
-	if !vars.IsNilErr(err) {
+// setAffectedPkgs replaces the vulnerable packages of the affected row (vid, sid). Packages listed more than once
+// are only inserted once.
+func setAffectedPkgs(tx *sql.Tx, vid, sid int64, pkgs []*vars.AffectedPkg) error {
+	err := vars.DeleteAffectedPkgs(tx, vid, sid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
 		return err
 	}
-
-	rollback = false
-	if e := tx.Commit(); e != nil {
-		return e
+	seen := make(map[string]bool)
+	for _, p := range pkgs {
+		if seen[p.Package+" "+p.Installed] {
+			continue
+		}
+		seen[p.Package+" "+p.Installed] = true
+		err = vars.InsertAffectedPkg(tx, vid, sid, p)
+		if !vars.IsNilErr(err) {
+			return err
+		}
 	}
 	return nil
 }
 
-// UpdateTickets determines the rows that need to be deleted/added and calls the appropriate VARS function.
-func UpdateTickets(tx *sql.Tx, old, vuln *vars.Vulnerability) error {
-	del := toBeDeleted(&old.Tickets, &vuln.Tickets)
-	for _, tick := range *del {
-		err := vars.DeleteTicket(tx, vuln.ID, tick)
+// setNoteMentions replaces the mentions recorded for the note with the employees mentioned in its text.
+// Mentions of usernames that are not in VARS are ignored.
+func setNoteMentions(tx *sql.Tx, noteid int64, note string) error {
+	err := vars.DeleteNoteMentions(tx, noteid)
+	if !vars.IsNilErr(err) && !vars.IsNoRowsError(err) {
+		return err
+	}
+	for _, username := range ParseMentions(note) {
+		eid, err := vars.GetEmpIDtx(tx, username)
 		if !vars.IsNilErr(err) {
+			if vars.IsNoRowsError(err) {
+				continue
+			}
 			return err
 		}
-	}
-	add := toBeAdded(&old.Tickets, &vuln.Tickets)
-	for _, tick := range *add {
-		err := vars.InsertTicket(tx, vuln.ID, tick)
+		err = vars.InsertNoteMention(tx, noteid, eid)
 		if !vars.IsNilErr(err) {
 			return err
 		}
@@ -2113,3 +6045,208 @@ func toBeDeleted(oldSlice, newSlice *[]string) *[]string {
 	}
 	return &del
 }
+
+// validateArchive checks that the archive can be imported: the version is supported, the tables and columns are
+// known and complete, the serial IDs are unique, every reference points to a row of the archive and every
+// attachment has its contents. Up to ten problems are listed in the error.
+func validateArchive(man *vars.ArchiveManifest, tables map[string][]map[string]interface{}, atts map[int64]string) error {
+	if man.Format != vars.ArchiveFormat {
+		return errors.New("Varsapi: validateArchive: Not a VARS archive")
+	}
+	if man.Version < 1 || man.Version > vars.ArchiveVersion {
+		return fmt.Errorf("Varsapi: validateArchive: Archive version %d is not supported (latest is %d)", man.Version, vars.ArchiveVersion)
+	}
+	var problems []string
+	for name, rows := range tables {
+		t := archiveTable(name)
+		if t == nil {
+			problems = append(problems, "unknown table "+name)
+			continue
+		}
+		if len(rows) != man.Tables[name] {
+			problems = append(problems, fmt.Sprintf("%s has %d rows, the manifest lists %d", name, len(rows), man.Tables[name]))
+		}
+		for i, row := range rows {
+			for col := range row {
+				if !stringInSlice(col, &t.Columns) {
+					problems = append(problems, fmt.Sprintf("%s row %d: unknown column %s", name, i+1, col))
+				}
+			}
+		}
+	}
+	for name, n := range man.Tables {
+		if _, ok := tables[name]; !ok && n > 0 {
+			problems = append(problems, "missing table "+name)
+		}
+	}
+
+	// Collect the keys referenced by the tables, then check the references
+	keys := make(map[string]map[string]bool)
+	for _, t := range vars.ArchiveTables {
+		for _, ref := range t.Refs {
+			id := ref.Table + ":" + strings.Join(ref.Keys, ",")
+			if keys[id] != nil {
+				continue
+			}
+			keys[id] = make(map[string]bool)
+			for _, row := range tables[ref.Table] {
+				if key, ok := archiveKey(row, ref.Keys); ok {
+					keys[id][key] = true
+				}
+			}
+		}
+	}
+	for _, t := range vars.ArchiveTables {
+		serials := make(map[int64]bool)
+		for i, row := range tables[t.Name] {
+			if t.Serial != "" {
+				id, ok := archiveInt(row[t.Serial])
+				if !ok || serials[id] {
+					problems = append(problems, fmt.Sprintf("%s row %d: missing or repeated %s", t.Name, i+1, t.Serial))
+				}
+				serials[id] = true
+				if t.Name == "attachments" && atts[id] == "" {
+					problems = append(problems, fmt.Sprintf("attachments row %d: missing contents", i+1))
+				}
+			}
+			for _, ref := range t.Refs {
+				key, ok := archiveKey(row, ref.Columns)
+				if ok && !keys[ref.Table+":"+strings.Join(ref.Keys, ",")][key] {
+					problems = append(problems, fmt.Sprintf("%s row %d: %s %s is not in %s", t.Name, i+1,
+						strings.Join(ref.Columns, ","), strings.Replace(key, "\x00", ",", -1), ref.Table))
+				}
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	if len(problems) > 10 {
+		problems = append(problems[:10], fmt.Sprintf("and %d more", len(problems)-10))
+	}
+	return errors.New("Varsapi: validateArchive: " + strings.Join(problems, "; "))
+}
+
+// validateTask checks that the task has a title and that its vulnerability, assignee and system exist. The problem
+// is returned as an InvalidValue error of the function fn.
+func validateTask(fn string, t *vars.Task) error {
+	if strings.TrimSpace(t.Title) == "" {
+		return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", fn, "Task title is required")
+	}
+	_, err := vars.GetVulnerability(t.VulnID)
+	if vars.IsNoRowsError(err) {
+		return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", fn, "Unknown vulnerability")
+	}
+	if !vars.IsNilErr(err) {
+		return err
+	}
+	if t.Assignee.Valid {
+		_, err = vars.GetEmployee(t.Assignee.Int64)
+		if vars.IsNoRowsError(err) {
+			return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", fn, "Unknown assignee")
+		}
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	if t.System.Valid {
+		_, err = vars.GetSystem(t.System.Int64)
+		if vars.IsNoRowsError(err) {
+			return vars.NewErr(vars.InvalidValue, "VARS", "varsapi", fn, "Unknown system")
+		}
+		if !vars.IsNilErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// vulnerabilityRows returns the cells of the VulnerabilityColumns for each vulnerability.
+func vulnerabilityRows(vulns []*vars.Vulnerability) ([][]interface{}, error) {
+	emps, err := vars.GetEmployees()
+	if !vars.IsNilErr(err) {
+		return nil, err
+	}
+	names := make(map[int64]string)
+	for _, e := range emps {
+		names[e.ID] = e.FirstName + " " + e.LastName
+	}
+	date := func(t vars.VarsNullTime) interface{} {
+		if !t.Valid {
+			return nil
+		}
+		return t.Time.Format("2006-01-02 15:04:05")
+	}
+	join := func(items []string) string {
+		sorted := append([]string{}, items...)
+		sort.Strings(sorted)
+		return strings.Join(sorted, "; ")
+	}
+
+	var rows [][]interface{}
+	for _, v := range vulns {
+		var epss interface{}
+		if v.Epss != nil {
+			epss = v.Epss.Score
+		}
+		kev := "no"
+		if len(v.Kev) > 0 {
+			kev = "yes"
+		}
+		mitigated := 0
+		for _, a := range v.AffSystems {
+			if a.Mitigated {
+				mitigated++
+			}
+		}
+		rows = append(rows, []interface{}{
+			v.ID,
+			v.Name,
+			join(v.Cves),
+			v.Cvss,
+			v.CorpScore,
+			epss,
+			kev,
+			date(v.Dates.Published),
+			v.Dates.Initiated.Format("2006-01-02 15:04:05"),
+			date(v.Dates.Mitigated),
+			len(v.AffSystems),
+			mitigated,
+			join(v.Tickets),
+			names[v.Initiator],
+		})
+	}
+	return rows, nil
+}
+
+// versionTokens splits the version into runs of digits and runs of letters, dropping the separators and an
+// epoch or "v" prefix.
+func versionTokens(v string) []string {
+	if i := strings.Index(v, ":"); i >= 0 {
+		v = v[i+1:]
+	}
+	v = strings.TrimPrefix(strings.ToLower(v), "v")
+	var tokens []string
+	start := -1
+	for i := 0; i <= len(v); i++ {
+		if start >= 0 && (i == len(v) || isDigit(v[i]) != isDigit(v[start]) || !isAlnum(v[i])) {
+			tokens = append(tokens, v[start:i])
+			start = -1
+		}
+		if start < 0 && i < len(v) && isAlnum(v[i]) {
+			start = i
+		}
+	}
+	return tokens
+}
+
+// writeArchiveMember writes the data as a member of the archive.
+func writeArchiveMember(tw *tar.Writer, name string, data []byte) error {
+	hdr := tar.Header{Name: name, Mode: 0640, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(&hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
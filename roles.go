@@ -0,0 +1,126 @@
+//////////////////////////////////////////////////////////////////////////////////////
+//                                                                                  //
+//    VARS (Vulnerability Analysis Reference System) is software used to track      //
+//    vulnerabilities from discovery through analysis to mitigation.                //
+//    Copyright (C) 2017  Christian Belk                                            //
+//                                                                                  //
+//    This program is free software: you can redistribute it and/or modify          //
+//    it under the terms of the GNU General Public License as published by          //
+//    the Free Software Foundation, either version 3 of the License, or             //
+//    (at your option) any later version.                                           //
+//                                                                                  //
+//    This program is distributed in the hope that it will be useful,               //
+//    but WITHOUT ANY WARRANTY; without even the implied warranty of                //
+//    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the                 //
+//    GNU General Public License for more details.                                  //
+//                                                                                  //
+//    See the full License here: https://github.com/cbelk/vars/blob/master/LICENSE  //
+//                                                                                  //
+//////////////////////////////////////////////////////////////////////////////////////
+
+package vars
+
+// Permissions that can be granted to a role.
+const (
+	PermAffectedUpdate  = "affected.update"  // Add, update and remove affected systems
+	PermAttachmentWrite = "attachment.write" // Upload and remove own attachments
+	PermEmployeeList    = "employee.list"    // List the employees (used when assigning a finder)
+	PermEmployeeManage  = "employee.manage"  // Add, update, deactivate and reactivate employees
+	PermEmployeeView    = "employee.view"    // Look up the name of an employee
+	PermNoteWrite       = "note.write"       // Add notes and edit or delete own notes
+	PermReportView      = "report.view"      // Run the report plugins
+	PermRoleManage      = "role.manage"      // Define the permissions of the roles
+	PermScanImport      = "scan.import"      // Import vulnerability scanner reports
+	PermSystemCreate    = "system.create"    // Add systems
+	PermSystemDelete    = "system.delete"    // Delete systems
+	PermSystemUpdate    = "system.update"    // Update systems
+	PermSystemView      = "system.view"      // View systems
+	PermTemplateManage  = "template.manage"  // Add, update and delete vulnerability templates
+	PermVulnAssign      = "vuln.assign"      // Change the finder of a vulnerability
+	PermVulnClose       = "vuln.close"       // Close and reopen vulnerabilities
+	PermVulnCreate      = "vuln.create"      // Start new vulnerability assessments
+	PermVulnDelete      = "vuln.delete"      // Delete vulnerabilities
+	PermVulnRename      = "vuln.rename"      // Rename vulnerabilities
+	PermVulnUpdate      = "vuln.update"      // Update the details of vulnerabilities
+	PermVulnView        = "vuln.view"        // View vulnerabilities and their notes
+)
+
+// Default roles. These map to the four user levels used before roles were introduced (0 to 3).
+const (
+	RoleAdmin      = "admin"
+	RolePrivileged = "privileged"
+	RoleStandard   = "standard"
+	RoleReporter   = "reporter"
+)
+
+// Permissions holds all of the permissions known to VARS.
+var Permissions = []string{
+	PermAffectedUpdate,
+	PermAttachmentWrite,
+	PermEmployeeList,
+	PermEmployeeManage,
+	PermEmployeeView,
+	PermNoteWrite,
+	PermReportView,
+	PermRoleManage,
+	PermScanImport,
+	PermSystemCreate,
+	PermSystemDelete,
+	PermSystemUpdate,
+	PermSystemView,
+	PermTemplateManage,
+	PermVulnAssign,
+	PermVulnClose,
+	PermVulnCreate,
+	PermVulnDelete,
+	PermVulnRename,
+	PermVulnUpdate,
+	PermVulnView,
+}
+
+// standardPerms are the permissions granted to the standard role.
+var standardPerms = []string{
+	PermAffectedUpdate,
+	PermAttachmentWrite,
+	PermEmployeeView,
+	PermNoteWrite,
+	PermReportView,
+	PermSystemCreate,
+	PermSystemUpdate,
+	PermSystemView,
+	PermVulnUpdate,
+	PermVulnView,
+}
+
+// DefaultRoles holds the permissions of the default roles. Roles with the same name in the
+// database or the configuration replace these.
+var DefaultRoles = map[string][]string{
+	RoleAdmin:      Permissions,
+	RolePrivileged: append([]string{PermEmployeeList, PermScanImport, PermSystemDelete, PermTemplateManage, PermVulnAssign, PermVulnClose, PermVulnCreate, PermVulnDelete, PermVulnRename}, standardPerms...),
+	RoleStandard:   standardPerms,
+	RoleReporter:   {PermReportView},
+}
+
+// Roles maps a role name to its set of permissions.
+type Roles map[string]map[string]bool
+
+// Can returns true if the role has the permission.
+func (r Roles) Can(role, perm string) bool {
+	return r[role][perm]
+}
+
+// BuildRoles returns the roles built from the given sources. Sources later in the list replace the
+// roles of the same name from earlier sources.
+func BuildRoles(sources ...map[string][]string) Roles {
+	roles := make(Roles)
+	for _, src := range sources {
+		for name, perms := range src {
+			set := make(map[string]bool)
+			for _, p := range perms {
+				set[p] = true
+			}
+			roles[name] = set
+		}
+	}
+	return roles
+}
@@ -16,25 +16,25 @@ import (
 
 var (
 	emps = []vars.Employee{
-		{FirstName: "Bob", LastName: "Barker", Email: "bob.barker@test.it", UserName: "user3", Level: 3},
-		{FirstName: "Alan", LastName: "Turing", Email: "alan.turing@test.it", UserName: "user0", Level: 0},
-		{FirstName: "Aretha", LastName: "Franklin", Email: "aretha.franklin@test.it", UserName: "user2", Level: 2},
-		{FirstName: "Pharoahe", LastName: "Monch", Email: "pahroahe.monch@test.it", UserName: "user1", Level: 1},
-		{FirstName: "Ismael", LastName: "Diaz", Email: "ismael.diaz@test.it", UserName: "ismaeld", Level: 2},
-		{FirstName: "Aaron", LastName: "Williams", Email: "aaron.williams@test.it", UserName: "aaronw", Level: 1},
-		{FirstName: "Sara", LastName: "Davidson", Email: "sara.davidson@test.it", UserName: "sarad", Level: 1},
-		{FirstName: "Florian", LastName: "Guillot", Email: "florian.guillot@test.it", UserName: "floriang", Level: 3},
-		{FirstName: "Roope", LastName: "Lampinen", Email: "roope.lampinen@test.it", UserName: "roopel", Level: 2},
-		{FirstName: "Hélvio", LastName: "Araújo", Email: "hélvio.araújo@test.it", UserName: "hélvioa", Level: 2},
-		{FirstName: "William", LastName: "Walker", Email: "william.walker@test.it", UserName: "williamw", Level: 3},
-		{FirstName: "Martin", LastName: "Little", Email: "martin.little@test.it", UserName: "martinl", Level: 2},
-		{FirstName: "Daryl", LastName: "Jenkins", Email: "daryl.jenkins@test.it", UserName: "darylj", Level: 2},
-		{FirstName: "Noah", LastName: "Andersen", Email: "noah.andersen@test.it", UserName: "noaha", Level: 1},
-		{FirstName: "Oliver", LastName: "Savela", Email: "oliver.savela@test.it", UserName: "olivers", Level: 3},
-		{FirstName: "Micheal", LastName: "Shelton", Email: "micheal.shelton@test.it", UserName: "micheals", Level: 2},
-		{FirstName: "Julius", LastName: "Renner", Email: "julius.renner@test.it", UserName: "juliusr", Level: 3},
-		{FirstName: "Silke", LastName: "Rasmussen", Email: "silke.rasmussen@test.it", UserName: "silker", Level: 3},
-		{FirstName: "Natalie", LastName: "Zhang", Email: "natalie.zhang@test.it", UserName: "nataliez", Level: 1},
+		{FirstName: "Bob", LastName: "Barker", Email: "bob.barker@test.it", UserName: "user3", Role: vars.RoleReporter},
+		{FirstName: "Alan", LastName: "Turing", Email: "alan.turing@test.it", UserName: "user0", Role: vars.RoleAdmin},
+		{FirstName: "Aretha", LastName: "Franklin", Email: "aretha.franklin@test.it", UserName: "user2", Role: vars.RoleStandard},
+		{FirstName: "Pharoahe", LastName: "Monch", Email: "pahroahe.monch@test.it", UserName: "user1", Role: vars.RolePrivileged},
+		{FirstName: "Ismael", LastName: "Diaz", Email: "ismael.diaz@test.it", UserName: "ismaeld", Role: vars.RoleStandard},
+		{FirstName: "Aaron", LastName: "Williams", Email: "aaron.williams@test.it", UserName: "aaronw", Role: vars.RolePrivileged},
+		{FirstName: "Sara", LastName: "Davidson", Email: "sara.davidson@test.it", UserName: "sarad", Role: vars.RolePrivileged},
+		{FirstName: "Florian", LastName: "Guillot", Email: "florian.guillot@test.it", UserName: "floriang", Role: vars.RoleReporter},
+		{FirstName: "Roope", LastName: "Lampinen", Email: "roope.lampinen@test.it", UserName: "roopel", Role: vars.RoleStandard},
+		{FirstName: "Hélvio", LastName: "Araújo", Email: "hélvio.araújo@test.it", UserName: "hélvioa", Role: vars.RoleStandard},
+		{FirstName: "William", LastName: "Walker", Email: "william.walker@test.it", UserName: "williamw", Role: vars.RoleReporter},
+		{FirstName: "Martin", LastName: "Little", Email: "martin.little@test.it", UserName: "martinl", Role: vars.RoleStandard},
+		{FirstName: "Daryl", LastName: "Jenkins", Email: "daryl.jenkins@test.it", UserName: "darylj", Role: vars.RoleStandard},
+		{FirstName: "Noah", LastName: "Andersen", Email: "noah.andersen@test.it", UserName: "noaha", Role: vars.RolePrivileged},
+		{FirstName: "Oliver", LastName: "Savela", Email: "oliver.savela@test.it", UserName: "olivers", Role: vars.RoleReporter},
+		{FirstName: "Micheal", LastName: "Shelton", Email: "micheal.shelton@test.it", UserName: "micheals", Role: vars.RoleStandard},
+		{FirstName: "Julius", LastName: "Renner", Email: "julius.renner@test.it", UserName: "juliusr", Role: vars.RoleReporter},
+		{FirstName: "Silke", LastName: "Rasmussen", Email: "silke.rasmussen@test.it", UserName: "silker", Role: vars.RoleReporter},
+		{FirstName: "Natalie", LastName: "Zhang", Email: "natalie.zhang@test.it", UserName: "nataliez", Role: vars.RolePrivileged},
 	}
 	vulns = []vars.Vulnerability{
 		{Name: "DirtyCOW", Cves: []string{"CVE-2016-5195"}, Cvss: 7.8, CorpScore: 8, CvssLink: vars.VarsNullString{sql.NullString{String: "https://nvd.nist.gov/vuln-metrics/cvss/v3-calculator?name=CVE-2016-5195&vector=AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", Valid: true}}, Finder: 1, Initiator: 3, Summary: "This crap is bad!!!", Test: "Look for a cow in the kernel", Mitigation: "Kill it with fire", Dates: vars.VulnDates{Published: vars.VarsNullTime{pq.NullTime{Time: time.Date(2016, time.November, 10, 1, 2, 3, 4, time.UTC), Valid: true}}}, Tickets: []string{"ticket101", "tciket102"}, References: []string{"https://dirtycow.ninja/", "https://nvd.nist.gov/vuln/detail/CVE-2016-5195"}, Exploit: vars.VarsNullString{sql.NullString{String: "https://github.com/dirtycow/dirtycow.github.io/wiki/PoCs", Valid: true}}, Exploitable: vars.VarsNullBool{sql.NullBool{Bool: true, Valid: true}}},
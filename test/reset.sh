@@ -1,10 +1,13 @@
 #!/bin/bash
 
 # Delete data from tables
-sudo -u vars psql -c 'truncate emp,systems,tickets,vuln,affected,impact,dates,exploits,ref,cves,notes cascade;'
+sudo -u vars psql -c 'truncate emp,systems,tickets,vuln,affected,affectedpkgs,impact,dates,exploits,ref,cves,notes,notementions,noterevisions,attachments,rolepermissions,related,merges,templates,templaterefs,tasks,cwes,kevmatches,scanmitigations,scanrefs,sysaddrs,syscomponents,sysports cascade;'
 
 # Reset sequences
 sudo -u vars psql -c 'alter sequence emp_empid_seq restart with 1;'
 sudo -u vars psql -c 'alter sequence vuln_vulnid_seq restart with 1;'
 sudo -u vars psql -c 'alter sequence systems_sysid_seq restart with 1;'
 sudo -u vars psql -c 'alter sequence notes_noteid_seq restart with 1;'
+sudo -u vars psql -c 'alter sequence attachments_attachid_seq restart with 1;'
+sudo -u vars psql -c 'alter sequence templates_templateid_seq restart with 1;'
+sudo -u vars psql -c 'alter sequence tasks_taskid_seq restart with 1;'
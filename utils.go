@@ -25,6 +25,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	_ "github.com/lib/pq" // Postgresql driver
 )
@@ -36,6 +37,29 @@ type Config struct {
 	User string
 	Pass string
 	Name string
+
+	// Attachment settings
+	AttachStore   string   // Blob store for attachment contents: "file" (default) or "largeobject"
+	AttachDir     string   // Directory used by the file blob store
+	AttachMaxSize int64    // Maximum attachment size in bytes
+	AttachTypes   []string // MIME types that are allowed to be attached
+
+	// Roles maps a role name to its permissions. These replace the default roles and the
+	// roles defined in the database with the same name.
+	Roles map[string][]string
+
+	// ScanCreateSystems controls whether scanner imports add the hosts that can't be resolved to a system
+	// by name or address as new systems.
+	ScanCreateSystems bool
+
+	// CsafPublisher and CsafNamespace name the organization publishing the CSAF documents and its URL. They
+	// default to VARS and its project URL. CsafPublisher is also the producer of the STIX bundles.
+	CsafPublisher string
+	CsafNamespace string
+
+	// StixTlp is the TLP level (white, green, amber or red) of the STIX bundles that don't choose one. It
+	// defaults to amber.
+	StixTlp string
 }
 
 // Conf will hold the VARS configuration.
@@ -98,7 +122,32 @@ func toNullString(s string) sql.NullString {
 	return sql.NullString{String: s, Valid: s != ""}
 }
 
+// ContainsString returns true if s is in the slice.
+func ContainsString(slice []string, s string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // ToVarsNullString creates a VarsNullString from a string.
 func ToVarsNullString(s string) VarsNullString {
 	return VarsNullString{toNullString(s)}
 }
+
+// UpgradeSolution returns the solution of a scan finding that lists the upgrades of its vulnerable packages.
+func UpgradeSolution(pkgs []*AffectedPkg) string {
+	var ups []string
+	for _, p := range pkgs {
+		up := p.Package + " to " + p.Fixed
+		if p.Fixed == "" {
+			up = p.Package + " (no fixed version yet)"
+		}
+		if !ContainsString(ups, up) {
+			ups = append(ups, up)
+		}
+	}
+	return "Upgrade " + strings.Join(ups, ", ") + "."
+}
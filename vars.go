@@ -32,69 +32,159 @@ type sqlStatement int
 const (
 	ssCheckVulnName sqlStatement = iota
 	ssCheckSysName
+	ssCheckTemplateName
 	ssDeleteAffected
+	ssDeleteAffectedPkgs
+	ssDeleteAttachment
+	ssDeleteCatalogCwes
+	ssDeleteCatalogRefs
 	ssDeleteCve
+	ssDeleteCwe
 	ssDeleteDates
 	ssDeleteExploit
 	ssDeleteImpact
+	ssDeleteKevMatches
+	ssDeleteLargeObject
+	ssDeleteMerges
 	ssDeleteNote
+	ssDeleteNoteMentions
+	ssDeleteNoteRevisions
 	ssDeleteRef
+	ssDeleteRelated
+	ssDeleteRelatedAll
+	ssDeleteRolePermissions
+	ssDeleteScanMitigation
+	ssDeleteScanRefs
 	ssDeleteSys
 	ssDeleteSysA
+	ssDeleteSysAddrs
+	ssDeleteSysAffectedPkgs
+	ssDeleteSysComponents
+	ssDeleteSysPorts
+	ssDeleteSysScanMitigations
+	ssDeleteVulnAffectedPkgs
+	ssDeleteVulnScanMitigations
+	ssDeleteTask
+	ssDeleteTasks
+	ssDeleteTemplate
+	ssDeleteTemplateRefs
 	ssDeleteTicket
 	ssDeleteVuln
 	ssGetAffected
+	ssGetAffectedMitigated
+	ssGetAffectedPkgs
+	ssGetAttachment
+	ssGetAttachments
+	ssGetAttachmentsBySys
+	ssGetCatalogCwes
+	ssGetCatalogEntry
+	ssGetCatalogRefs
 	ssGetClosedVulnIDs
 	ssGetCves
+	ssGetCwes
+	ssGetEpssByVuln
 	ssGetEmployee
 	ssGetEmps
 	ssGetEmpID
 	ssGetExploit
 	ssGetImpact
+	ssGetKevByVuln
+	ssGetKevMatches
+	ssGetKevUnmatched
+	ssGetLargeObject
+	ssGetMergedInto
+	ssGetNote
 	ssGetNoteEmp
+	ssGetNoteMentions
+	ssGetNoteRevisions
 	ssGetNotes
 	ssGetOpenVulnIDs
+	ssGetOpenVulnPkgs
 	ssGetReferences
+	ssGetRelated
+	ssGetRolePermissions
+	ssGetScanAffected
+	ssGetScanMitigation
+	ssGetScanRefVuln
+	ssGetSysAddrs
+	ssGetSysComponents
+	ssGetSysIDByAddr
+	ssGetSysPorts
+	ssGetSysVulnIDs
 	ssGetSystem
 	ssGetSystems
 	ssGetSystemsByState
 	ssGetSystemID
+	ssGetTask
+	ssGetTasks
+	ssGetTemplate
+	ssGetTemplateRefs
+	ssGetTemplates
 	ssGetTickets
 	ssGetVuln
 	ssGetVulns
 	ssGetVulnDates
 	ssGetVulnID
+	ssGetVulnIDsByCve
 	ssInsertAffected
+	ssInsertAffectedPkg
+	ssInsertAttachment
+	ssInsertCatalogCwe
+	ssInsertCatalogRef
 	ssInsertCve
+	ssInsertCwe
 	ssInsertDates
 	ssInsertEmployee
 	ssInsertExploit
 	ssInsertNote
+	ssInsertNoteMention
+	ssInsertNoteRevision
 	ssInsertImpact
+	ssInsertKevMatch
+	ssInsertLargeObject
+	ssInsertMerge
 	ssInsertRefers
+	ssInsertRelated
+	ssInsertRolePermission
+	ssInsertScanRef
+	ssInsertSysAddr
+	ssInsertSysComponent
+	ssInsertSysPort
 	ssInsertSystem
+	ssInsertTask
+	ssInsertTemplate
+	ssInsertTemplateRef
 	ssInsertTicket
 	ssInsertVuln
+	ssMergeCatalogEntry
 	ssUpdateAffected
+	ssUpdateAttachmentsVuln
 	ssUpdateCve
 	ssUpdateCvss
 	ssUpdateCvssLink
 	ssUpdateCorpScore
+	ssUpdateEmpActive
 	ssUpdateEmpEmail
 	ssUpdateEmpFname
-	ssUpdateEmpLevel
 	ssUpdateEmpLname
+	ssUpdateEmpRole
 	ssUpdateEmpUname
 	ssUpdateExploit
 	ssUpdateExploitable
 	ssUpdateFinder
 	ssUpdateInitiator
+	ssUpdateInitiatorOpen
 	ssUpdateInitDate
+	ssUpdateMerges
 	ssUpdateMitDate
 	ssUpdateMitigation
 	ssUpdateNote
+	ssUpdateNoteDeleted
+	ssUpdateNotePublic
+	ssUpdateNotesVuln
 	ssUpdatePubDate
 	ssUpdateRefers
+	ssUpdateScanRefsVuln
 	ssUpdateSummary
 	ssUpdateSysName
 	ssUpdateSysType
@@ -102,163 +192,366 @@ const (
 	ssUpdateSysLoc
 	ssUpdateSysDesc
 	ssUpdateSysState
+	ssUpdateTask
+	ssUpdateTasksSysNull
+	ssUpdateTasksVuln
+	ssUpdateTemplate
 	ssUpdateTest
 	ssUpdateTicket
 	ssUpdateVulnName
+	ssUpsertCatalogEntry
+	ssUpsertEpssScore
+	ssUpsertKevEntry
+	ssUpsertScanMitigation
 )
 
 // SQL queries to be used in program execution.
 var (
 	queries      map[sqlStatement]*sql.Stmt
 	queryStrings = map[sqlStatement]string{
-		ssCheckVulnName:     "SELECT vulnid FROM vuln WHERE vulnname=$1;",
-		ssCheckSysName:      "SELECT sysid FROM systems WHERE sysname=$1;",
-		ssDeleteAffected:    "DELETE FROM affected WHERE vulnid=$1 AND sysid=$2;",
-		ssDeleteCve:         "DELETE FROM cves WHERE vulnid=$1 AND cve=$2;",
-		ssDeleteDates:       "DELETE FROM dates WHERE vulnid=$1;",
-		ssDeleteExploit:     "DELETE FROM exploits WHERE vulnid=$1;",
-		ssDeleteImpact:      "DELETE FROM impact WHERE vulnid=$1;",
-		ssDeleteNote:        "DELETE FROM notes WHERE noteid=$1;",
-		ssDeleteRef:         "DELETE FROM ref WHERE vulnid=$1 AND url=$2;",
-		ssDeleteSys:         "DELETE FROM systems WHERE sysid=$1;",
-		ssDeleteSysA:        "DELETE FROM affected WHERE sysid=$1;",
-		ssDeleteTicket:      "DELETE FROM tickets WHERE vulnid=$1 AND ticket=$2;",
-		ssDeleteVuln:        "DELETE FROM vuln WHERE vulnid=$1;",
-		ssGetAffected:       "SELECT sysid, mitigated FROM affected WHERE vulnid=$1;",
-		ssGetClosedVulnIDs:  "SELECT vulnid FROM dates WHERE mitigated IS NOT NULL;",
-		ssGetCves:           "SELECT cve FROM cves WHERE vulnid=$1;",
-		ssGetEmployee:       "SELECT firstname, lastname, email, username, level FROM emp WHERE empid=$1;",
-		ssGetEmpID:          "SELECT empid FROM emp WHERE username=$1;",
-		ssGetEmps:           "SELECT empid, firstname, lastname, email, username, level FROM emp;",
-		ssGetExploit:        "SELECT exploitable, exploit FROM exploits WHERE vulnid=$1;",
-		ssGetImpact:         "SELECT cvss, cvsslink, corpscore FROM impact WHERE vulnid=$1;",
-		ssGetNoteEmp:        "SELECT empid FROM notes WHERE noteid=$1;",
-		ssGetNotes:          "SELECT noteid, empid, added, note FROM notes WHERE vulnid=$1 ORDER BY added ASC;",
-		ssGetOpenVulnIDs:    "SELECT vulnid FROM dates WHERE mitigated IS NULL;",
-		ssGetReferences:     "SELECT url FROM ref WHERE vulnid=$1;",
-		ssGetSystem:         "SELECT sysname, systype, opsys, location, description, state FROM systems WHERE sysid=$1;",
-		ssGetSystems:        "SELECT sysid, sysname, systype, opsys, location, description, state FROM systems;",
-		ssGetSystemsByState: "SELECT sysid, sysname, systype, opsys, location, description, state FROM systems WHERE state=$1;",
-		ssGetSystemID:       "SELECT sysid FROM systems WHERE sysname=$1;",
-		ssGetTickets:        "SELECT ticket FROM tickets WHERE vulnid=$1;",
-		ssGetVuln:           "SELECT vulnname, finder, initiator, summary, test, mitigation FROM vuln WHERE vulnid=$1;",
-		ssGetVulns:          "SELECT vulnid, vulnname, finder, initiator, summary, test, mitigation FROM vuln ORDER BY vulnid;",
-		ssGetVulnDates:      "SELECT published, initiated, mitigated FROM dates WHERE vulnid=$1;",
-		ssGetVulnID:         "SELECT vulnid FROM vuln WHERE vulnname=$1;",
-		ssInsertAffected:    "INSERT INTO affected (vulnid, sysid, mitigated) VALUES ($1, $2, $3);",
-		ssInsertCve:         "INSERT INTO cves (vulnid, cve) VALUES ($1, $2);",
-		ssInsertDates:       "INSERT INTO dates (vulnid, published, initiated, mitigated) VALUES ($1, $2, $3, $4);",
-		ssInsertEmployee:    "INSERT INTO emp (firstname, lastname, email, username, level) VALUES ($1, $2, $3, $4, $5);",
-		ssInsertExploit:     "INSERT INTO exploits (vulnid, exploitable, exploit) VALUES ($1, $2, $3);",
-		ssInsertNote:        "INSERT INTO notes (vulnid, empid, added, note) VALUES ($1, $2, $3, $4);",
-		ssInsertImpact:      "INSERT INTO impact (vulnid, cvss, cvsslink, corpscore) VALUES ($1, $2, $3, $4);",
-		ssInsertRefers:      "INSERT INTO ref (vulnid, url) VALUES ($1, $2);",
-		ssInsertSystem:      "INSERT INTO systems (sysname, systype, opsys, location, description, state) VALUES ($1, $2, $3, $4, $5, $6);",
-		ssInsertTicket:      "INSERT INTO tickets (vulnid, ticket) VALUES ($1, $2);",
-		ssInsertVuln:        "INSERT INTO vuln (vulnname, finder, initiator, summary, test, mitigation) VALUES ($1, $2, $3, $4, $5, $6) RETURNING vulnid;",
-		ssUpdateAffected:    "UPDATE affected SET mitigated=$1 WHERE vulnid=$2 AND sysid=$3;",
-		ssUpdateCve:         "UPDATE cves SET cve=$1 WHERE vulnid=$2 AND cve=$3;",
-		ssUpdateCvss:        "UPDATE impact SET cvss=$1 WHERE vulnid=$2;",
-		ssUpdateCvssLink:    "UPDATE impact SET cvsslink=$1 WHERE vulnid=$2;",
-		ssUpdateCorpScore:   "UPDATE impact SET corpscore=$1 WHERE vulnid=$2;",
-		ssUpdateEmpEmail:    "UPDATE emp SET email=$1 WHERE empid=$2;",
-		ssUpdateEmpFname:    "UPDATE emp SET firstname=$1 WHERE empid=$2;",
-		ssUpdateEmpLevel:    "UPDATE emp SET level=$1 WHERE empid=$2;",
-		ssUpdateEmpLname:    "UPDATE emp SET lastname=$1 WHERE empid=$2;",
-		ssUpdateEmpUname:    "UPDATE emp SET username=$1 WHERE empid=$2;",
-		ssUpdateExploit:     "UPDATE exploits SET exploitable=$1, exploit=$2 WHERE vulnid=$3;",
-		ssUpdateExploitable: "UPDATE exploits SET exploitable=$1 WHERE vulnid=$2;",
-		ssUpdateFinder:      "UPDATE vuln SET finder=$1 WHERE vulnid=$2;",
-		ssUpdateInitiator:   "UPDATE vuln SET initiator=$1 WHERE vulnid=$2;",
-		ssUpdateInitDate:    "UPDATE dates SET initiated=$1 WHERE vulnid=$2;",
-		ssUpdateMitDate:     "UPDATE dates SET mitigated=$1 WHERE vulnid=$2;",
-		ssUpdateMitigation:  "UPDATE vuln SET mitigation=$1 WHERE vulnid=$2;",
-		ssUpdateNote:        "UPDATE notes SET note=$1 WHERE noteid=$2;",
-		ssUpdatePubDate:     "UPDATE dates SET published=$1 WHERE vulnid=$2;",
-		ssUpdateRefers:      "UPDATE ref SET url=$1 WHERE vulnid=$2 AND url=$3;",
-		ssUpdateSummary:     "UPDATE vuln SET summary=$1 WHERE vulnid=$2;",
-		ssUpdateSysName:     "UPDATE systems SET sysname=$1 WHERE sysid=$2;",
-		ssUpdateSysType:     "UPDATE systems SET systype=$1 WHERE sysid=$2;",
-		ssUpdateSysOS:       "UPDATE systems SET opsys=$1 WHERE sysid=$2;",
-		ssUpdateSysLoc:      "UPDATE systems SET location=$1 WHERE sysid=$2;",
-		ssUpdateSysDesc:     "UPDATE systems SET description=$1 WHERE sysid=$2;",
-		ssUpdateSysState:    "UPDATE systems SET state=$1 WHERE sysid=$2;",
-		ssUpdateTest:        "UPDATE vuln SET test=$1 WHERE vulnid=$2;",
-		ssUpdateTicket:      "UPDATE tickets SET ticket=$1 WHERE vulnid=$2 AND ticket=$3;",
-		ssUpdateVulnName:    "UPDATE vuln SET vulnname=$1 WHERE vulnid=$2;",
+		ssCheckVulnName:             "SELECT vulnid FROM vuln WHERE vulnname=$1;",
+		ssCheckSysName:              "SELECT sysid FROM systems WHERE sysname=$1;",
+		ssCheckTemplateName:         "SELECT templateid FROM templates WHERE name=$1;",
+		ssDeleteAffected:            "DELETE FROM affected WHERE vulnid=$1 AND sysid=$2;",
+		ssDeleteAffectedPkgs:        "DELETE FROM affectedpkgs WHERE vulnid=$1 AND sysid=$2;",
+		ssDeleteAttachment:          "DELETE FROM attachments WHERE attachid=$1;",
+		ssDeleteCatalogCwes:         "DELETE FROM cve_catalog_cwes WHERE cve=$1;",
+		ssDeleteCatalogRefs:         "DELETE FROM cve_catalog_refs WHERE cve=$1;",
+		ssDeleteCve:                 "DELETE FROM cves WHERE vulnid=$1 AND cve=$2;",
+		ssDeleteCwe:                 "DELETE FROM cwes WHERE vulnid=$1 AND cwe=$2;",
+		ssDeleteDates:               "DELETE FROM dates WHERE vulnid=$1;",
+		ssDeleteExploit:             "DELETE FROM exploits WHERE vulnid=$1;",
+		ssDeleteImpact:              "DELETE FROM impact WHERE vulnid=$1;",
+		ssDeleteKevMatches:          "DELETE FROM kevmatches WHERE vulnid=$1;",
+		ssDeleteLargeObject:         "SELECT lo_unlink($1::oid);",
+		ssDeleteMerges:              "DELETE FROM merges WHERE keepid=$1;",
+		ssDeleteNote:                "DELETE FROM notes WHERE noteid=$1;",
+		ssDeleteNoteMentions:        "DELETE FROM notementions WHERE noteid=$1;",
+		ssDeleteNoteRevisions:       "DELETE FROM noterevisions WHERE noteid=$1;",
+		ssDeleteRef:                 "DELETE FROM ref WHERE vulnid=$1 AND url=$2;",
+		ssDeleteRelated:             "DELETE FROM related WHERE (vulnid=$1 AND relvulnid=$2) OR (vulnid=$2 AND relvulnid=$1);",
+		ssDeleteRelatedAll:          "DELETE FROM related WHERE vulnid=$1 OR relvulnid=$1;",
+		ssDeleteRolePermissions:     "DELETE FROM rolepermissions WHERE role=$1;",
+		ssDeleteScanMitigation:      "DELETE FROM scanmitigations WHERE vulnid=$1 AND sysid=$2;",
+		ssDeleteScanRefs:            "DELETE FROM scanrefs WHERE vulnid=$1;",
+		ssDeleteSys:                 "DELETE FROM systems WHERE sysid=$1;",
+		ssDeleteSysA:                "DELETE FROM affected WHERE sysid=$1;",
+		ssDeleteSysAddrs:            "DELETE FROM sysaddrs WHERE sysid=$1;",
+		ssDeleteSysAffectedPkgs:     "DELETE FROM affectedpkgs WHERE sysid=$1;",
+		ssDeleteSysComponents:       "DELETE FROM syscomponents WHERE sysid=$1;",
+		ssDeleteSysPorts:            "DELETE FROM sysports WHERE sysid=$1;",
+		ssDeleteSysScanMitigations:  "DELETE FROM scanmitigations WHERE sysid=$1;",
+		ssDeleteVulnAffectedPkgs:    "DELETE FROM affectedpkgs WHERE vulnid=$1;",
+		ssDeleteVulnScanMitigations: "DELETE FROM scanmitigations WHERE vulnid=$1;",
+		ssDeleteTask:                "DELETE FROM tasks WHERE taskid=$1;",
+		ssDeleteTasks:               "DELETE FROM tasks WHERE vulnid=$1;",
+		ssDeleteTemplate:            "DELETE FROM templates WHERE templateid=$1;",
+		ssDeleteTemplateRefs:        "DELETE FROM templaterefs WHERE templateid=$1;",
+		ssDeleteTicket:              "DELETE FROM tickets WHERE vulnid=$1 AND ticket=$2;",
+		ssDeleteVuln:                "DELETE FROM vuln WHERE vulnid=$1;",
+		ssGetAffected:               "SELECT a.sysid, a.mitigated, s.scanner, s.scan, s.mitigated FROM affected a LEFT JOIN scanmitigations s ON s.vulnid=a.vulnid AND s.sysid=a.sysid WHERE a.vulnid=$1;",
+		ssGetAffectedMitigated:      "SELECT mitigated FROM affected WHERE vulnid=$1 AND sysid=$2;",
+		ssGetAffectedPkgs:           "SELECT package, installed, fixed FROM affectedpkgs WHERE vulnid=$1 AND sysid=$2 ORDER BY package, installed;",
+		ssGetAttachment:             "SELECT vulnid, sysid, empid, filename, mimetype, size, sha256, storekey, added FROM attachments WHERE attachid=$1;",
+		ssGetAttachments:            "SELECT attachid, vulnid, sysid, empid, filename, mimetype, size, sha256, storekey, added FROM attachments WHERE vulnid=$1 ORDER BY added ASC;",
+		ssGetAttachmentsBySys:       "SELECT attachid, vulnid, sysid, empid, filename, mimetype, size, sha256, storekey, added FROM attachments WHERE sysid=$1 ORDER BY added ASC;",
+		ssGetCatalogCwes:            "SELECT cwe FROM cve_catalog_cwes WHERE cve=$1 ORDER BY cwe;",
+		ssGetCatalogEntry:           "SELECT cve, summary, published, lastmodified, cvssvector, cvssscore FROM cve_catalog WHERE cve=upper($1);",
+		ssGetCatalogRefs:            "SELECT url FROM cve_catalog_refs WHERE cve=$1 ORDER BY url;",
+		ssGetClosedVulnIDs:          "SELECT vulnid FROM dates WHERE mitigated IS NOT NULL;",
+		ssGetCves:                   "SELECT cve FROM cves WHERE vulnid=$1;",
+		ssGetCwes:                   "SELECT cwe FROM cwes WHERE vulnid=$1 ORDER BY cwe;",
+		ssGetEpssByVuln:             "SELECT e.cve, e.score, e.percentile, e.modeldate, e.modelversion FROM epss e JOIN cves c ON e.cve=upper(c.cve) WHERE c.vulnid=$1 ORDER BY e.score DESC, e.cve LIMIT 1;",
+		ssGetEmployee:               "SELECT firstname, lastname, email, username, role, active, deactivated FROM emp WHERE empid=$1;",
+		ssGetEmpID:                  "SELECT empid FROM emp WHERE username=$1 ORDER BY active DESC, empid DESC LIMIT 1;",
+		ssGetEmps:                   "SELECT empid, firstname, lastname, email, username, role, active, deactivated FROM emp;",
+		ssGetExploit:                "SELECT exploitable, exploit FROM exploits WHERE vulnid=$1;",
+		ssGetImpact:                 "SELECT cvss, cvsslink, corpscore FROM impact WHERE vulnid=$1;",
+		ssGetKevByVuln:              "SELECT k.cve, k.vendor, k.product, k.name, k.action, k.dateadded, k.duedate, k.ransomware FROM kev k JOIN cves c ON k.cve=upper(c.cve) WHERE c.vulnid=$1 ORDER BY k.dateadded, k.cve;",
+		ssGetKevMatches:             "SELECT m.vulnid, v.vulnname, m.cve, m.matched FROM kevmatches m JOIN vuln v ON v.vulnid=m.vulnid WHERE m.matched >= $1 ORDER BY m.matched DESC, v.vulnname;",
+		ssGetKevUnmatched:           "SELECT DISTINCT c.vulnid, k.cve FROM cves c JOIN kev k ON k.cve=upper(c.cve) WHERE NOT EXISTS (SELECT 1 FROM kevmatches m WHERE m.vulnid=c.vulnid AND m.cve=k.cve) ORDER BY c.vulnid, k.cve;",
+		ssGetLargeObject:            "SELECT lo_get($1::oid);",
+		ssGetMergedInto:             "SELECT keepid FROM merges WHERE dropid=$1;",
+		ssGetNote:                   "SELECT vulnid, empid, added, note, parent, deleted, public FROM notes WHERE noteid=$1;",
+		ssGetNoteEmp:                "SELECT empid FROM notes WHERE noteid=$1;",
+		ssGetNoteMentions:           "SELECT empid FROM notementions WHERE noteid=$1;",
+		ssGetNoteRevisions:          "SELECT revised, note FROM noterevisions WHERE noteid=$1 ORDER BY revised ASC;",
+		ssGetNotes:                  "SELECT noteid, empid, added, note, parent, deleted, public FROM notes WHERE vulnid=$1 ORDER BY added ASC;",
+		ssGetOpenVulnIDs:            "SELECT vulnid FROM dates WHERE mitigated IS NULL;",
+		ssGetOpenVulnPkgs:           "SELECT DISTINCT p.vulnid, v.vulnname, p.package, p.installed, p.fixed FROM affectedpkgs p JOIN vuln v ON v.vulnid=p.vulnid JOIN dates d ON d.vulnid=p.vulnid WHERE d.mitigated IS NULL ORDER BY p.vulnid, p.package, p.installed;",
+		ssGetReferences:             "SELECT url FROM ref WHERE vulnid=$1;",
+		ssGetRelated:                "SELECT r.relvulnid, v.vulnname, r.reltype, false FROM related r JOIN vuln v ON v.vulnid=r.relvulnid WHERE r.vulnid=$1 UNION ALL SELECT r.vulnid, v.vulnname, r.reltype, true FROM related r JOIN vuln v ON v.vulnid=r.vulnid WHERE r.relvulnid=$1 ORDER BY 1;",
+		ssGetRolePermissions:        "SELECT role, permission FROM rolepermissions ORDER BY role;",
+		ssGetScanAffected:           "SELECT DISTINCT a.vulnid, v.vulnname FROM affected a JOIN scanrefs r ON r.vulnid=a.vulnid AND r.scanner=$2 JOIN vuln v ON v.vulnid=a.vulnid JOIN dates d ON d.vulnid=a.vulnid WHERE a.sysid=$1 AND NOT a.mitigated AND d.mitigated IS NULL ORDER BY a.vulnid;",
+		ssGetScanMitigation:         "SELECT scanner, scan, mitigated FROM scanmitigations WHERE vulnid=$1 AND sysid=$2;",
+		ssGetScanRefVuln:            "SELECT vulnid FROM scanrefs WHERE scanner=$1 AND pluginid=$2;",
+		ssGetSysAddrs:               "SELECT addr FROM sysaddrs WHERE sysid=$1 ORDER BY addr;",
+		ssGetSysComponents:          "SELECT name, version, purl FROM syscomponents WHERE sysid=$1 ORDER BY name, version;",
+		ssGetSysIDByAddr:            "SELECT sysid FROM sysaddrs WHERE addr=lower($1);",
+		ssGetSysPorts:               "SELECT port, protocol, service FROM sysports WHERE sysid=$1 ORDER BY protocol, port;",
+		ssGetSysVulnIDs:             "SELECT vulnid FROM affected WHERE sysid=$1 ORDER BY vulnid;",
+		ssGetSystem:                 "SELECT sysname, systype, opsys, location, description, state FROM systems WHERE sysid=$1;",
+		ssGetSystems:                "SELECT sysid, sysname, systype, opsys, location, description, state FROM systems;",
+		ssGetSystemsByState:         "SELECT sysid, sysname, systype, opsys, location, description, state FROM systems WHERE state=$1;",
+		ssGetSystemID:               "SELECT sysid FROM systems WHERE sysname=$1;",
+		ssGetTask:                   "SELECT taskid, vulnid, title, assignee, due, done, sysid FROM tasks WHERE taskid=$1;",
+		ssGetTasks:                  "SELECT taskid, vulnid, title, assignee, due, done, sysid FROM tasks WHERE vulnid=$1 ORDER BY taskid;",
+		ssGetTemplate:               "SELECT name, summary, test, mitigation, cvss, cvsslink, corpscore FROM templates WHERE templateid=$1;",
+		ssGetTemplateRefs:           "SELECT url FROM templaterefs WHERE templateid=$1;",
+		ssGetTemplates:              "SELECT templateid, name, summary, test, mitigation, cvss, cvsslink, corpscore FROM templates ORDER BY name;",
+		ssGetTickets:                "SELECT ticket FROM tickets WHERE vulnid=$1;",
+		ssGetVuln:                   "SELECT vulnname, finder, initiator, summary, test, mitigation FROM vuln WHERE vulnid=$1;",
+		ssGetVulns:                  "SELECT vulnid, vulnname, finder, initiator, summary, test, mitigation FROM vuln ORDER BY vulnid;",
+		ssGetVulnDates:              "SELECT published, initiated, mitigated FROM dates WHERE vulnid=$1;",
+		ssGetVulnID:                 "SELECT vulnid FROM vuln WHERE vulnname=$1;",
+		ssGetVulnIDsByCve:           "SELECT vulnid FROM cves WHERE upper(cve)=upper($1) ORDER BY vulnid;",
+		ssInsertAffected:            "INSERT INTO affected (vulnid, sysid, mitigated) VALUES ($1, $2, $3);",
+		ssInsertAffectedPkg:         "INSERT INTO affectedpkgs (vulnid, sysid, package, installed, fixed) VALUES ($1, $2, $3, $4, $5);",
+		ssInsertAttachment:          "INSERT INTO attachments (vulnid, sysid, empid, filename, mimetype, size, sha256, storekey, added) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING attachid;",
+		ssInsertCatalogCwe:          "INSERT INTO cve_catalog_cwes (cve, cwe) VALUES ($1, $2) ON CONFLICT DO NOTHING;",
+		ssInsertCatalogRef:          "INSERT INTO cve_catalog_refs (cve, url) VALUES ($1, $2) ON CONFLICT DO NOTHING;",
+		ssInsertCve:                 "INSERT INTO cves (vulnid, cve) VALUES ($1, $2);",
+		ssInsertCwe:                 "INSERT INTO cwes (vulnid, cwe) VALUES ($1, $2);",
+		ssInsertDates:               "INSERT INTO dates (vulnid, published, initiated, mitigated) VALUES ($1, $2, $3, $4);",
+		ssInsertEmployee:            "INSERT INTO emp (firstname, lastname, email, username, role) VALUES ($1, $2, $3, $4, $5);",
+		ssInsertExploit:             "INSERT INTO exploits (vulnid, exploitable, exploit) VALUES ($1, $2, $3);",
+		ssInsertNote:                "INSERT INTO notes (vulnid, empid, added, note, parent) VALUES ($1, $2, $3, $4, $5) RETURNING noteid;",
+		ssInsertNoteMention:         "INSERT INTO notementions (noteid, empid) VALUES ($1, $2);",
+		ssInsertNoteRevision:        "INSERT INTO noterevisions (noteid, revised, note) SELECT noteid, $2, note FROM notes WHERE noteid=$1;",
+		ssInsertImpact:              "INSERT INTO impact (vulnid, cvss, cvsslink, corpscore) VALUES ($1, $2, $3, $4);",
+		ssInsertKevMatch:            "INSERT INTO kevmatches (vulnid, cve, matched) VALUES ($1, $2, $3);",
+		ssInsertLargeObject:         "SELECT lo_from_bytea(0, $1);",
+		ssInsertMerge:               "INSERT INTO merges (dropid, dropname, keepid, merged) VALUES ($1, $2, $3, $4);",
+		ssInsertRefers:              "INSERT INTO ref (vulnid, url) VALUES ($1, $2);",
+		ssInsertRelated:             "INSERT INTO related (vulnid, relvulnid, reltype) VALUES ($1, $2, $3);",
+		ssInsertRolePermission:      "INSERT INTO rolepermissions (role, permission) VALUES ($1, $2);",
+		ssInsertScanRef:             "INSERT INTO scanrefs (scanner, pluginid, vulnid) VALUES ($1, $2, $3);",
+		ssInsertSysAddr:             "INSERT INTO sysaddrs (sysid, addr) VALUES ($1, lower($2));",
+		ssInsertSysComponent:        "INSERT INTO syscomponents (sysid, name, version, purl) VALUES ($1, $2, $3, $4);",
+		ssInsertSysPort:             "INSERT INTO sysports (sysid, port, protocol, service) VALUES ($1, $2, $3, $4);",
+		ssInsertSystem:              "INSERT INTO systems (sysname, systype, opsys, location, description, state) VALUES ($1, $2, $3, $4, $5, $6);",
+		ssInsertTask:                "INSERT INTO tasks (vulnid, title, assignee, due, done, sysid) VALUES ($1, $2, $3, $4, $5, $6) RETURNING taskid;",
+		ssInsertTemplate:            "INSERT INTO templates (name, summary, test, mitigation, cvss, cvsslink, corpscore) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING templateid;",
+		ssInsertTemplateRef:         "INSERT INTO templaterefs (templateid, url) VALUES ($1, $2);",
+		ssInsertTicket:              "INSERT INTO tickets (vulnid, ticket) VALUES ($1, $2);",
+		ssInsertVuln:                "INSERT INTO vuln (vulnname, finder, initiator, summary, test, mitigation) VALUES ($1, $2, $3, $4, $5, $6) RETURNING vulnid;",
+		ssMergeCatalogEntry:         "INSERT INTO cve_catalog (cve, summary, published, lastmodified, cvssvector, cvssscore) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (cve) DO UPDATE SET summary=CASE WHEN cve_catalog.summary='' THEN EXCLUDED.summary ELSE cve_catalog.summary END, published=COALESCE(cve_catalog.published, EXCLUDED.published), cvssvector=COALESCE(cve_catalog.cvssvector, EXCLUDED.cvssvector), cvssscore=CASE WHEN cve_catalog.cvssvector IS NULL THEN EXCLUDED.cvssscore ELSE cve_catalog.cvssscore END WHERE (cve_catalog.summary='' AND EXCLUDED.summary<>'') OR (cve_catalog.published IS NULL AND EXCLUDED.published IS NOT NULL) OR (cve_catalog.cvssvector IS NULL AND EXCLUDED.cvssvector IS NOT NULL);",
+		ssUpdateAffected:            "UPDATE affected SET mitigated=$1 WHERE vulnid=$2 AND sysid=$3;",
+		ssUpdateAttachmentsVuln:     "UPDATE attachments SET vulnid=$1 WHERE vulnid=$2;",
+		ssUpdateCve:                 "UPDATE cves SET cve=$1 WHERE vulnid=$2 AND cve=$3;",
+		ssUpdateCvss:                "UPDATE impact SET cvss=$1 WHERE vulnid=$2;",
+		ssUpdateCvssLink:            "UPDATE impact SET cvsslink=$1 WHERE vulnid=$2;",
+		ssUpdateCorpScore:           "UPDATE impact SET corpscore=$1 WHERE vulnid=$2;",
+		ssUpdateEmpActive:           "UPDATE emp SET active=$1, deactivated=$2 WHERE empid=$3;",
+		ssUpdateEmpEmail:            "UPDATE emp SET email=$1 WHERE empid=$2;",
+		ssUpdateEmpFname:            "UPDATE emp SET firstname=$1 WHERE empid=$2;",
+		ssUpdateEmpLname:            "UPDATE emp SET lastname=$1 WHERE empid=$2;",
+		ssUpdateEmpRole:             "UPDATE emp SET role=$1 WHERE empid=$2;",
+		ssUpdateEmpUname:            "UPDATE emp SET username=$1 WHERE empid=$2;",
+		ssUpdateExploit:             "UPDATE exploits SET exploitable=$1, exploit=$2 WHERE vulnid=$3;",
+		ssUpdateExploitable:         "UPDATE exploits SET exploitable=$1 WHERE vulnid=$2;",
+		ssUpdateFinder:              "UPDATE vuln SET finder=$1 WHERE vulnid=$2;",
+		ssUpdateInitiator:           "UPDATE vuln SET initiator=$1 WHERE vulnid=$2;",
+		ssUpdateInitiatorOpen:       "UPDATE vuln SET initiator=$1 WHERE initiator=$2 AND vulnid IN (SELECT vulnid FROM dates WHERE mitigated IS NULL);",
+		ssUpdateInitDate:            "UPDATE dates SET initiated=$1 WHERE vulnid=$2;",
+		ssUpdateMerges:              "UPDATE merges SET keepid=$1 WHERE keepid=$2;",
+		ssUpdateMitDate:             "UPDATE dates SET mitigated=$1 WHERE vulnid=$2;",
+		ssUpdateMitigation:          "UPDATE vuln SET mitigation=$1 WHERE vulnid=$2;",
+		ssUpdateNote:                "UPDATE notes SET note=$1 WHERE noteid=$2;",
+		ssUpdateNoteDeleted:         "UPDATE notes SET deleted=$1 WHERE noteid=$2;",
+		ssUpdateNotePublic:          "UPDATE notes SET public=$1 WHERE noteid=$2;",
+		ssUpdateNotesVuln:           "UPDATE notes SET vulnid=$1 WHERE vulnid=$2;",
+		ssUpdatePubDate:             "UPDATE dates SET published=$1 WHERE vulnid=$2;",
+		ssUpdateRefers:              "UPDATE ref SET url=$1 WHERE vulnid=$2 AND url=$3;",
+		ssUpdateScanRefsVuln:        "UPDATE scanrefs SET vulnid=$1 WHERE vulnid=$2;",
+		ssUpdateSummary:             "UPDATE vuln SET summary=$1 WHERE vulnid=$2;",
+		ssUpdateSysName:             "UPDATE systems SET sysname=$1 WHERE sysid=$2;",
+		ssUpdateSysType:             "UPDATE systems SET systype=$1 WHERE sysid=$2;",
+		ssUpdateSysOS:               "UPDATE systems SET opsys=$1 WHERE sysid=$2;",
+		ssUpdateSysLoc:              "UPDATE systems SET location=$1 WHERE sysid=$2;",
+		ssUpdateSysDesc:             "UPDATE systems SET description=$1 WHERE sysid=$2;",
+		ssUpdateSysState:            "UPDATE systems SET state=$1 WHERE sysid=$2;",
+		ssUpdateTask:                "UPDATE tasks SET title=$1, assignee=$2, due=$3, done=$4, sysid=$5 WHERE taskid=$6;",
+		ssUpdateTasksSysNull:        "UPDATE tasks SET sysid=NULL WHERE sysid=$1;",
+		ssUpdateTasksVuln:           "UPDATE tasks SET vulnid=$1 WHERE vulnid=$2;",
+		ssUpdateTemplate:            "UPDATE templates SET name=$1, summary=$2, test=$3, mitigation=$4, cvss=$5, cvsslink=$6, corpscore=$7 WHERE templateid=$8;",
+		ssUpdateTest:                "UPDATE vuln SET test=$1 WHERE vulnid=$2;",
+		ssUpdateTicket:              "UPDATE tickets SET ticket=$1 WHERE vulnid=$2 AND ticket=$3;",
+		ssUpdateVulnName:            "UPDATE vuln SET vulnname=$1 WHERE vulnid=$2;",
+		ssUpsertCatalogEntry:        "INSERT INTO cve_catalog (cve, summary, published, lastmodified, cvssvector, cvssscore) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (cve) DO UPDATE SET summary=EXCLUDED.summary, published=EXCLUDED.published, lastmodified=EXCLUDED.lastmodified, cvssvector=EXCLUDED.cvssvector, cvssscore=EXCLUDED.cvssscore WHERE cve_catalog.lastmodified < EXCLUDED.lastmodified;",
+		ssUpsertEpssScore:           "INSERT INTO epss (cve, score, percentile, modeldate, modelversion) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (cve) DO UPDATE SET score=EXCLUDED.score, percentile=EXCLUDED.percentile, modeldate=EXCLUDED.modeldate, modelversion=EXCLUDED.modelversion WHERE epss.modeldate < EXCLUDED.modeldate;",
+		ssUpsertKevEntry:            "INSERT INTO kev (cve, vendor, product, name, action, dateadded, duedate, ransomware) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (cve) DO UPDATE SET vendor=EXCLUDED.vendor, product=EXCLUDED.product, name=EXCLUDED.name, action=EXCLUDED.action, dateadded=EXCLUDED.dateadded, duedate=EXCLUDED.duedate, ransomware=EXCLUDED.ransomware;",
+		ssUpsertScanMitigation:      "INSERT INTO scanmitigations (vulnid, sysid, scanner, scan, mitigated) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (vulnid, sysid) DO UPDATE SET scanner=EXCLUDED.scanner, scan=EXCLUDED.scan, mitigated=EXCLUDED.mitigated;",
 	}
 	execNames = map[sqlStatement]string{
-		ssDeleteAffected:    "DeleteAffected",
-		ssDeleteCve:         "DeleteCve",
-		ssDeleteDates:       "DeleteDates",
-		ssDeleteExploit:     "DeleteExploit",
-		ssDeleteImpact:      "DeleteImpact",
-		ssDeleteNote:        "DeleteNote",
-		ssDeleteRef:         "DeleteRef",
-		ssDeleteSys:         "DeleteSystem",
-		ssDeleteSysA:        "DeleteSystemFromAffected",
-		ssDeleteTicket:      "DeleteTicket",
-		ssDeleteVuln:        "DeleteVulnerability",
-		ssGetAffected:       "GetAffected",
-		ssGetCves:           "GetCves",
-		ssGetEmployee:       "GetEmployee",
-		ssGetEmpID:          "GetEmpID",
-		ssGetEmps:           "GetEmployees",
-		ssGetExploit:        "GetExploit",
-		ssGetClosedVulnIDs:  "GetClosedVulnIDs",
-		ssGetImpact:         "GetImpact",
-		ssGetNoteEmp:        "GetNoteAuthor",
-		ssGetNotes:          "GetNotes",
-		ssGetOpenVulnIDs:    "GetOpenVulnIDs",
-		ssGetReferences:     "GetReferences",
-		ssGetSystem:         "GetSystem",
-		ssGetSystems:        "GetSystems",
-		ssGetSystemsByState: "GetSystemsByState",
-		ssGetSystemID:       "GetSystemID",
-		ssGetTickets:        "GetTickets",
-		ssGetVuln:           "GetVulnerability",
-		ssGetVulns:          "GetVulnerabilities",
-		ssGetVulnID:         "GetVulnID",
-		ssInsertAffected:    "InsertAffected",
-		ssInsertCve:         "InsertCve",
-		ssInsertDates:       "InsertDates",
-		ssInsertEmployee:    "InsertEmployee",
-		ssInsertExploit:     "InsertExploit",
-		ssInsertImpact:      "InsertImpact",
-		ssInsertNote:        "InsertNote",
-		ssInsertRefers:      "InsertRef",
-		ssInsertTicket:      "InsertTicket",
-		ssInsertVuln:        "InsertVulnerability",
-		ssUpdateAffected:    "UpdateAffected",
-		ssUpdateCve:         "UpdateCve",
-		ssUpdateCvss:        "UpdateCvss",
-		ssUpdateCvssLink:    "UpdateCvssLink",
-		ssUpdateCorpScore:   "UpdateCorpScore",
-		ssUpdateEmpEmail:    "UpdateEmpEmail",
-		ssUpdateEmpFname:    "UpdateEmpFname",
-		ssUpdateEmpLevel:    "UpdateEmpLevel",
-		ssUpdateEmpLname:    "UpdateEmpLname",
-		ssUpdateEmpUname:    "UpdateEmpUname",
-		ssUpdateExploit:     "UpdateExploit",
-		ssUpdateExploitable: "UpdateExploitable",
-		ssUpdateFinder:      "UpdateFinder",
-		ssUpdateInitiator:   "UpdateInitiator",
-		ssUpdateInitDate:    "UpdateInitDate",
-		ssUpdateMitDate:     "UpdateMitDate",
-		ssUpdateMitigation:  "UpdateMitigation",
-		ssUpdateNote:        "UpdateNote",
-		ssUpdatePubDate:     "UpdatePubDate",
-		ssUpdateRefers:      "UpdateRefers",
-		ssUpdateSummary:     "UpdateSummary",
-		ssUpdateSysName:     "UpdateSysName",
-		ssUpdateSysType:     "UpdateSysType",
-		ssUpdateSysOS:       "UpdateSysOS",
-		ssUpdateSysLoc:      "UpdateSysLoc",
-		ssUpdateSysDesc:     "UpdateSysDesc",
-		ssUpdateSysState:    "UpdateSysState",
-		ssUpdateTest:        "UpdateTest",
-		ssUpdateTicket:      "UpdateTicket",
-		ssUpdateVulnName:    "UpdateVulnName",
+		ssDeleteAffected:            "DeleteAffected",
+		ssDeleteAffectedPkgs:        "DeleteAffectedPkgs",
+		ssDeleteAttachment:          "DeleteAttachment",
+		ssDeleteCatalogCwes:         "DeleteCatalogCwes",
+		ssDeleteCatalogRefs:         "DeleteCatalogRefs",
+		ssDeleteCve:                 "DeleteCve",
+		ssDeleteCwe:                 "DeleteCwe",
+		ssDeleteDates:               "DeleteDates",
+		ssDeleteExploit:             "DeleteExploit",
+		ssDeleteImpact:              "DeleteImpact",
+		ssDeleteKevMatches:          "DeleteKevMatches",
+		ssDeleteLargeObject:         "DeleteLargeObject",
+		ssDeleteMerges:              "DeleteMerges",
+		ssDeleteNote:                "DeleteNote",
+		ssDeleteNoteMentions:        "DeleteNoteMentions",
+		ssDeleteNoteRevisions:       "DeleteNoteRevisions",
+		ssDeleteRef:                 "DeleteRef",
+		ssDeleteRelated:             "DeleteRelated",
+		ssDeleteRelatedAll:          "DeleteRelatedAll",
+		ssDeleteRolePermissions:     "DeleteRolePermissions",
+		ssDeleteScanMitigation:      "DeleteScanMitigation",
+		ssDeleteScanRefs:            "DeleteScanRefs",
+		ssDeleteSys:                 "DeleteSystem",
+		ssDeleteSysA:                "DeleteSystemFromAffected",
+		ssDeleteSysAddrs:            "DeleteSysAddrs",
+		ssDeleteSysAffectedPkgs:     "DeleteSysAffectedPkgs",
+		ssDeleteSysComponents:       "DeleteSysComponents",
+		ssDeleteSysPorts:            "DeleteSysPorts",
+		ssDeleteSysScanMitigations:  "DeleteSysScanMitigations",
+		ssDeleteVulnAffectedPkgs:    "DeleteVulnAffectedPkgs",
+		ssDeleteVulnScanMitigations: "DeleteVulnScanMitigations",
+		ssDeleteTask:                "DeleteTask",
+		ssDeleteTasks:               "DeleteTasks",
+		ssDeleteTemplate:            "DeleteTemplate",
+		ssDeleteTemplateRefs:        "DeleteTemplateRefs",
+		ssDeleteTicket:              "DeleteTicket",
+		ssDeleteVuln:                "DeleteVulnerability",
+		ssGetAffected:               "GetAffected",
+		ssGetAffectedMitigated:      "GetAffectedMitigated",
+		ssGetAffectedPkgs:           "GetAffectedPkgs",
+		ssGetAttachment:             "GetAttachment",
+		ssGetAttachments:            "GetAttachments",
+		ssGetAttachmentsBySys:       "GetAttachmentsBySystem",
+		ssGetCatalogCwes:            "GetCatalogCwes",
+		ssGetCatalogEntry:           "GetCatalogEntry",
+		ssGetCatalogRefs:            "GetCatalogRefs",
+		ssGetCves:                   "GetCves",
+		ssGetCwes:                   "GetCwes",
+		ssGetEpssByVuln:             "GetEpssByVuln",
+		ssGetEmployee:               "GetEmployee",
+		ssGetEmpID:                  "GetEmpID",
+		ssGetEmps:                   "GetEmployees",
+		ssGetExploit:                "GetExploit",
+		ssGetClosedVulnIDs:          "GetClosedVulnIDs",
+		ssGetImpact:                 "GetImpact",
+		ssGetKevByVuln:              "GetKevByVuln",
+		ssGetKevMatches:             "GetKevMatches",
+		ssGetKevUnmatched:           "GetKevUnmatched",
+		ssGetLargeObject:            "GetLargeObject",
+		ssGetMergedInto:             "GetMergedInto",
+		ssGetNote:                   "GetNote",
+		ssGetNoteEmp:                "GetNoteAuthor",
+		ssGetNoteMentions:           "GetNoteMentions",
+		ssGetNoteRevisions:          "GetNoteRevisions",
+		ssGetNotes:                  "GetNotes",
+		ssGetOpenVulnIDs:            "GetOpenVulnIDs",
+		ssGetOpenVulnPkgs:           "GetOpenVulnPkgs",
+		ssGetReferences:             "GetReferences",
+		ssGetRelated:                "GetRelated",
+		ssGetRolePermissions:        "GetRolePermissions",
+		ssGetScanAffected:           "GetScanAffected",
+		ssGetScanMitigation:         "GetScanMitigation",
+		ssGetScanRefVuln:            "GetScanRefVuln",
+		ssGetSysAddrs:               "GetSysAddrs",
+		ssGetSysComponents:          "GetSysComponents",
+		ssGetSysIDByAddr:            "GetSysIDByAddr",
+		ssGetSysPorts:               "GetSysPorts",
+		ssGetSysVulnIDs:             "GetSysVulnIDs",
+		ssGetSystem:                 "GetSystem",
+		ssGetSystems:                "GetSystems",
+		ssGetSystemsByState:         "GetSystemsByState",
+		ssGetSystemID:               "GetSystemID",
+		ssGetTask:                   "GetTask",
+		ssGetTasks:                  "GetTasks",
+		ssGetTemplate:               "GetTemplate",
+		ssGetTemplateRefs:           "GetTemplateRefs",
+		ssGetTemplates:              "GetTemplates",
+		ssGetTickets:                "GetTickets",
+		ssGetVuln:                   "GetVulnerability",
+		ssGetVulns:                  "GetVulnerabilities",
+		ssGetVulnID:                 "GetVulnID",
+		ssGetVulnIDsByCve:           "GetVulnIDsByCve",
+		ssInsertAffected:            "InsertAffected",
+		ssInsertAffectedPkg:         "InsertAffectedPkg",
+		ssInsertAttachment:          "InsertAttachment",
+		ssInsertCatalogCwe:          "InsertCatalogCwe",
+		ssInsertCatalogRef:          "InsertCatalogRef",
+		ssInsertCve:                 "InsertCve",
+		ssInsertCwe:                 "InsertCwe",
+		ssInsertDates:               "InsertDates",
+		ssInsertEmployee:            "InsertEmployee",
+		ssInsertExploit:             "InsertExploit",
+		ssInsertImpact:              "InsertImpact",
+		ssInsertKevMatch:            "InsertKevMatch",
+		ssInsertLargeObject:         "InsertLargeObject",
+		ssInsertMerge:               "InsertMerge",
+		ssInsertNote:                "InsertNote",
+		ssInsertNoteMention:         "InsertNoteMention",
+		ssInsertNoteRevision:        "InsertNoteRevision",
+		ssInsertRefers:              "InsertRef",
+		ssInsertRelated:             "InsertRelated",
+		ssInsertRolePermission:      "InsertRolePermission",
+		ssInsertScanRef:             "InsertScanRef",
+		ssInsertSysAddr:             "InsertSysAddr",
+		ssInsertSysComponent:        "InsertSysComponent",
+		ssInsertSysPort:             "InsertSysPort",
+		ssInsertTask:                "InsertTask",
+		ssInsertTemplate:            "InsertTemplate",
+		ssInsertTemplateRef:         "InsertTemplateRef",
+		ssInsertTicket:              "InsertTicket",
+		ssInsertVuln:                "InsertVulnerability",
+		ssMergeCatalogEntry:         "MergeCatalogEntry",
+		ssUpdateAffected:            "UpdateAffected",
+		ssUpdateAttachmentsVuln:     "UpdateAttachmentsVuln",
+		ssUpdateCve:                 "UpdateCve",
+		ssUpdateCvss:                "UpdateCvss",
+		ssUpdateCvssLink:            "UpdateCvssLink",
+		ssUpdateCorpScore:           "UpdateCorpScore",
+		ssUpdateEmpActive:           "UpdateEmpActive",
+		ssUpdateEmpEmail:            "UpdateEmpEmail",
+		ssUpdateEmpFname:            "UpdateEmpFname",
+		ssUpdateEmpLname:            "UpdateEmpLname",
+		ssUpdateEmpRole:             "UpdateEmpRole",
+		ssUpdateEmpUname:            "UpdateEmpUname",
+		ssUpdateExploit:             "UpdateExploit",
+		ssUpdateExploitable:         "UpdateExploitable",
+		ssUpdateFinder:              "UpdateFinder",
+		ssUpdateInitiator:           "UpdateInitiator",
+		ssUpdateInitiatorOpen:       "UpdateInitiatorOpen",
+		ssUpdateInitDate:            "UpdateInitDate",
+		ssUpdateMerges:              "UpdateMerges",
+		ssUpdateMitDate:             "UpdateMitDate",
+		ssUpdateMitigation:          "UpdateMitigation",
+		ssUpdateNote:                "UpdateNote",
+		ssUpdateNoteDeleted:         "UpdateNoteDeleted",
+		ssUpdateNotePublic:          "UpdateNotePublic",
+		ssUpdateNotesVuln:           "UpdateNotesVuln",
+		ssUpdatePubDate:             "UpdatePubDate",
+		ssUpdateRefers:              "UpdateRefers",
+		ssUpdateScanRefsVuln:        "UpdateScanRefsVuln",
+		ssUpdateSummary:             "UpdateSummary",
+		ssUpdateSysName:             "UpdateSysName",
+		ssUpdateSysType:             "UpdateSysType",
+		ssUpdateSysOS:               "UpdateSysOS",
+		ssUpdateSysLoc:              "UpdateSysLoc",
+		ssUpdateSysDesc:             "UpdateSysDesc",
+		ssUpdateSysState:            "UpdateSysState",
+		ssUpdateTask:                "UpdateTask",
+		ssUpdateTasksSysNull:        "UpdateTasksSysNull",
+		ssUpdateTasksVuln:           "UpdateTasksVuln",
+		ssUpdateTemplate:            "UpdateTemplate",
+		ssUpdateTest:                "UpdateTest",
+		ssUpdateTicket:              "UpdateTicket",
+		ssUpdateVulnName:            "UpdateVulnName",
+		ssUpsertCatalogEntry:        "UpsertCatalogEntry",
+		ssUpsertEpssScore:           "UpsertEpssScore",
+		ssUpsertKevEntry:            "UpsertKevEntry",
+		ssUpsertScanMitigation:      "UpsertScanMitigation",
 	}
 )
 
@@ -267,25 +560,285 @@ var (
 type Affected struct {
 	Sys       System
 	Mitigated bool
+	Evidence  *ScanMitigation // Scan that showed the system mitigated, if it was mitigated by a scan import
+	Packages  []*AffectedPkg  // Vulnerable packages found on the system by a scan import
+}
+
+// AffectedPkg holds a vulnerable package of an affected system.
+type AffectedPkg struct {
+	Package   string
+	Installed string // Installed version
+	Fixed     string // Version the vulnerability is fixed in, if there is one
+}
+
+// Attachment holds the metadata of a file attached to a vulnerability or to one of its affected systems.
+// The contents of the file are kept in the configured BlobStore under StoreKey.
+type Attachment struct {
+	ID       int64
+	VulnID   int64
+	SysID    VarsNullInt64 // Affected system the file relates to, if any
+	EmpID    int64         // Employee that uploaded the file
+	FileName string
+	MimeType string
+	Size     int64  // Size of the contents in bytes
+	Sha256   string // Hex encoded SHA-256 checksum of the contents
+	StoreKey string `json:"-"`
+	Added    time.Time
+}
+
+// CatalogEntry holds a CVE record imported from the NVD feeds.
+type CatalogEntry struct {
+	Cve          string
+	Summary      string
+	Published    VarsNullTime   // Date the CVE was published
+	LastModified time.Time      // Date the CVE was last modified in the NVD
+	CvssVector   VarsNullString // Preferred CVSS vector string
+	CvssScore    float32        // Base score of the preferred CVSS vector
+	Cwes         []string       // CWE IDs
+	References   []string       // Reference URLs
+}
+
+// Component holds a software component of a system listed by an SBOM.
+type Component struct {
+	Name    string
+	Version string
+	Purl    string   // Package URL, if the SBOM has one
+	Cves    []string // CVEs the SBOM reports for the component
+}
+
+// CsvOptions holds the options of a CSV import.
+type CsvOptions struct {
+	BestEffort bool // Import the valid rows when other rows are rejected
+	DryRun     bool
+}
+
+// CsvRowError holds the problems that got a row of a CSV import rejected.
+type CsvRowError struct {
+	Row    int // Number of the row, not counting the header
+	Name   string
+	Errors []string
+}
+
+// Dossier holds everything about a vulnerability assessment that is shown on its printable summary.
+type Dossier struct {
+	Vuln      *Vulnerability
+	Finder    string          // Name of the employee that found the vulnerability
+	Initiator string          // Name of the employee that started the vulnerability assessment
+	Timeline  []*DossierEvent // Dates of the vulnerability, oldest first
+	Notes     []*DossierNote  // Notes that are not deleted, oldest first with each followed by its replies
+	Generated time.Time
+}
+
+// DossierEvent holds a dated event of the timeline of a dossier.
+type DossierEvent struct {
+	Date  time.Time
+	Event string
+}
+
+// DossierNote holds a note of a dossier with the name of its author.
+type DossierNote struct {
+	Author string
+	Added  time.Time
+	Note   string
+	Reply  bool // Whether the note is a reply to another note
 }
 
 // Employee holds information about an employee
 type Employee struct {
-	ID        int64
-	FirstName string
-	LastName  string
-	Email     string
-	UserName  string
-	Level     int
+	ID          int64
+	FirstName   string
+	LastName    string
+	Email       string
+	UserName    string
+	Role        string
+	Active      bool
+	Deactivated VarsNullTime
+}
+
+// EpssScore holds the FIRST EPSS score of a CVE.
+type EpssScore struct {
+	Cve          string
+	Score        float32        // Probability of exploitation in the next 30 days
+	Percentile   float32        // Percentile of the score among all scored CVEs
+	ModelDate    time.Time      // Date the score was published
+	ModelVersion VarsNullString // Version of the EPSS model
+}
+
+// InventoryDiff holds the changes made to the systems by an inventory import such as Nmap. For a dry run it holds
+// the changes that would have been made. Systems are never set inactive by the import; the active systems that were
+// in the scanned networks but not seen are only reported as candidates.
+type InventoryDiff struct {
+	Scanner            string
+	DryRun             bool
+	NewSystems         []*System
+	Changed            []*SystemChange
+	InactiveCandidates []*System
+	Unchanged          int // Systems seen without changes
+}
+
+// KevEntry holds a vulnerability of the CISA Known Exploited Vulnerabilities catalog.
+type KevEntry struct {
+	Cve        string
+	Vendor     string
+	Product    string
+	Name       string
+	Action     string       // Required action
+	DateAdded  time.Time    // Date the CVE was added to the catalog
+	DueDate    VarsNullTime // Date the required action is due
+	Ransomware string       // Known use in ransomware campaigns (Known or Unknown)
+}
+
+// KevMatch holds a CVE of a vulnerability that was found in the KEV catalog.
+type KevMatch struct {
+	VulnID   int64
+	VulnName string
+	Cve      string
+	Matched  time.Time // Date the match was found
 }
 
 // Note holds the imformation about a note
 type Note struct {
-	ID     int64
+	ID       int64
+	VulnID   int64
+	EmpID    int64
+	Added    time.Time
+	Note     string
+	Parent   VarsNullInt64 // Note that this note is a reply to
+	Deleted  VarsNullTime  // Date the note was deleted
+	Mentions []int64       // Employees mentioned in the note
+	Public   bool          // Whether the note is included in published advisories
+}
+
+// NoteRevision holds a previous version of a note and the date it was replaced.
+type NoteRevision struct {
+	NoteID  int64
+	Revised time.Time
+	Note    string
+}
+
+// OsvOptions holds the options of an OSV import.
+type OsvOptions struct {
+	DryRun   bool  // Roll back the import and only report the changes
+	Employee int64 // Employee recorded as the finder and initiator of new vulnerabilities
+	Vulns    bool  // Start vulnerabilities for the records instead of adding them to the CVE catalog
+}
+
+// OsvReport holds the results of an OSV import.
+type OsvReport struct {
+	DryRun    bool
+	Records   int              // Records read
+	Imported  int              // Catalog entries added or replaced
+	Unchanged int              // Catalog entries that were already up to date
+	NewVulns  []*Vulnerability // Vulnerabilities started for the records
+	Tracked   []string         // IDs of the records already tracked by a vulnerability
+	Skipped   []string         // IDs of the records that were withdrawn or, for the catalog, have no CVE
+}
+
+// ScanAffected holds an affected row added by a scan import.
+type ScanAffected struct {
 	VulnID int64
-	EmpID  int64
-	Added  time.Time
-	Note   string
+	Vuln   string // Name of the vulnerability
+	SysID  int64
+	System string // Name of the system
+}
+
+// ScanConflict holds an affected system whose state a scan import did not change because it was changed by hand.
+type ScanConflict struct {
+	ScanAffected
+	Reason string
+}
+
+// ScanDiff holds the changes made by a scan import. For a dry run it holds the changes that would have been made;
+// the IDs of the new vulnerabilities and systems are then only placeholders.
+type ScanDiff struct {
+	Scanner      string
+	DryRun       bool
+	NewSystems   []*System
+	NewVulns     []*Vulnerability
+	NewAffected  []*ScanAffected
+	Mitigated    []*ScanAffected // Affected systems no longer reported by the scan (reconciliation only)
+	Reopened     []*ScanAffected // Mitigated systems reported again by the scan (reconciliation only)
+	Conflicts    []*ScanConflict // Affected systems that were left alone (reconciliation only)
+	UnknownHosts []string        // Hosts that could not be resolved to a system and were not added
+	Unchanged    int             // Findings on systems that were already recorded as affected
+}
+
+// ScanFinding holds a vulnerability reported by a scanner on a host.
+type ScanFinding struct {
+	PluginID   string // Identifier of the check in the scanner
+	Name       string
+	Cves       []string
+	Cvss       float32
+	Summary    string
+	Solution   string
+	References []string
+	Packages   []*AffectedPkg // Vulnerable packages the finding was reported for
+}
+
+// ScanHost holds a host of a scan report and the vulnerabilities reported on it.
+type ScanHost struct {
+	Name     string // Host name reported by the scanner
+	Address  string // IP address reported by the scanner
+	Type     string // Type of the system added for the host, "unknown" if empty
+	OpSys    string
+	Findings []*ScanFinding
+	Ports    []*SysPort // Open ports
+}
+
+// ScanMitigation holds the scan that showed an affected system mitigated.
+type ScanMitigation struct {
+	Scanner   string
+	Scan      string    // Name of the scan report
+	Mitigated time.Time // When the scan was imported
+}
+
+// ScanOptions holds the options of a scan import.
+type ScanOptions struct {
+	DryRun    bool  // Roll back the import and only report the changes
+	Employee  int64 // Employee recorded as the finder and initiator of new vulnerabilities
+	Reconcile bool  // Mitigate and reopen the affected systems of the scanned hosts to match the scan
+}
+
+// ScanReport holds the results of a vulnerability scan.
+type ScanReport struct {
+	Scanner string // Name of the scanner (nessus, openvas, etc)
+	Name    string // Name or ID of the scan, if the scanner reports one
+	Hosts   []*ScanHost
+	Targets []string // Networks (CIDR) and addresses that were scanned, if the scanner reports them
+}
+
+// Sbom holds the components listed by a software bill of materials.
+type Sbom struct {
+	Format     string // cyclonedx or spdx
+	Name       string // Name of the SBOM document or of the component it describes
+	Components []*Component
+}
+
+// SbomCandidate holds an affected row suggested by the components of a system.
+type SbomCandidate struct {
+	ScanAffected
+	Component string
+	Version   string
+	Reason    string
+}
+
+// SbomDiff holds the changes made to the components of a system by an SBOM import, and the vulnerabilities that
+// may affect the system through its components. For a dry run it holds the changes that would have been made.
+type SbomDiff struct {
+	Format     string
+	DryRun     bool
+	System     string
+	Components int // Number of components listed by the SBOM
+	Added      []*Component
+	Removed    []*Component
+	Candidates []*SbomCandidate
+}
+
+// SysPort holds an open port of a system.
+type SysPort struct {
+	Port     int
+	Protocol string // tcp, udp or sctp
+	Service  string
 }
 
 // System holds information about systems in the environment.
@@ -296,7 +849,60 @@ type System struct {
 	OpSys       string
 	Location    string // Corporate, hosted, etc
 	Description string
-	State       string // Active or inactive
+	State       string     // Active or inactive
+	Addresses   []string   // IP addresses and host names the system is known by to scanners
+	Ports       []*SysPort // Open ports found by the last inventory import
+}
+
+// SystemsCsvReport holds the results of a systems CSV import. Committed is false when nothing was written, either
+// for a dry run or because rows were rejected and the import was not best effort.
+type SystemsCsvReport struct {
+	DryRun    bool
+	Committed bool
+	Rows      int
+	Added     []*System
+	Rejected  []*CsvRowError
+	Ignored   []string // Columns of the header that are not system fields
+}
+
+// SystemChange holds the changes an inventory import made to a system.
+type SystemChange struct {
+	System       *System
+	OpSys        string // OS guess filled into a system without an OS, if any
+	NewAddresses []string
+	OpenedPorts  []*SysPort
+	ClosedPorts  []*SysPort
+}
+
+// Task holds a remediation step of a vulnerability.
+type Task struct {
+	ID       int64
+	VulnID   int64
+	Title    string
+	Assignee VarsNullInt64 // Employee responsible for the task
+	Due      VarsNullTime  // Date the task is due
+	Done     bool
+	System   VarsNullInt64 // System the task is scoped to
+}
+
+// Template holds the values used to prefill a new vulnerability assessment.
+type Template struct {
+	ID         int64
+	Name       string
+	Summary    string
+	Test       string
+	Mitigation string
+	Cvss       float32        // Default CVSS score
+	CvssLink   VarsNullString // Default link to CVSS scoresheet
+	CorpScore  float32        // Default corporate score
+	References []string       // Reference URLs
+}
+
+// VulnPkg holds a vulnerable package of an affected row and the vulnerability it belongs to.
+type VulnPkg struct {
+	VulnID int64
+	Vuln   string
+	AffectedPkg
 }
 
 // VulnDates holds the different dates relating to the vulnerability.
@@ -311,6 +917,7 @@ type Vulnerability struct {
 	ID          int64
 	Name        string
 	Cves        []string
+	Cwes        []string       // CWE IDs
 	Cvss        float32        // CVSS score
 	CorpScore   float32        // Calculated corporate score
 	CvssLink    VarsNullString // Link to CVSS scoresheet
@@ -324,7 +931,29 @@ type Vulnerability struct {
 	References  []string       // Reference URLs
 	Exploit     VarsNullString // Exploit for the vulnerability
 	Exploitable VarsNullBool   // Are there currently exploits for the vulnerability
+	Kev         []*KevEntry    // KEV catalog entries of the CVEs
+	Epss        *EpssScore     // Highest EPSS score of the CVEs
 	AffSystems  []*Affected    // Affected systems and whether they have been mitigated
+	Related     []*Related     // Links to other vulnerabilities
+	Tasks       []*Task        // Remediation tasks
+	Progress    int            // Percentage of the remediation tasks that are done
+}
+
+// Types of links between vulnerabilities. The inverse types are used when the link was made
+// from the other vulnerability.
+const (
+	RelDuplicateOf  = "duplicate-of"
+	RelSupersedes   = "supersedes"
+	RelRelated      = "related"
+	RelDuplicatedBy = "duplicated-by"
+	RelSupersededBy = "superseded-by"
+)
+
+// Related holds a link from a vulnerability to another vulnerability.
+type Related struct {
+	VulnID int64  // The other vulnerability
+	Name   string // Name of the other vulnerability
+	Type   string // Type of the link as seen from this vulnerability
 }
 
 // DeleteAffected deletes the row in the affected table with the given vulnid and sysid.
@@ -332,11 +961,36 @@ func DeleteAffected(tx *sql.Tx, vid, sid int64) Err {
 	return execMutation(tx, ssDeleteAffected, vid, sid)
 }
 
+// DeleteAffectedPkgs deletes the vulnerable packages of the affected row (vid, sid).
+func DeleteAffectedPkgs(tx *sql.Tx, vid, sid int64) Err {
+	return execMutation(tx, ssDeleteAffectedPkgs, vid, sid)
+}
+
+// DeleteAttachment deletes the row in the attachments table with the given attachid.
+func DeleteAttachment(tx *sql.Tx, aid int64) Err {
+	return execMutation(tx, ssDeleteAttachment, aid)
+}
+
+// DeleteCatalogCwes deletes the CWEs of the CVE from the cve_catalog_cwes table.
+func DeleteCatalogCwes(tx *sql.Tx, cve string) Err {
+	return execMutation(tx, ssDeleteCatalogCwes, cve)
+}
+
+// DeleteCatalogRefs deletes the reference URLs of the CVE from the cve_catalog_refs table.
+func DeleteCatalogRefs(tx *sql.Tx, cve string) Err {
+	return execMutation(tx, ssDeleteCatalogRefs, cve)
+}
+
 // DeleteCve deletes the row in the cves table with the given vulnid and cve.
 func DeleteCve(tx *sql.Tx, vid int64, cve string) Err {
 	return execMutation(tx, ssDeleteCve, vid, cve)
 }
 
+// DeleteCwe deletes the row in the cwes table with the given vulnid and cwe.
+func DeleteCwe(tx *sql.Tx, vid int64, cwe string) Err {
+	return execMutation(tx, ssDeleteCwe, vid, cwe)
+}
+
 // DeleteDates deletes the row in the dates table with the given vulnid.
 func DeleteDates(tx *sql.Tx, vid int64) Err {
 	return execMutation(tx, ssDeleteDates, vid)
@@ -352,16 +1006,86 @@ func DeleteImpact(tx *sql.Tx, vid int64) Err {
 	return execMutation(tx, ssDeleteImpact, vid)
 }
 
+// DeleteKevMatches deletes the rows in the kevmatches table with the given vulnid.
+func DeleteKevMatches(tx *sql.Tx, vid int64) Err {
+	return execMutation(tx, ssDeleteKevMatches, vid)
+}
+
+// DeleteMerges deletes the merge records that redirect to the given vulnid.
+func DeleteMerges(tx *sql.Tx, vid int64) Err {
+	return execMutation(tx, ssDeleteMerges, vid)
+}
+
 // DeleteNote deletes the row in the notes table with the given noteid.
 func DeleteNote(tx *sql.Tx, noteid int64) Err {
 	return execMutation(tx, ssDeleteNote, noteid)
 }
 
+// DeleteNoteMentions deletes the rows in the notementions table with the given noteid.
+func DeleteNoteMentions(tx *sql.Tx, noteid int64) Err {
+	return execMutation(tx, ssDeleteNoteMentions, noteid)
+}
+
+// DeleteNoteRevisions deletes the rows in the noterevisions table with the given noteid.
+func DeleteNoteRevisions(tx *sql.Tx, noteid int64) Err {
+	return execMutation(tx, ssDeleteNoteRevisions, noteid)
+}
+
 // DeleteRef deletes the row in the ref table with the given vulnid and url.
 func DeleteRef(tx *sql.Tx, vid int64, ref string) Err {
 	return execMutation(tx, ssDeleteRef, vid, ref)
 }
 
+// DeleteRolePermissions deletes the permissions of the given role from the rolepermissions table.
+func DeleteRolePermissions(tx *sql.Tx, role string) Err {
+	return execMutation(tx, ssDeleteRolePermissions, role)
+}
+
+// DeleteRelated deletes the link between the two vulnerabilities, in either direction.
+func DeleteRelated(tx *sql.Tx, vid, rvid int64) Err {
+	return execMutation(tx, ssDeleteRelated, vid, rvid)
+}
+
+// DeleteRelatedAll deletes all of the links to and from the vulnerability.
+func DeleteRelatedAll(tx *sql.Tx, vid int64) Err {
+	return execMutation(tx, ssDeleteRelatedAll, vid)
+}
+
+// DeleteScanMitigation deletes the scan evidence of the affected row (vid, sid) from the scanmitigations table.
+func DeleteScanMitigation(tx *sql.Tx, vid, sid int64) Err {
+	return execMutation(tx, ssDeleteScanMitigation, vid, sid)
+}
+
+// DeleteScanRefs deletes the rows in the scanrefs table with the given vulnid.
+func DeleteScanRefs(tx *sql.Tx, vid int64) Err {
+	return execMutation(tx, ssDeleteScanRefs, vid)
+}
+
+// DeleteSysAddrs deletes the addresses of the system from the sysaddrs table.
+func DeleteSysAddrs(tx *sql.Tx, sid int64) Err {
+	return execMutation(tx, ssDeleteSysAddrs, sid)
+}
+
+// DeleteSysAffectedPkgs deletes the vulnerable packages of the affected rows of the system.
+func DeleteSysAffectedPkgs(tx *sql.Tx, sid int64) Err {
+	return execMutation(tx, ssDeleteSysAffectedPkgs, sid)
+}
+
+// DeleteSysComponents deletes the components of the system from the syscomponents table.
+func DeleteSysComponents(tx *sql.Tx, sid int64) Err {
+	return execMutation(tx, ssDeleteSysComponents, sid)
+}
+
+// DeleteSysPorts deletes the open ports of the system from the sysports table.
+func DeleteSysPorts(tx *sql.Tx, sid int64) Err {
+	return execMutation(tx, ssDeleteSysPorts, sid)
+}
+
+// DeleteSysScanMitigations deletes the scan evidence of the affected rows of the system.
+func DeleteSysScanMitigations(tx *sql.Tx, sid int64) Err {
+	return execMutation(tx, ssDeleteSysScanMitigations, sid)
+}
+
 // DeleteSystem deletes the row in the systems table with the given sysid.
 func DeleteSystem(tx *sql.Tx, sid int64) Err {
 	return execMutation(tx, ssDeleteSys, sid)
@@ -372,11 +1096,41 @@ func DeleteSystemFromAffected(tx *sql.Tx, sid int64) Err {
 	return execMutation(tx, ssDeleteSysA, sid)
 }
 
+// DeleteTask deletes the row in the tasks table with the given taskid.
+func DeleteTask(tx *sql.Tx, tid int64) Err {
+	return execMutation(tx, ssDeleteTask, tid)
+}
+
+// DeleteTasks deletes the rows in the tasks table with the given vulnid.
+func DeleteTasks(tx *sql.Tx, vid int64) Err {
+	return execMutation(tx, ssDeleteTasks, vid)
+}
+
+// DeleteTemplate deletes the row in the templates table with the given templateid.
+func DeleteTemplate(tx *sql.Tx, tid int64) Err {
+	return execMutation(tx, ssDeleteTemplate, tid)
+}
+
+// DeleteTemplateRefs deletes the rows in the templaterefs table with the given templateid.
+func DeleteTemplateRefs(tx *sql.Tx, tid int64) Err {
+	return execMutation(tx, ssDeleteTemplateRefs, tid)
+}
+
 // DeleteTicket deletes the row in the tickets table with the given vulnid and ticket.
 func DeleteTicket(tx *sql.Tx, vid int64, ticket string) Err {
 	return execMutation(tx, ssDeleteTicket, vid, ticket)
 }
 
+// DeleteVulnAffectedPkgs deletes the vulnerable packages of the affected rows of the vulnerability.
+func DeleteVulnAffectedPkgs(tx *sql.Tx, vid int64) Err {
+	return execMutation(tx, ssDeleteVulnAffectedPkgs, vid)
+}
+
+// DeleteVulnScanMitigations deletes the scan evidence of the affected rows of the vulnerability.
+func DeleteVulnScanMitigations(tx *sql.Tx, vid int64) Err {
+	return execMutation(tx, ssDeleteVulnScanMitigations, vid)
+}
+
 // DeleteVulnerability deletes the row in the vuln table with the given vulnid.
 func DeleteVulnerability(tx *sql.Tx, vid int64) Err {
 	return execMutation(tx, ssDeleteVuln, vid)
@@ -394,7 +1148,9 @@ func GetAffected(vid int64) ([]*Affected, error) {
 		var a Affected
 		var m bool
 		var sid int64
-		if err := rows.Scan(&sid, &m); err != nil {
+		var scanner, scan VarsNullString
+		var smit VarsNullTime
+		if err := rows.Scan(&sid, &m, &scanner, &scan, &smit); err != nil {
 			return affs, newErrFromErr(err, execNames[ssGetAffected], "rows.Scan")
 		}
 		sys, err := GetSystem(sid)
@@ -403,6 +1159,13 @@ func GetAffected(vid int64) ([]*Affected, error) {
 		}
 		a.Sys = *sys
 		a.Mitigated = m
+		a.Packages, err = GetAffectedPkgs(vid, sid)
+		if err != nil {
+			return affs, err
+		}
+		if m && scanner.Valid {
+			a.Evidence = &ScanMitigation{Scanner: scanner.String, Scan: scan.String, Mitigated: smit.Time}
+		}
 		affs = append(affs, &a)
 	}
 	if err := rows.Err(); err != nil {
@@ -411,6 +1174,79 @@ func GetAffected(vid int64) ([]*Affected, error) {
 	return affs, nil
 }
 
+// GetAffectedMitigatedtx returns whether the system is mitigated for the vulnerability using the given transaction.
+// A no rows error is returned if the system is not affected.
+func GetAffectedMitigatedtx(tx *sql.Tx, vid, sid int64) (bool, error) {
+	var mit bool
+	err := tx.Stmt(queries[ssGetAffectedMitigated]).QueryRow(vid, sid).Scan(&mit)
+	if err != nil {
+		return mit, newErrFromErr(err, execNames[ssGetAffectedMitigated])
+	}
+	return mit, nil
+}
+
+// GetAffectedPkgs returns the vulnerable packages of the affected row (vid, sid).
+func GetAffectedPkgs(vid, sid int64) ([]*AffectedPkg, error) {
+	pkgs := []*AffectedPkg{}
+	rows, err := queries[ssGetAffectedPkgs].Query(vid, sid)
+	if err != nil {
+		return pkgs, newErrFromErr(err, execNames[ssGetAffectedPkgs])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p AffectedPkg
+		if err := rows.Scan(&p.Package, &p.Installed, &p.Fixed); err != nil {
+			return pkgs, newErrFromErr(err, execNames[ssGetAffectedPkgs], "rows.Scan")
+		}
+		pkgs = append(pkgs, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return pkgs, newErrFromErr(err, execNames[ssGetAffectedPkgs])
+	}
+	return pkgs, nil
+}
+
+// GetAttachment returns an Attachment object with the given attachid.
+func GetAttachment(aid int64) (*Attachment, error) {
+	var att Attachment
+	att.ID = aid
+	err := queries[ssGetAttachment].QueryRow(aid).Scan(&att.VulnID, &att.SysID, &att.EmpID, &att.FileName, &att.MimeType, &att.Size, &att.Sha256, &att.StoreKey, &att.Added)
+	if err != nil {
+		return &att, newErrFromErr(err, execNames[ssGetAttachment])
+	}
+	return &att, nil
+}
+
+// GetAttachments returns a slice of pointers to the Attachment objects for the given vulnid.
+func GetAttachments(vid int64) ([]*Attachment, error) {
+	return execGetRowsAttach(ssGetAttachments, vid)
+}
+
+// GetAttachmentsBySystem returns a slice of pointers to the Attachment objects for the given sysid.
+func GetAttachmentsBySystem(sid int64) ([]*Attachment, error) {
+	return execGetRowsAttach(ssGetAttachmentsBySys, sid)
+}
+
+// GetCatalogEntry returns the CatalogEntry of the CVE, including its CWEs and references.
+func GetCatalogEntry(cve string) (*CatalogEntry, error) {
+	var e CatalogEntry
+	err := queries[ssGetCatalogEntry].QueryRow(cve).Scan(&e.Cve, &e.Summary, &e.Published, &e.LastModified, &e.CvssVector, &e.CvssScore)
+	if err != nil {
+		return &e, newErrFromErr(err, execNames[ssGetCatalogEntry])
+	}
+	cwes, err := execGetRowsStr(ssGetCatalogCwes, e.Cve)
+	if !IsNilErr(err) {
+		return &e, newErrFromErr(err, execNames[ssGetCatalogCwes])
+	}
+	e.Cwes = *cwes
+	refs, err := execGetRowsStr(ssGetCatalogRefs, e.Cve)
+	if !IsNilErr(err) {
+		return &e, newErrFromErr(err, execNames[ssGetCatalogRefs])
+	}
+	e.References = *refs
+	return &e, nil
+}
+
 // GetEmpID returns the empid associated with the employee.
 func GetEmpID(username string) (int64, error) {
 	var id int64
@@ -435,7 +1271,7 @@ func GetEmpIDtx(tx *sql.Tx, username string) (int64, error) {
 func GetEmployee(eid int64) (*Employee, error) {
 	var emp Employee
 	emp.ID = eid
-	err := queries[ssGetEmployee].QueryRow(eid).Scan(&emp.FirstName, &emp.LastName, &emp.Email, &emp.UserName, &emp.Level)
+	err := queries[ssGetEmployee].QueryRow(eid).Scan(&emp.FirstName, &emp.LastName, &emp.Email, &emp.UserName, &emp.Role, &emp.Active, &emp.Deactivated)
 	if !IsNilErr(err) {
 		return &emp, newErrFromErr(err, execNames[ssGetEmployee])
 	}
@@ -452,7 +1288,7 @@ func GetEmployees() ([]*Employee, error) {
 	defer rows.Close()
 	for rows.Next() {
 		var e Employee
-		if err := rows.Scan(&e.ID, &e.FirstName, &e.LastName, &e.Email, &e.UserName, &e.Level); err != nil {
+		if err := rows.Scan(&e.ID, &e.FirstName, &e.LastName, &e.Email, &e.UserName, &e.Role, &e.Active, &e.Deactivated); err != nil {
 			return emps, newErrFromErr(err, execNames[ssGetEmps], "rows.Scan")
 		}
 		emps = append(emps, &e)
@@ -463,6 +1299,19 @@ func GetEmployees() ([]*Employee, error) {
 	return emps, nil
 }
 
+// GetEpssByVuln returns the highest EPSS score of the CVEs of the vulnerability, or nil if none of them are scored.
+func GetEpssByVuln(vid int64) (*EpssScore, error) {
+	var s EpssScore
+	err := queries[ssGetEpssByVuln].QueryRow(vid).Scan(&s.Cve, &s.Score, &s.Percentile, &s.ModelDate, &s.ModelVersion)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newErrFromErr(err, execNames[ssGetEpssByVuln])
+	}
+	return &s, nil
+}
+
 // GetExploit returns the row from the exploits table for the given vulnid.
 func GetExploit(vid int64) (VarsNullString, VarsNullBool, error) {
 	var exploit VarsNullString
@@ -489,6 +1338,16 @@ func GetCves(vid int64) (*[]string, error) {
 	return cves, nil
 }
 
+// GetCwes returns a pointer to a slice of the CWE IDs associated with the vulnid.
+func GetCwes(vid int64) (*[]string, error) {
+	cwes, err := execGetRowsStr(ssGetCwes, vid)
+	if !IsNilErr(err) {
+		var c []string
+		return &c, newErrFromErr(err, execNames[ssGetCwes])
+	}
+	return cwes, nil
+}
+
 // GetImpact returns the row from the impact table for the given vulnid.
 func GetImpact(vid int64) (float32, VarsNullString, float32, error) {
 	var cvss float32
@@ -501,11 +1360,117 @@ func GetImpact(vid int64) (float32, VarsNullString, float32, error) {
 	return cvss, cvssLink, corpscore, nil
 }
 
+// GetKevByVuln returns the KEV catalog entries of the CVEs of the vulnerability ordered by the date they were added.
+func GetKevByVuln(vid int64) ([]*KevEntry, error) {
+	entries := []*KevEntry{}
+	rows, err := queries[ssGetKevByVuln].Query(vid)
+	if err != nil {
+		return entries, newErrFromErr(err, execNames[ssGetKevByVuln])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e KevEntry
+		if err := rows.Scan(&e.Cve, &e.Vendor, &e.Product, &e.Name, &e.Action, &e.DateAdded, &e.DueDate, &e.Ransomware); err != nil {
+			return entries, newErrFromErr(err, execNames[ssGetKevByVuln], "rows.Scan")
+		}
+		entries = append(entries, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return entries, newErrFromErr(err, execNames[ssGetKevByVuln])
+	}
+	return entries, nil
+}
+
+// GetKevMatches returns the KEV matches found since the given time, newest first.
+func GetKevMatches(since time.Time) ([]*KevMatch, error) {
+	matches := []*KevMatch{}
+	rows, err := queries[ssGetKevMatches].Query(since)
+	if err != nil {
+		return matches, newErrFromErr(err, execNames[ssGetKevMatches])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m KevMatch
+		if err := rows.Scan(&m.VulnID, &m.VulnName, &m.Cve, &m.Matched); err != nil {
+			return matches, newErrFromErr(err, execNames[ssGetKevMatches], "rows.Scan")
+		}
+		matches = append(matches, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return matches, newErrFromErr(err, execNames[ssGetKevMatches])
+	}
+	return matches, nil
+}
+
+// GetKevUnmatchedtx returns the CVEs of vulnerabilities that are in the KEV catalog but have not been matched
+// yet, using the given transaction. Only the VulnID and Cve of the matches are filled in.
+func GetKevUnmatchedtx(tx *sql.Tx) ([]*KevMatch, error) {
+	matches := []*KevMatch{}
+	rows, err := tx.Stmt(queries[ssGetKevUnmatched]).Query()
+	if err != nil {
+		return matches, newErrFromErr(err, execNames[ssGetKevUnmatched])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m KevMatch
+		if err := rows.Scan(&m.VulnID, &m.Cve); err != nil {
+			return matches, newErrFromErr(err, execNames[ssGetKevUnmatched], "rows.Scan")
+		}
+		matches = append(matches, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return matches, newErrFromErr(err, execNames[ssGetKevUnmatched])
+	}
+	return matches, nil
+}
+
 // GetOpenVulnIDs returns a pointer to a slice of vulnerability IDs that do not have a mitigated date.
 func GetOpenVulnIDs() (*[]int64, error) {
 	return execGetRowsInt(ssGetOpenVulnIDs)
 }
 
+// GetOpenVulnPkgs returns the vulnerable packages recorded on the affected rows of the open vulnerabilities.
+func GetOpenVulnPkgs() ([]*VulnPkg, error) {
+	pkgs := []*VulnPkg{}
+	rows, err := queries[ssGetOpenVulnPkgs].Query()
+	if err != nil {
+		return pkgs, newErrFromErr(err, execNames[ssGetOpenVulnPkgs])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p VulnPkg
+		if err := rows.Scan(&p.VulnID, &p.Vuln, &p.Package, &p.Installed, &p.Fixed); err != nil {
+			return pkgs, newErrFromErr(err, execNames[ssGetOpenVulnPkgs], "rows.Scan")
+		}
+		pkgs = append(pkgs, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return pkgs, newErrFromErr(err, execNames[ssGetOpenVulnPkgs])
+	}
+	return pkgs, nil
+}
+
+// GetMergedInto returns the vulnid that the given (dropped) vulnid was merged into.
+func GetMergedInto(vid int64) (int64, error) {
+	var id int64
+	err := queries[ssGetMergedInto].QueryRow(vid).Scan(&id)
+	if err != nil {
+		return id, newErrFromErr(err, execNames[ssGetMergedInto])
+	}
+	return id, nil
+}
+
+// GetNote returns a Note object with the given noteid.
+func GetNote(noteid int64) (*Note, error) {
+	var n Note
+	n.ID = noteid
+	err := queries[ssGetNote].QueryRow(noteid).Scan(&n.VulnID, &n.EmpID, &n.Added, &n.Note, &n.Parent, &n.Deleted, &n.Public)
+	if err != nil {
+		return &n, newErrFromErr(err, execNames[ssGetNote])
+	}
+	return &n, nil
+}
+
 // GetNoteAuthor returns the empid of the author of the note.
 func GetNoteAuthor(noteid int64) (int64, error) {
 	var empid int64
@@ -526,7 +1491,8 @@ func GetNotes(vid int64) ([]*Note, error) {
 	defer rows.Close()
 	for rows.Next() {
 		var n Note
-		if err := rows.Scan(&n.ID, &n.EmpID, &n.Added, &n.Note); err != nil {
+		n.VulnID = vid
+		if err := rows.Scan(&n.ID, &n.EmpID, &n.Added, &n.Note, &n.Parent, &n.Deleted, &n.Public); err != nil {
 			return notes, newErrFromErr(err, execNames[ssGetNotes], "rows.Scan")
 		}
 		notes = append(notes, &n)
@@ -537,6 +1503,32 @@ func GetNotes(vid int64) ([]*Note, error) {
 	return notes, nil
 }
 
+// GetNoteMentions returns a pointer to a slice of empids mentioned in the note.
+func GetNoteMentions(noteid int64) (*[]int64, error) {
+	return execGetRowsInt(ssGetNoteMentions, noteid)
+}
+
+// GetNoteRevisions returns a slice of pointers to the previous versions of the note, oldest first.
+func GetNoteRevisions(noteid int64) ([]*NoteRevision, error) {
+	revs := []*NoteRevision{}
+	rows, err := queries[ssGetNoteRevisions].Query(noteid)
+	if err != nil {
+		return revs, newErrFromErr(err, execNames[ssGetNoteRevisions])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r := NoteRevision{NoteID: noteid}
+		if err := rows.Scan(&r.Revised, &r.Note); err != nil {
+			return revs, newErrFromErr(err, execNames[ssGetNoteRevisions], "rows.Scan")
+		}
+		revs = append(revs, &r)
+	}
+	if err := rows.Err(); err != nil {
+		return revs, newErrFromErr(err, execNames[ssGetNoteRevisions])
+	}
+	return revs, nil
+}
+
 // GetReferences returns a pointer to a slice of urls associated with the vulnid.
 func GetReferences(vid int64) (*[]string, error) {
 	refs, err := execGetRowsStr(ssGetReferences, vid)
@@ -547,6 +1539,168 @@ func GetReferences(vid int64) (*[]string, error) {
 	return refs, nil
 }
 
+// GetRelated returns the links to and from the vulnerability with the given vulnid.
+func GetRelated(vid int64) ([]*Related, error) {
+	rels := []*Related{}
+	rows, err := queries[ssGetRelated].Query(vid)
+	if err != nil {
+		return rels, newErrFromErr(err, execNames[ssGetRelated])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rel Related
+		var inverse bool
+		if err := rows.Scan(&rel.VulnID, &rel.Name, &rel.Type, &inverse); err != nil {
+			return rels, newErrFromErr(err, execNames[ssGetRelated], "rows.Scan")
+		}
+		if inverse {
+			switch rel.Type {
+			case RelDuplicateOf:
+				rel.Type = RelDuplicatedBy
+			case RelSupersedes:
+				rel.Type = RelSupersededBy
+			}
+		}
+		rels = append(rels, &rel)
+	}
+	if err := rows.Err(); err != nil {
+		return rels, newErrFromErr(err, execNames[ssGetRelated])
+	}
+	return rels, nil
+}
+
+// GetRolePermissions returns the roles defined in the rolepermissions table as a map of role name
+// to the permissions granted to that role.
+func GetRolePermissions() (map[string][]string, error) {
+	roles := make(map[string][]string)
+	rows, err := queries[ssGetRolePermissions].Query()
+	if err != nil {
+		return roles, newErrFromErr(err, execNames[ssGetRolePermissions])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var role, perm string
+		if err := rows.Scan(&role, &perm); err != nil {
+			return roles, newErrFromErr(err, execNames[ssGetRolePermissions], "rows.Scan")
+		}
+		roles[role] = append(roles[role], perm)
+	}
+	if err := rows.Err(); err != nil {
+		return roles, newErrFromErr(err, execNames[ssGetRolePermissions])
+	}
+	return roles, nil
+}
+
+// GetScanAffectedtx returns the open vulnerabilities the system is affected by and not mitigated for that have
+// plugins of the scanner mapped to them, using the given transaction.
+func GetScanAffectedtx(tx *sql.Tx, sid int64, scanner string) ([]*ScanAffected, error) {
+	affs := []*ScanAffected{}
+	rows, err := tx.Stmt(queries[ssGetScanAffected]).Query(sid, scanner)
+	if err != nil {
+		return affs, newErrFromErr(err, execNames[ssGetScanAffected])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		a := ScanAffected{SysID: sid}
+		if err := rows.Scan(&a.VulnID, &a.Vuln); err != nil {
+			return affs, newErrFromErr(err, execNames[ssGetScanAffected], "rows.Scan")
+		}
+		affs = append(affs, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return affs, newErrFromErr(err, execNames[ssGetScanAffected])
+	}
+	return affs, nil
+}
+
+// GetScanMitigationtx returns the scan evidence of the affected row (vid, sid) using the given transaction.
+// A no rows error is returned if the row was not mitigated by a scan import.
+func GetScanMitigationtx(tx *sql.Tx, vid, sid int64) (*ScanMitigation, error) {
+	var m ScanMitigation
+	err := tx.Stmt(queries[ssGetScanMitigation]).QueryRow(vid, sid).Scan(&m.Scanner, &m.Scan, &m.Mitigated)
+	if err != nil {
+		return &m, newErrFromErr(err, execNames[ssGetScanMitigation])
+	}
+	return &m, nil
+}
+
+// GetScanRefVulntx returns the vulnid mapped to the plugin of the scanner using the given transaction.
+func GetScanRefVulntx(tx *sql.Tx, scanner, pluginid string) (int64, error) {
+	var id int64
+	err := tx.Stmt(queries[ssGetScanRefVuln]).QueryRow(scanner, pluginid).Scan(&id)
+	if err != nil {
+		return id, newErrFromErr(err, execNames[ssGetScanRefVuln])
+	}
+	return id, nil
+}
+
+// GetSysAddrs returns a pointer to a slice of the addresses of the system.
+func GetSysAddrs(sid int64) (*[]string, error) {
+	addrs, err := execGetRowsStr(ssGetSysAddrs, sid)
+	if !IsNilErr(err) {
+		var a []string
+		return &a, newErrFromErr(err, execNames[ssGetSysAddrs])
+	}
+	return addrs, nil
+}
+
+// GetSysComponents returns the components of the system.
+func GetSysComponents(sid int64) ([]*Component, error) {
+	comps := []*Component{}
+	rows, err := queries[ssGetSysComponents].Query(sid)
+	if err != nil {
+		return comps, newErrFromErr(err, execNames[ssGetSysComponents])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c Component
+		if err := rows.Scan(&c.Name, &c.Version, &c.Purl); err != nil {
+			return comps, newErrFromErr(err, execNames[ssGetSysComponents], "rows.Scan")
+		}
+		comps = append(comps, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return comps, newErrFromErr(err, execNames[ssGetSysComponents])
+	}
+	return comps, nil
+}
+
+// GetSysIDByAddrtx returns the sysid of the system known by the address using the given transaction.
+func GetSysIDByAddrtx(tx *sql.Tx, addr string) (int64, error) {
+	var id int64
+	err := tx.Stmt(queries[ssGetSysIDByAddr]).QueryRow(addr).Scan(&id)
+	if err != nil {
+		return id, newErrFromErr(err, execNames[ssGetSysIDByAddr])
+	}
+	return id, nil
+}
+
+// GetSysPorts returns the open ports of the system.
+func GetSysPorts(sid int64) ([]*SysPort, error) {
+	ports := []*SysPort{}
+	rows, err := queries[ssGetSysPorts].Query(sid)
+	if err != nil {
+		return ports, newErrFromErr(err, execNames[ssGetSysPorts])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p SysPort
+		if err := rows.Scan(&p.Port, &p.Protocol, &p.Service); err != nil {
+			return ports, newErrFromErr(err, execNames[ssGetSysPorts], "rows.Scan")
+		}
+		ports = append(ports, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return ports, newErrFromErr(err, execNames[ssGetSysPorts])
+	}
+	return ports, nil
+}
+
+// GetSysVulnIDs returns a pointer to a slice of the vulnids affecting the system.
+func GetSysVulnIDs(sid int64) (*[]int64, error) {
+	return execGetRowsInt(ssGetSysVulnIDs, sid)
+}
+
 // GetSystem returns a system struct matching the given systemID.
 func GetSystem(sid int64) (*System, error) {
 	var sys System
@@ -588,6 +1742,79 @@ func GetSystemIDtx(tx *sql.Tx, sysname string) (int64, error) {
 	return id, nil
 }
 
+// GetTask returns the Task with the given taskid.
+func GetTask(tid int64) (*Task, error) {
+	var t Task
+	err := queries[ssGetTask].QueryRow(tid).Scan(&t.ID, &t.VulnID, &t.Title, &t.Assignee, &t.Due, &t.Done, &t.System)
+	if err != nil {
+		return &t, newErrFromErr(err, execNames[ssGetTask])
+	}
+	return &t, nil
+}
+
+// GetTasks returns a slice of the tasks of the vulnerability in the order they were added.
+func GetTasks(vid int64) ([]*Task, error) {
+	tasks := []*Task{}
+	rows, err := queries[ssGetTasks].Query(vid)
+	if err != nil {
+		return tasks, newErrFromErr(err, execNames[ssGetTasks])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t Task
+		if err := rows.Scan(&t.ID, &t.VulnID, &t.Title, &t.Assignee, &t.Due, &t.Done, &t.System); err != nil {
+			return tasks, newErrFromErr(err, execNames[ssGetTasks], "rows.Scan")
+		}
+		tasks = append(tasks, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return tasks, newErrFromErr(err, execNames[ssGetTasks])
+	}
+	return tasks, nil
+}
+
+// GetTemplate returns the Template with the given templateid.
+func GetTemplate(tid int64) (*Template, error) {
+	var t Template
+	t.ID = tid
+	err := queries[ssGetTemplate].QueryRow(tid).Scan(&t.Name, &t.Summary, &t.Test, &t.Mitigation, &t.Cvss, &t.CvssLink, &t.CorpScore)
+	if err != nil {
+		return &t, newErrFromErr(err, execNames[ssGetTemplate])
+	}
+	return &t, nil
+}
+
+// GetTemplateRefs returns a pointer to a slice of the reference URLs of the template.
+func GetTemplateRefs(tid int64) (*[]string, error) {
+	refs, err := execGetRowsStr(ssGetTemplateRefs, tid)
+	if !IsNilErr(err) {
+		var s []string
+		return &s, newErrFromErr(err, execNames[ssGetTemplateRefs])
+	}
+	return refs, nil
+}
+
+// GetTemplates returns a slice of all of the templates ordered by name. The references are not filled in.
+func GetTemplates() ([]*Template, error) {
+	tmpls := []*Template{}
+	rows, err := queries[ssGetTemplates].Query()
+	if err != nil {
+		return tmpls, newErrFromErr(err, execNames[ssGetTemplates])
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t Template
+		if err := rows.Scan(&t.ID, &t.Name, &t.Summary, &t.Test, &t.Mitigation, &t.Cvss, &t.CvssLink, &t.CorpScore); err != nil {
+			return tmpls, newErrFromErr(err, execNames[ssGetTemplates], "rows.Scan")
+		}
+		tmpls = append(tmpls, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return tmpls, newErrFromErr(err, execNames[ssGetTemplates])
+	}
+	return tmpls, nil
+}
+
 // GetTickets returns a pointer to a slice of tickets associated with the vulnid.
 func GetTickets(vid int64) (*[]string, error) {
 	ticks, err := execGetRowsStr(ssGetTickets, vid)
@@ -651,6 +1878,17 @@ func GetVulnID(vname string) (int64, error) {
 	return id, nil
 }
 
+// GetVulnIDsByCve returns a pointer to a slice of the vulnids that list the given CVE. The CVE is
+// matched without regard to case.
+func GetVulnIDsByCve(cve string) (*[]int64, error) {
+	ids, err := execGetRowsInt(ssGetVulnIDsByCve, cve)
+	if !IsNilErr(err) {
+		var i []int64
+		return &i, newErrFromErr(err, execNames[ssGetVulnIDsByCve])
+	}
+	return ids, nil
+}
+
 // GetVulnIDtx returns the vulnid associated with the vname.
 func GetVulnIDtx(tx *sql.Tx, vulnname string) (int64, error) {
 	var id int64
@@ -666,19 +1904,50 @@ func InsertAffected(tx *sql.Tx, vid, sid int64, mitigated bool) Err {
 	return execMutation(tx, ssInsertAffected, vid, sid, mitigated)
 }
 
+// InsertAffectedPkg inserts the vulnerable package of the affected row (vid, sid) into the affectedpkgs table.
+func InsertAffectedPkg(tx *sql.Tx, vid, sid int64, p *AffectedPkg) Err {
+	return execMutation(tx, ssInsertAffectedPkg, vid, sid, p.Package, p.Installed, p.Fixed)
+}
+
+// InsertAttachment will insert a new row into the attachments table and set the ID of att.
+func InsertAttachment(tx *sql.Tx, att *Attachment) Err {
+	err := tx.Stmt(queries[ssInsertAttachment]).QueryRow(att.VulnID, att.SysID, att.EmpID, att.FileName, att.MimeType, att.Size, att.Sha256, att.StoreKey, att.Added).Scan(&att.ID)
+	if err != nil {
+		return newErrFromErr(err, execNames[ssInsertAttachment])
+	}
+	return Err{}
+}
+
+// InsertCatalogCwe inserts a row into the cve_catalog_cwes table for (cve, cwe). A no rows updated error is returned
+// if the row already exists.
+func InsertCatalogCwe(tx *sql.Tx, cve, cwe string) Err {
+	return execMutation(tx, ssInsertCatalogCwe, cve, cwe)
+}
+
+// InsertCatalogRef inserts a row into the cve_catalog_refs table for (cve, url). A no rows updated error is returned
+// if the row already exists.
+func InsertCatalogRef(tx *sql.Tx, cve, url string) Err {
+	return execMutation(tx, ssInsertCatalogRef, cve, url)
+}
+
 // InsertCve will insert a new row into the cves table with key (vid, cve).
 func InsertCve(tx *sql.Tx, vid int64, cve string) Err {
 	return execMutation(tx, ssInsertCve, vid, cve)
 }
 
+// InsertCwe will insert a new row into the cwes table with key (vid, cwe).
+func InsertCwe(tx *sql.Tx, vid int64, cwe string) Err {
+	return execMutation(tx, ssInsertCwe, vid, cwe)
+}
+
 // InsertDates inserts the dates published, initiated, and mitigated.
 func InsertDates(tx *sql.Tx, vid int64, ini time.Time, pub, mit VarsNullTime) error {
 	return execMutation(tx, ssInsertDates, vid, pub, ini, mit)
 }
 
 // InsertEmployee inserts the employee's first name, last name, and email.
-func InsertEmployee(tx *sql.Tx, first, last, email, username string, level int) error {
-	return execMutation(tx, ssInsertEmployee, first, last, email, username, level)
+func InsertEmployee(tx *sql.Tx, first, last, email, username, role string) error {
+	return execMutation(tx, ssInsertEmployee, first, last, email, username, role)
 }
 
 // InsertExploit inserts a row into the exploits table for vulnid
@@ -691,9 +1960,35 @@ func InsertImpact(tx *sql.Tx, vid int64, cvss, corpscore float32, cvsslink VarsN
 	return execMutation(tx, ssInsertImpact, vid, cvss, cvsslink, corpscore)
 }
 
-// InsertNote inserts the vulnid, empid, date added, and note.
-func InsertNote(tx *sql.Tx, vid, eid int64, note string) Err {
-	return execMutation(tx, ssInsertNote, vid, eid, time.Now(), note)
+// InsertKevMatch records that the CVE of the vulnerability was found in the KEV catalog.
+func InsertKevMatch(tx *sql.Tx, m *KevMatch) Err {
+	return execMutation(tx, ssInsertKevMatch, m.VulnID, m.Cve, m.Matched)
+}
+
+// InsertMerge records that the vulnerability dropid (named dropname) was merged into keepid.
+func InsertMerge(tx *sql.Tx, dropid int64, dropname string, keepid int64, merged time.Time) Err {
+	return execMutation(tx, ssInsertMerge, dropid, dropname, keepid, merged)
+}
+
+// InsertNote inserts the vulnid, empid, date added, note, and parent note and returns the new noteid.
+// Pass an invalid parent for a note that is not a reply.
+func InsertNote(tx *sql.Tx, vid, eid int64, note string, parent VarsNullInt64) (int64, Err) {
+	var id int64
+	err := tx.Stmt(queries[ssInsertNote]).QueryRow(vid, eid, time.Now(), note, parent).Scan(&id)
+	if err != nil {
+		return id, newErrFromErr(err, execNames[ssInsertNote])
+	}
+	return id, Err{}
+}
+
+// InsertNoteMention inserts a row into the notementions table for (noteid, empid).
+func InsertNoteMention(tx *sql.Tx, noteid, eid int64) Err {
+	return execMutation(tx, ssInsertNoteMention, noteid, eid)
+}
+
+// InsertNoteRevision copies the current text of the note into the noterevisions table.
+func InsertNoteRevision(tx *sql.Tx, noteid int64) Err {
+	return execMutation(tx, ssInsertNoteRevision, noteid, time.Now())
 }
 
 // InsertRef will insert a new row into the ref table with key (vid, url).
@@ -701,11 +1996,66 @@ func InsertRef(tx *sql.Tx, vid int64, url string) Err {
 	return execMutation(tx, ssInsertRefers, vid, url)
 }
 
+// InsertRelated will insert a link of the given type from the vulnerability vid to rvid.
+func InsertRelated(tx *sql.Tx, vid, rvid int64, reltype string) Err {
+	return execMutation(tx, ssInsertRelated, vid, rvid, reltype)
+}
+
+// InsertRolePermission will grant the permission to the role in the rolepermissions table.
+func InsertRolePermission(tx *sql.Tx, role, perm string) Err {
+	return execMutation(tx, ssInsertRolePermission, role, perm)
+}
+
+// InsertScanRef maps the plugin of the scanner to the vulnerability in the scanrefs table.
+func InsertScanRef(tx *sql.Tx, scanner, pluginid string, vid int64) Err {
+	return execMutation(tx, ssInsertScanRef, scanner, pluginid, vid)
+}
+
+// InsertSysAddr adds the address to the system in the sysaddrs table. Addresses are stored in lower case.
+func InsertSysAddr(tx *sql.Tx, sid int64, addr string) Err {
+	return execMutation(tx, ssInsertSysAddr, sid, addr)
+}
+
+// InsertSysComponent inserts the component of the system into the syscomponents table.
+func InsertSysComponent(tx *sql.Tx, sid int64, c *Component) Err {
+	return execMutation(tx, ssInsertSysComponent, sid, c.Name, c.Version, c.Purl)
+}
+
+// InsertSysPort adds the open port to the system in the sysports table.
+func InsertSysPort(tx *sql.Tx, sid int64, p *SysPort) Err {
+	return execMutation(tx, ssInsertSysPort, sid, p.Port, p.Protocol, p.Service)
+}
+
 // InsertSystem will add a new system to the database.
 func InsertSystem(tx *sql.Tx, sys *System) Err {
 	return execMutation(tx, ssInsertSystem, sys.Name, sys.Type, sys.OpSys, sys.Location, sys.Description, "active")
 }
 
+// InsertTask inserts the task into the tasks table and returns the new taskid.
+func InsertTask(tx *sql.Tx, t *Task) (int64, Err) {
+	var id int64
+	err := tx.Stmt(queries[ssInsertTask]).QueryRow(t.VulnID, t.Title, t.Assignee, t.Due, t.Done, t.System).Scan(&id)
+	if err != nil {
+		return id, newErrFromErr(err, execNames[ssInsertTask])
+	}
+	return id, Err{}
+}
+
+// InsertTemplate inserts the template into the templates table and returns the new templateid.
+func InsertTemplate(tx *sql.Tx, t *Template) (int64, Err) {
+	var id int64
+	err := tx.Stmt(queries[ssInsertTemplate]).QueryRow(t.Name, t.Summary, t.Test, t.Mitigation, t.Cvss, t.CvssLink, t.CorpScore).Scan(&id)
+	if err != nil {
+		return id, newErrFromErr(err, execNames[ssInsertTemplate])
+	}
+	return id, Err{}
+}
+
+// InsertTemplateRef inserts a row into the templaterefs table for (templateid, url).
+func InsertTemplateRef(tx *sql.Tx, tid int64, url string) Err {
+	return execMutation(tx, ssInsertTemplateRef, tid, url)
+}
+
 // InsertTicket will insert a new row into the ticket table with key (vid, ticket).
 func InsertTicket(tx *sql.Tx, vid int64, ticket string) Err {
 	return execMutation(tx, ssInsertTicket, vid, ticket)
@@ -729,6 +2079,13 @@ func IsVulnOpen(vid int64) (bool, error) {
 	return true, nil
 }
 
+// MergeCatalogEntry inserts the entry into the cve_catalog table or fills in the summary, published date and CVSS
+// vector of the existing row where they are empty. The values already in the row, and its last modified date, are
+// kept. A no rows updated error is returned if there was nothing to fill in.
+func MergeCatalogEntry(tx *sql.Tx, e *CatalogEntry) Err {
+	return execMutation(tx, ssMergeCatalogEntry, e.Cve, e.Summary, e.Published, e.LastModified, e.CvssVector, e.CvssScore)
+}
+
 // NameIsAvailable returns true if the vulnerability name is available, false otherwise.
 func NameIsAvailable(obj, name string) (bool, error) {
 	var id int64
@@ -740,6 +2097,8 @@ func NameIsAvailable(obj, name string) (bool, error) {
 		ss = ssCheckVulnName
 	case "sys":
 		ss = ssCheckSysName
+	case "template":
+		ss = ssCheckTemplateName
 	default:
 		return false, newErr(unknownType, "NameIsAvailable")
 	}
@@ -820,6 +2179,11 @@ func UpdateAffected(tx *sql.Tx, vid, sid int64, mit bool) Err {
 	return execMutation(tx, ssUpdateAffected, mit, vid, sid)
 }
 
+// UpdateAttachmentsVuln moves the attachments of the vulnerability from to the vulnerability to.
+func UpdateAttachmentsVuln(tx *sql.Tx, from, to int64) Err {
+	return execMutation(tx, ssUpdateAttachmentsVuln, to, from)
+}
+
 // UpdateCve will update the CVE associated with the (vulnid, oldCve) row to newCve.
 func UpdateCve(tx *sql.Tx, vid int64, oldCve, newCve string) Err {
 	return execMutation(tx, ssUpdateCve, newCve, vid, oldCve)
@@ -840,6 +2204,12 @@ func UpdateCorpScore(tx *sql.Tx, vid int64, cscore float32) Err {
 	return execMutation(tx, ssUpdateCorpScore, cscore, vid)
 }
 
+// UpdateEmpActive will update the active flag and deactivation date of the employee with the given ID.
+// The deactivation date should be NULL when the employee is active.
+func UpdateEmpActive(tx *sql.Tx, eid int64, active bool, deactivated VarsNullTime) Err {
+	return execMutation(tx, ssUpdateEmpActive, active, deactivated, eid)
+}
+
 // UpdateEmpEmail will update the email of the employee with the given ID.
 func UpdateEmpEmail(tx *sql.Tx, eid int64, email string) Err {
 	return execMutation(tx, ssUpdateEmpEmail, email, eid)
@@ -850,16 +2220,16 @@ func UpdateEmpFname(tx *sql.Tx, eid int64, name string) Err {
 	return execMutation(tx, ssUpdateEmpFname, name, eid)
 }
 
-// UpdateEmpLevel will update the level of the employee with the given ID.
-func UpdateEmpLevel(tx *sql.Tx, eid int64, level int) Err {
-	return execMutation(tx, ssUpdateEmpLevel, level, eid)
-}
-
 // UpdateEmpLname will update the last name of the employee with the given ID.
 func UpdateEmpLname(tx *sql.Tx, eid int64, name string) Err {
 	return execMutation(tx, ssUpdateEmpLname, name, eid)
 }
 
+// UpdateEmpRole will update the role of the employee with the given ID.
+func UpdateEmpRole(tx *sql.Tx, eid int64, role string) Err {
+	return execMutation(tx, ssUpdateEmpRole, role, eid)
+}
+
 // UpdateEmpUname will update the username of the employee with the given ID.
 func UpdateEmpUname(tx *sql.Tx, eid int64, uname string) Err {
 	return execMutation(tx, ssUpdateEmpUname, uname, eid)
@@ -888,11 +2258,22 @@ func UpdateInitiator(tx *sql.Tx, vid, initiator int64) Err {
 	return execMutation(tx, ssUpdateInitiator, initiator, vid)
 }
 
+// UpdateInitiatorOpen will change the initiator of all of the open vulnerability assessments started by
+// the employee with ID from to the employee with ID to.
+func UpdateInitiatorOpen(tx *sql.Tx, from, to int64) Err {
+	return execMutation(tx, ssUpdateInitiatorOpen, to, from)
+}
+
 // UpdateInitDate will update the date that the vulnerability assessment was initiated for the given vulnerability ID.
 func UpdateInitDate(tx *sql.Tx, vid int64, initDate time.Time) Err {
 	return execMutation(tx, ssUpdateInitDate, initDate, vid)
 }
 
+// UpdateMerges points the merge records that redirect to the vulnerability from at the vulnerability to.
+func UpdateMerges(tx *sql.Tx, from, to int64) Err {
+	return execMutation(tx, ssUpdateMerges, to, from)
+}
+
 // UpdateMitDate will update the date that the vulnerability assessment was mitigated for the given vulnerability ID.
 // To set the mitigation date to NULL, pass in an empty string for mitDate.
 func UpdateMitDate(tx *sql.Tx, vid int64, mitDate VarsNullTime) Err {
@@ -909,6 +2290,22 @@ func UpdateNote(tx *sql.Tx, nid int64, note string) Err {
 	return execMutation(tx, ssUpdateNote, note, nid)
 }
 
+// UpdateNoteDeleted will update the deleted date for the given noteid.
+// To restore the note, pass in an invalid VarsNullTime.
+func UpdateNoteDeleted(tx *sql.Tx, nid int64, deleted VarsNullTime) Err {
+	return execMutation(tx, ssUpdateNoteDeleted, deleted, nid)
+}
+
+// UpdateNotePublic will update whether the note with the given noteid is public.
+func UpdateNotePublic(tx *sql.Tx, nid int64, public bool) Err {
+	return execMutation(tx, ssUpdateNotePublic, public, nid)
+}
+
+// UpdateNotesVuln moves the notes of the vulnerability from to the vulnerability to.
+func UpdateNotesVuln(tx *sql.Tx, from, to int64) Err {
+	return execMutation(tx, ssUpdateNotesVuln, to, from)
+}
+
 // UpdatePubDate will update the date that the vulnerability was published for the given vulnerability ID.
 // To set the published date to NULL, pass in an empty string for pubDate.
 func UpdatePubDate(tx *sql.Tx, vid int64, pubDate VarsNullTime) Err {
@@ -920,6 +2317,11 @@ func UpdateRefers(tx *sql.Tx, vid int64, oldURL, newURL string) Err {
 	return execMutation(tx, ssUpdateRefers, newURL, vid, oldURL)
 }
 
+// UpdateScanRefsVuln maps the scanner plugins mapped to the vulnerability from to the vulnerability to.
+func UpdateScanRefsVuln(tx *sql.Tx, from, to int64) Err {
+	return execMutation(tx, ssUpdateScanRefsVuln, to, from)
+}
+
 // UpdateSummary will update the summary associated with the vulnerability ID.
 func UpdateSummary(tx *sql.Tx, vid int64, summary string) Err {
 	return execMutation(tx, ssUpdateSummary, summary, vid)
@@ -955,6 +2357,26 @@ func UpdateSysState(tx *sql.Tx, sid int64, state string) Err {
 	return execMutation(tx, ssUpdateSysState, state, sid)
 }
 
+// UpdateTask will update the title, assignee, due date, done flag and system of the task with the ID of t.
+func UpdateTask(tx *sql.Tx, t *Task) Err {
+	return execMutation(tx, ssUpdateTask, t.Title, t.Assignee, t.Due, t.Done, t.System, t.ID)
+}
+
+// UpdateTasksSysNull removes the system scope from the tasks scoped to the given sysid.
+func UpdateTasksSysNull(tx *sql.Tx, sid int64) Err {
+	return execMutation(tx, ssUpdateTasksSysNull, sid)
+}
+
+// UpdateTasksVuln moves the tasks of the vulnerability from to the vulnerability to.
+func UpdateTasksVuln(tx *sql.Tx, from, to int64) Err {
+	return execMutation(tx, ssUpdateTasksVuln, to, from)
+}
+
+// UpdateTemplate will update the fields of the template with the ID of t. The references are not updated.
+func UpdateTemplate(tx *sql.Tx, t *Template) Err {
+	return execMutation(tx, ssUpdateTemplate, t.Name, t.Summary, t.Test, t.Mitigation, t.Cvss, t.CvssLink, t.CorpScore, t.ID)
+}
+
 // UpdateTicket will update the ticket associated with the (vid, oldTicket) row to newTicket.
 func UpdateTicket(tx *sql.Tx, vid int64, oldTicket, newTicket string) Err {
 	return execMutation(tx, ssUpdateTicket, newTicket, vid, oldTicket)
@@ -970,6 +2392,29 @@ func UpdateVulnName(tx *sql.Tx, vid int64, vname string) Err {
 	return execMutation(tx, ssUpdateVulnName, vname, vid)
 }
 
+// UpsertCatalogEntry inserts the entry into the cve_catalog table or updates the existing row if
+// the entry was modified more recently. A no rows updated error is returned if the existing row is
+// already up to date.
+func UpsertCatalogEntry(tx *sql.Tx, e *CatalogEntry) Err {
+	return execMutation(tx, ssUpsertCatalogEntry, e.Cve, e.Summary, e.Published, e.LastModified, e.CvssVector, e.CvssScore)
+}
+
+// UpsertEpssScore inserts the score into the epss table or updates the existing row if the score is from a newer
+// model date. A no rows updated error is returned if the existing row is already up to date.
+func UpsertEpssScore(tx *sql.Tx, s *EpssScore) Err {
+	return execMutation(tx, ssUpsertEpssScore, s.Cve, s.Score, s.Percentile, s.ModelDate, s.ModelVersion)
+}
+
+// UpsertKevEntry inserts the entry into the kev table or replaces the existing row.
+func UpsertKevEntry(tx *sql.Tx, e *KevEntry) Err {
+	return execMutation(tx, ssUpsertKevEntry, e.Cve, e.Vendor, e.Product, e.Name, e.Action, e.DateAdded, e.DueDate, e.Ransomware)
+}
+
+// UpsertScanMitigation records the scan evidence of the affected row (vid, sid), replacing the existing evidence.
+func UpsertScanMitigation(tx *sql.Tx, vid, sid int64, m *ScanMitigation) Err {
+	return execMutation(tx, ssUpsertScanMitigation, vid, sid, m.Scanner, m.Scan, m.Mitigated)
+}
+
 // execMutation executes the query referenced by ss in the queries map and returns any errors.
 func execMutation(tx *sql.Tx, ss sqlStatement, args ...interface{}) Err {
 	var err Err
@@ -1025,6 +2470,27 @@ func execGetRowsStr(ss sqlStatement, args ...interface{}) (*[]string, error) {
 	return &res, nil
 }
 
+// execGetRowsAttach executes the query referenced by ss in the queries map and returns a slice of pointers to Attachment and an error.
+func execGetRowsAttach(ss sqlStatement, args ...interface{}) ([]*Attachment, error) {
+	res := []*Attachment{}
+	rows, err := queries[ss].Query(args...)
+	if err != nil {
+		return res, newErrFromErr(err, execNames[ss], "execGetRowsAttach")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.ID, &a.VulnID, &a.SysID, &a.EmpID, &a.FileName, &a.MimeType, &a.Size, &a.Sha256, &a.StoreKey, &a.Added); err != nil {
+			return res, newErrFromErr(err, execNames[ss], "execGetRowsAttach", "rows.Scan")
+		}
+		res = append(res, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return res, newErrFromErr(err, execNames[ss], "execGetRowsAttach", "rows.Err")
+	}
+	return res, nil
+}
+
 // execGetRowsSys executes the query referenced by ss in the queries map and returns a pointer to a slice of System and an error.
 func execGetRowsSys(ss sqlStatement, args ...interface{}) ([]*System, error) {
 	res := []*System{}